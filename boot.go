@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// bootDevices는 부팅 가능한 장치 ID를 기본 순서(디스크, CD/DVD, 네트워크)로 반환합니다.
+// ID는 명령줄의 -device id(disk0-dev 등)와 netdev(net0)에서 따옴
+func bootDevices(config VMConfig) []string {
+	var ids []string
+	for i := range parseDiskConfigs(config.Disk) {
+		ids = append(ids, fmt.Sprintf("disk%d", i))
+	}
+	for i := range parseCDROMConfigs(config.CDROM) {
+		ids = append(ids, fmt.Sprintf("cd%d", i))
+	}
+	if config.Network != "none" && nicModel(vmMachine(config)) != "" {
+		ids = append(ids, "net0")
+	}
+	return ids
+}
+
+// syncBootOrder는 저장된 순서에서 없어진 장치를 빼고 새 장치를 뒤에 붙입니다.
+func syncBootOrder(order, devices []string) []string {
+	exists := make(map[string]bool)
+	for _, id := range devices {
+		exists[id] = true
+	}
+	var result []string
+	seen := make(map[string]bool)
+	for _, id := range order {
+		if exists[id] && !seen[id] {
+			result = append(result, id)
+			seen[id] = true
+		}
+	}
+	for _, id := range devices {
+		if !seen[id] {
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+// bootIndexes는 장치 ID별 bootindex를 계산합니다.
+// 부팅 순서나 1회 부팅 장치가 없으면 nil을 반환해 펌웨어 기본 순서를 따릅니다.
+func bootIndexes(config VMConfig) map[string]int {
+	if config.BootOrder == "" && config.BootOnce == "" {
+		return nil
+	}
+	devices := bootDevices(config)
+	var order []string
+	if config.BootOrder != "" {
+		order = strings.Split(config.BootOrder, ",")
+	}
+	// 1회 부팅 장치는 이번 실행에서만 맨 앞에 둠
+	if config.BootOnce != "" {
+		order = append([]string{config.BootOnce}, order...)
+	}
+	indexes := make(map[string]int)
+	for i, id := range syncBootOrder(order, devices) {
+		indexes[id] = i + 1
+	}
+	return indexes
+}
+
+// "-boot menu=on,splash-time=5000" (SeaBIOS/OVMF 공통)
+func bootMenuArgs(config VMConfig) []string {
+	if config.BootMenu != "true" {
+		return nil
+	}
+	opts := "menu=on"
+	if config.BootMenuTimeout != "" {
+		opts += ",splash-time=" + config.BootMenuTimeout
+	}
+	return []string{"-boot", opts}
+}
+
+// 부팅 순서 목록에 표시할 장치 이름
+func bootDeviceLabel(config VMConfig, id string) string {
+	var index int
+	switch {
+	case strings.HasPrefix(id, "disk"):
+		fmt.Sscanf(id, "disk%d", &index)
+		disks := parseDiskConfigs(config.Disk)
+		if index < len(disks) {
+			return fmt.Sprintf("하드디스크 %d (%s)", index+1, disks[index].Path)
+		}
+	case strings.HasPrefix(id, "cd"):
+		fmt.Sscanf(id, "cd%d", &index)
+		cdroms := parseCDROMConfigs(config.CDROM)
+		if index < len(cdroms) {
+			if cdroms[index].Path == "" {
+				return fmt.Sprintf("CD/DVD %d (비어 있음)", index+1)
+			}
+			return fmt.Sprintf("CD/DVD %d (%s)", index+1, cdroms[index].Path)
+		}
+	case id == "net0":
+		return "네트워크 (PXE)"
+	}
+	return id
+}
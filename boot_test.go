@@ -0,0 +1,56 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBootIndexes(t *testing.T) {
+	config := VMConfig{
+		Machine: "q35",
+		Disk:    formatDiskConfigs([]diskConfig{{Type: "QCOW2", Path: "a.qcow2"}, {Type: "RAW", Path: "b.img"}}),
+		CDROM:   formatCDROMConfigs([]cdromConfig{{Path: "install.iso"}}),
+	}
+	if got := bootIndexes(config); got != nil {
+		t.Errorf("순서가 없는데 %v", got)
+	}
+	if got, want := bootDevices(config), []string{"disk0", "disk1", "cd0", "net0"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("bootDevices = %q, want %q", got, want)
+	}
+
+	// 없어진 장치(cd1)와 중복은 빼고, 새 장치는 뒤에
+	config.BootOrder = "cd1,disk1,cd0,disk1"
+	want := map[string]int{"disk1": 1, "cd0": 2, "disk0": 3, "net0": 4}
+	if got := bootIndexes(config); !reflect.DeepEqual(got, want) {
+		t.Errorf("bootIndexes = %v, want %v", got, want)
+	}
+
+	// 1회 부팅 장치는 맨 앞으로
+	config.BootOnce = "net0"
+	want = map[string]int{"net0": 1, "disk1": 2, "cd0": 3, "disk0": 4}
+	if got := bootIndexes(config); !reflect.DeepEqual(got, want) {
+		t.Errorf("BootOnce: bootIndexes = %v, want %v", got, want)
+	}
+
+	// NIC가 없는 머신
+	config = VMConfig{Machine: "microbit", BootOnce: "net0", Disk: config.Disk}
+	if got := bootIndexes(config); !reflect.DeepEqual(got, map[string]int{"disk0": 1, "disk1": 2}) {
+		t.Errorf("microbit: bootIndexes = %v", got)
+	}
+}
+
+func TestBootMenuArgs(t *testing.T) {
+	for _, tt := range []struct {
+		config VMConfig
+		want   []string
+	}{
+		{VMConfig{}, nil},
+		{VMConfig{BootMenuTimeout: "3000"}, nil},
+		{VMConfig{BootMenu: "true"}, []string{"-boot", "menu=on"}},
+		{VMConfig{BootMenu: "true", BootMenuTimeout: "3000"}, []string{"-boot", "menu=on,splash-time=3000"}},
+	} {
+		if got := bootMenuArgs(tt.config); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("bootMenuArgs(%+v) = %q, want %q", tt.config, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,407 @@
+package main
+
+import (
+	"archive/tar"
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 가상머신 묶음(.vmbundle)은 다른 컴퓨터로 옮기기 위한 tar 파일입니다.
+//
+//	vm.conf          경로를 묶음 안의 파일 이름으로 바꾼 설정
+//	disk1.qcow2 ...  디스크 (기반 이미지가 있으면 하나로 병합)
+//	kernel-*, nvram* 직접 커널 부팅 파일, UEFI 변수 저장소
+//	tpm-*            TPM 상태 (swtpm 상태 폴더의 파일, 가져오면 tpm 폴더에 풂)
+//	manifest.sha256  파일마다 "해시  이름" (sha256sum -c로 확인 가능)
+const (
+	bundleConfigName   = "vm.conf"
+	bundleTPMPrefix    = "tpm-"
+	bundleManifestName = "manifest.sha256"
+)
+
+// rewriteConfigPaths는 설정 안의 파일 경로를 rewrite 결과로 바꿉니다.
+// role은 disk, kernel, initrd, dtb, nvram(UEFI 변수 저장소, 쓰기 가능한 pflash),
+// firmware(읽기 전용 pflash), tpm(swtpm 소켓), seed(cloud-init seed ISO), unattend(Windows 응답 파일 ISO),
+// agent(게스트 에이전트 소켓)입니다.
+// CD-ROM의 ISO는 바꾸지 않습니다.
+func rewriteConfigPaths(config VMConfig, rewrite func(path, role string) (string, error)) (VMConfig, error) {
+	var err error
+	disks := parseDiskConfigs(config.Disk)
+	for i := range disks {
+		if disks[i].Path, err = rewrite(disks[i].Path, "disk"); err != nil {
+			return config, err
+		}
+	}
+	config.Disk = formatDiskConfigs(disks)
+	for _, f := range []struct {
+		path *string
+		role string
+	}{{&config.Kernel, "kernel"}, {&config.Initrd, "initrd"}, {&config.DTB, "dtb"}, {&config.NVRAM, "nvram"}, {&config.TPM, "tpm"}, {&config.CloudInit.Seed, "seed"}, {&config.Unattend.Media, "unattend"}, {&config.GuestAgent, "agent"}} {
+		if *f.path == "" {
+			continue
+		}
+		if *f.path, err = rewrite(*f.path, f.role); err != nil {
+			return config, err
+		}
+	}
+
+	// 추가 인자의 -drive if=pflash,file=...
+	if config.ExtraArgs == "" {
+		return config, nil
+	}
+	args, err := splitArgs(config.ExtraArgs)
+	if err != nil {
+		return config, err
+	}
+	changed := false
+	for i := 0; i+1 < len(args); i++ {
+		if normalizeOption(args[i]) != "-drive" {
+			continue
+		}
+		items := splitOptionList(args[i+1])
+		_, props := optionProps(args[i+1])
+		if props["if"] != "pflash" || props["file"] == "" {
+			continue
+		}
+		role := "nvram"
+		if props["readonly"] == "on" {
+			role = "firmware"
+		}
+		for j, item := range items {
+			if file, ok := strings.CutPrefix(item, "file="); ok {
+				newFile, err := rewrite(file, role)
+				if err != nil {
+					return config, err
+				}
+				items[j] = "file=" + newFile
+				changed = changed || newFile != file
+			}
+		}
+		for j := range items {
+			items[j] = escapeOptionValue(items[j])
+		}
+		args[i+1] = strings.Join(items, ",")
+		i++
+	}
+	if changed {
+		config.ExtraArgs = joinArgs(args)
+	}
+	return config, nil
+}
+
+// exportBundle은 설정과 파일을 path에 가상머신 묶음으로 저장합니다.
+func exportBundle(ctx context.Context, config VMConfig, path string, report func(float64)) ([]string, error) {
+	var notes []string
+	tmp, err := os.MkdirTemp("", "goqemu-bundle-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	// 묶음에 넣을 파일 (src → 묶음 안 이름)
+	type bundleFile struct {
+		src, name string
+		disk      *diskConfig // 기반 이미지를 병합해야 하는 디스크
+	}
+	var files []bundleFile
+	disks := parseDiskConfigs(config.Disk)
+	diskIndex, nvramIndex := 0, 0
+	bundled, err := rewriteConfigPaths(config, func(src, role string) (string, error) {
+		var name string
+		var disk *diskConfig
+		switch role {
+		case "firmware":
+			notes = append(notes, "펌웨어 코드("+filepath.Base(src)+")는 QEMU와 함께 설치되므로 묶음에 넣지 않았습니다.")
+			return src, nil
+		case "tpm", "agent", "seed", "unattend":
+			// 소켓은 파일이 아니고 seed, 응답 파일 ISO는 설정으로 다시 만들므로 가져온 폴더 기준 경로만 남김
+			// (TPM 상태 파일은 아래에서 따로 넣음)
+			return filepath.Base(src), nil
+		case "disk":
+			d := disks[diskIndex]
+			diskIndex++
+			name = fmt.Sprintf("disk%d%s", diskIndex, diskFileExt(d.Type))
+			if backingChainText(src) != "" {
+				if d.Encrypted {
+					return "", fmt.Errorf("%s: 기반 이미지가 있는 암호화 디스크는 묶을 수 없습니다.", src)
+				}
+				disk = &d
+				notes = append(notes, filepath.Base(src)+"의 기반 이미지를 하나로 병합했습니다.")
+			}
+		case "nvram":
+			nvramIndex++
+			name = fmt.Sprintf("nvram%d%s", nvramIndex, filepath.Ext(src))
+		default:
+			name = role + "-" + filepath.Base(src)
+		}
+		if _, err := os.Stat(src); err != nil {
+			return "", err
+		}
+		files = append(files, bundleFile{src: src, name: name, disk: disk})
+		return name, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range disks {
+		if d.Encrypted {
+			notes = append(notes, filepath.Base(d.Path)+"는 암호화되어 있습니다. 가져온 컴퓨터에서 암호를 다시 입력해야 합니다.")
+		}
+	}
+	for _, cd := range parseCDROMConfigs(config.CDROM) {
+		if cd.Path != "" {
+			notes = append(notes, cd.Path+" ISO는 묶음에 넣지 않았습니다.")
+		}
+	}
+	if config.TPM != "" {
+		states, err := tpmStateFiles(config.TPM)
+		if err != nil {
+			return nil, err
+		}
+		for _, src := range states {
+			files = append(files, bundleFile{src: src, name: bundleTPMPrefix + filepath.Base(src)})
+		}
+		if len(states) == 0 {
+			notes = append(notes, "TPM 상태 파일이 아직 없습니다 (swtpm을 실행한 적이 없음). 가져온 가상머신의 TPM은 비어 있는 상태로 시작합니다.")
+		}
+	} else if strings.Contains(config.ExtraArgs, "tpm") {
+		notes = append(notes, "추가 인자로 지정한 TPM의 상태는 묶음에 넣지 않았습니다.")
+	}
+
+	// 기반 이미지가 있는 디스크는 임시 폴더에 병합 (진행률 40%)
+	convertWeight, toConvert := 0.0, 0
+	for _, f := range files {
+		if f.disk != nil {
+			convertWeight = 0.4
+			toConvert++
+		}
+	}
+	converted := 0
+	for i, f := range files {
+		if f.disk == nil {
+			continue
+		}
+		dst := filepath.Join(tmp, f.name)
+		err := convertDiskImage(ctx, f.src, qemuImgFormat(f.disk.Type), dst, qemuImgFormat(f.disk.Type), func(percent float64) {
+			report(convertWeight * (float64(converted) + percent/100) / float64(toConvert))
+		})
+		if err != nil {
+			return nil, err
+		}
+		converted++
+		files[i].src = dst
+	}
+
+	// tar: vm.conf, 파일들, 매니페스트 순서 (진행률 나머지)
+	var total, written int64
+	for _, f := range files {
+		if info, err := os.Stat(f.src); err == nil {
+			total += info.Size()
+		}
+	}
+	confPath := filepath.Join(tmp, bundleConfigName)
+	if err := os.WriteFile(confPath, []byte(formatVMConfig(bundled)), 0644); err != nil {
+		return nil, err
+	}
+	out, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	fail := func(err error) ([]string, error) {
+		out.Close()
+		os.Remove(path)
+		return nil, err
+	}
+	tw := tar.NewWriter(out)
+	var manifest strings.Builder
+	add := func(src, name string) error {
+		sum, err := addTarFile(ctx, tw, src, name, func(n int) {
+			written += int64(n)
+			if total > 0 {
+				report(convertWeight + (1-convertWeight)*float64(written)/float64(total))
+			}
+		})
+		fmt.Fprintf(&manifest, "%s  %s\n", sum, name)
+		return err
+	}
+	if err := add(confPath, bundleConfigName); err != nil {
+		return fail(err)
+	}
+	for _, f := range files {
+		if err := add(f.src, f.name); err != nil {
+			return fail(err)
+		}
+	}
+	manifestPath := filepath.Join(tmp, bundleManifestName)
+	if err := os.WriteFile(manifestPath, []byte(manifest.String()), 0644); err != nil {
+		return fail(err)
+	}
+	if _, err := addTarFile(ctx, tw, manifestPath, bundleManifestName, func(int) {}); err != nil {
+		return fail(err)
+	}
+	if err := tw.Close(); err != nil {
+		return fail(err)
+	}
+	return notes, out.Close()
+}
+
+// importBundle은 가상머신 묶음을 destDir에 풀고, 설정의 경로를 destDir 기준으로 바꿉니다.
+// 설정 저장은 호출한 쪽에서 합니다 (saveImportedConfig).
+func importBundle(ctx context.Context, path, name, destDir string, report func(float64)) (VMConfig, []string, error) {
+	var notes []string
+	f, err := os.Open(path)
+	if err != nil {
+		return VMConfig{}, nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return VMConfig{}, nil, err
+	}
+
+	_, statErr := os.Stat(destDir)
+	createdDir := os.IsNotExist(statErr)
+	if err := os.MkdirAll(destDir, os.ModePerm); err != nil {
+		return VMConfig{}, nil, err
+	}
+	var created []string // 만든 순서대로 (폴더가 안의 파일보다 앞)
+	cleanup := func() {
+		for i := len(created) - 1; i >= 0; i-- {
+			os.Remove(created[i])
+		}
+		if createdDir {
+			os.Remove(destDir)
+		}
+	}
+	fail := func(err error) (VMConfig, []string, error) {
+		cleanup()
+		return VMConfig{}, nil, err
+	}
+
+	var read int64
+	tr := tar.NewReader(&progressReader{ctx: ctx, r: f, onRead: func(n int) {
+		read += int64(n)
+		report(float64(read) / float64(info.Size()))
+	}})
+	sums := make(map[string]string)
+	var confData, manifestData []byte
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fail(fmt.Errorf("묶음 파일을 읽을 수 없습니다: %w", err))
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		// 경로 조작을 막기 위해 파일 이름만 사용
+		entry := filepath.Base(hdr.Name)
+		h := sha256.New()
+		switch entry {
+		case bundleConfigName, bundleManifestName:
+			data, err := io.ReadAll(io.TeeReader(tr, h))
+			if err != nil {
+				return fail(err)
+			}
+			if entry == bundleConfigName {
+				confData = data
+			} else {
+				manifestData = data
+			}
+		default:
+			dst := filepath.Join(destDir, entry)
+			// TPM 상태는 tpm 폴더에 원래 이름으로 (swtpm 상태 폴더 = 소켓이 있는 폴더)
+			if state, ok := strings.CutPrefix(entry, bundleTPMPrefix); ok {
+				tpmDir := filepath.Join(destDir, "tpm")
+				if _, err := os.Stat(tpmDir); os.IsNotExist(err) {
+					if err := os.Mkdir(tpmDir, os.ModePerm); err != nil {
+						return fail(err)
+					}
+					created = append(created, tpmDir)
+				}
+				dst = filepath.Join(tpmDir, state)
+			}
+			if _, err := os.Stat(dst); err == nil {
+				return fail(fmt.Errorf("%s 파일이 이미 있습니다.", dst))
+			}
+			out, err := os.Create(dst)
+			if err != nil {
+				return fail(err)
+			}
+			created = append(created, dst)
+			_, err = io.Copy(io.MultiWriter(out, h), tr)
+			out.Close()
+			if err != nil {
+				return fail(err)
+			}
+		}
+		sums[entry] = hex.EncodeToString(h.Sum(nil))
+	}
+	if confData == nil || manifestData == nil {
+		return fail(errors.New("가상머신 묶음 파일이 아닙니다."))
+	}
+
+	// 매니페스트의 모든 파일이 있고 해시가 같아야 함
+	listed := map[string]bool{bundleManifestName: true}
+	scanner := bufio.NewScanner(strings.NewReader(string(manifestData)))
+	for scanner.Scan() {
+		sum, entry, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "  ")
+		if !ok {
+			continue
+		}
+		if got, ok := sums[entry]; !ok {
+			return fail(fmt.Errorf("묶음에 %s 파일이 없습니다.", entry))
+		} else if !strings.EqualFold(got, sum) {
+			return fail(fmt.Errorf("%s 파일의 체크섬이 매니페스트와 다릅니다.", entry))
+		}
+		listed[entry] = true
+	}
+	for entry := range sums {
+		if !listed[entry] {
+			return fail(fmt.Errorf("%s 파일이 매니페스트에 없습니다.", entry))
+		}
+	}
+
+	config, err := rewriteConfigPaths(parseVMConfig(string(confData)), func(p, role string) (string, error) {
+		if p == "" || filepath.IsAbs(p) {
+			return p, nil
+		}
+		if role == "tpm" {
+			return filepath.Join(destDir, "tpm", filepath.Base(p)), nil
+		}
+		return filepath.Join(destDir, filepath.Base(p)), nil
+	})
+	if err != nil {
+		return fail(err)
+	}
+	// swtpm은 상태 폴더를 만들지 않으므로 상태 파일이 없던 묶음도 폴더는 만들어 둠
+	if config.TPM != "" {
+		if err := os.MkdirAll(filepath.Dir(config.TPM), os.ModePerm); err != nil {
+			return fail(err)
+		}
+	}
+	if name != "" {
+		config.Name = name
+	}
+	for _, d := range parseDiskConfigs(config.Disk) {
+		if d.Encrypted {
+			notes = append(notes, filepath.Base(d.Path)+"는 암호화되어 있습니다. 처음 실행할 때 암호를 입력하세요.")
+		}
+	}
+	for _, cd := range parseCDROMConfigs(config.CDROM) {
+		if _, err := os.Stat(cd.Path); cd.Path != "" && err != nil {
+			notes = append(notes, cd.Path+" ISO가 이 컴퓨터에 없습니다. 설정에서 다시 선택하세요.")
+		}
+	}
+	return config, notes, nil
+}
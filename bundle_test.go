@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readTestFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestBundleRoundTrip(t *testing.T) {
+	src := t.TempDir()
+	disk := filepath.Join(src, "os.img")
+	nvram := filepath.Join(src, "nvram", "rt_VARS.fd")
+	tpm := filepath.Join(src, "tpm", "rt", "swtpm-sock")
+	writeTestFile(t, disk, "disk data")
+	writeTestFile(t, nvram, "uefi vars")
+	writeTestFile(t, filepath.Join(filepath.Dir(tpm), "tpm2-00.permall"), "tpm state")
+	writeTestFile(t, filepath.Join(filepath.Dir(tpm), ".lock"), "")
+
+	config := VMConfig{
+		Name: "rt", RAM: "2GB", Machine: "q35", Firmware: "uefi", NVRAM: nvram, TPM: tpm,
+		Disk:  formatDiskConfigs([]diskConfig{{Type: "RAW", Path: disk, Bus: "virtio-blk", Cache: "none"}}),
+		CDROM: formatCDROMConfigs([]cdromConfig{{Bus: "ahci"}}),
+	}
+	bundle := filepath.Join(t.TempDir(), "rt.vmbundle")
+	if _, err := exportBundle(context.Background(), config, bundle, func(float64) {}); err != nil {
+		t.Fatal(err)
+	}
+
+	dest := filepath.Join(t.TempDir(), "imported")
+	got, _, err := importBundle(context.Background(), bundle, "copy", dest, func(float64) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "copy" || got.RAM != config.RAM || got.Machine != config.Machine || got.Firmware != "uefi" {
+		t.Errorf("config = %+v", got)
+	}
+	disks := parseDiskConfigs(got.Disk)
+	if len(disks) != 1 || disks[0].Path != filepath.Join(dest, "disk1.img") || disks[0].Cache != "none" {
+		t.Fatalf("disks = %+v", disks)
+	}
+	if got.CDROM != config.CDROM {
+		t.Errorf("CDROM = %q, want %q", got.CDROM, config.CDROM)
+	}
+	if s := readTestFile(t, disks[0].Path); s != "disk data" {
+		t.Errorf("disk = %q", s)
+	}
+	if got.NVRAM != filepath.Join(dest, "nvram1.fd") || readTestFile(t, got.NVRAM) != "uefi vars" {
+		t.Errorf("NVRAM = %q", got.NVRAM)
+	}
+	// TPM 상태는 tpm 폴더에, 잠금 파일은 빼고
+	if got.TPM != filepath.Join(dest, "tpm", "swtpm-sock") {
+		t.Errorf("TPM = %q", got.TPM)
+	}
+	if s := readTestFile(t, filepath.Join(dest, "tpm", "tpm2-00.permall")); s != "tpm state" {
+		t.Errorf("TPM state = %q", s)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "tpm", ".lock")); !os.IsNotExist(err) {
+		t.Errorf(".lock이 묶음에 들어갔습니다: %v", err)
+	}
+
+	// 같은 폴더에 다시 풀면 실패하고 이미 있던 파일은 그대로
+	if _, _, err := importBundle(context.Background(), bundle, "copy", dest, func(float64) {}); err == nil {
+		t.Error("이미 있는 파일에 덮어썼습니다")
+	}
+	if readTestFile(t, filepath.Join(dest, "tpm", "tpm2-00.permall")) != "tpm state" {
+		t.Error("실패한 가져오기가 기존 TPM 상태를 지웠습니다")
+	}
+}
+
+func TestBundleWithoutTPMState(t *testing.T) {
+	src := t.TempDir()
+	disk := filepath.Join(src, "os.qcow2")
+	writeTestFile(t, disk, "disk")
+	config := VMConfig{
+		Name: "fresh", TPM: filepath.Join(src, "tpm", "fresh", "swtpm-sock"),
+		Disk: formatDiskConfigs([]diskConfig{{Type: "QCOW2", Path: disk}}),
+	}
+	bundle := filepath.Join(t.TempDir(), "fresh.vmbundle")
+	notes, err := exportBundle(context.Background(), config, bundle, func(float64) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notes) == 0 {
+		t.Error("TPM 상태가 없다는 안내가 없습니다")
+	}
+	dest := t.TempDir()
+	got, _, err := importBundle(context.Background(), bundle, "", dest, func(float64) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(filepath.Dir(got.TPM)); err != nil || !info.IsDir() {
+		t.Errorf("TPM 상태 폴더가 없습니다: %v", err)
+	}
+}
+
+func TestRewriteConfigPaths(t *testing.T) {
+	config := VMConfig{
+		Name:   "rw",
+		Disk:   formatDiskConfigs([]diskConfig{{Type: "QCOW2", Path: "/a/os.qcow2", Bus: "virtio-blk"}, {Type: "RAW", Path: "/a/data.img"}}),
+		CDROM:  formatCDROMConfigs([]cdromConfig{{Path: "/iso/install.iso"}}),
+		Kernel: "/a/vmlinuz", Initrd: "/a/initrd", DTB: "/a/board.dtb",
+		NVRAM: "/a/VARS.fd", TPM: "/a/tpm/swtpm-sock", GuestAgent: "/a/qga.sock",
+		CloudInit: cloudInitConfig{Enabled: true, Seed: "/a/seed.iso"},
+		Unattend:  unattendConfig{Enabled: true, Media: "/a/unattend.iso"},
+		ExtraArgs: joinArgs([]string{
+			"-drive", "if=pflash,format=raw,readonly=on,file=/fw/CODE,,secure.fd",
+			"--drive", "file=/a/vars copy.fd,if=pflash,format=raw",
+			"-drive", "file=/a/other.img,if=virtio",
+			"-rtc", "base=localtime",
+		}),
+	}
+	roles := make(map[string]string)
+	got, err := rewriteConfigPaths(config, func(path, role string) (string, error) {
+		roles[path] = role
+		return "/new" + path, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRoles := map[string]string{
+		"/a/os.qcow2": "disk", "/a/data.img": "disk", "/a/vmlinuz": "kernel", "/a/initrd": "initrd", "/a/board.dtb": "dtb",
+		"/a/VARS.fd": "nvram", "/a/tpm/swtpm-sock": "tpm", "/a/qga.sock": "agent", "/a/seed.iso": "seed", "/a/unattend.iso": "unattend",
+		"/fw/CODE,secure.fd": "firmware", "/a/vars copy.fd": "nvram",
+	}
+	if !reflect.DeepEqual(roles, wantRoles) {
+		t.Errorf("roles =\n%v\nwant\n%v", roles, wantRoles)
+	}
+	disks := parseDiskConfigs(got.Disk)
+	if len(disks) != 2 || disks[0].Path != "/new/a/os.qcow2" || disks[0].Bus != "virtio-blk" || disks[1].Path != "/new/a/data.img" {
+		t.Errorf("Disk = %q", got.Disk)
+	}
+	// CD-ROM의 ISO와 pflash가 아닌 -drive는 그대로
+	if got.CDROM != config.CDROM {
+		t.Errorf("CDROM = %q", got.CDROM)
+	}
+	if got.Kernel != "/new/a/vmlinuz" || got.NVRAM != "/new/a/VARS.fd" || got.CloudInit.Seed != "/new/a/seed.iso" || !got.CloudInit.Enabled {
+		t.Errorf("config = %+v", got)
+	}
+	wantExtra := joinArgs([]string{
+		"-drive", "if=pflash,format=raw,readonly=on,file=/new/fw/CODE,,secure.fd",
+		"--drive", "file=/new/a/vars copy.fd,if=pflash,format=raw",
+		"-drive", "file=/a/other.img,if=virtio",
+		"-rtc", "base=localtime",
+	})
+	if got.ExtraArgs != wantExtra {
+		t.Errorf("ExtraArgs = %q, want %q", got.ExtraArgs, wantExtra)
+	}
+
+	// 실패하면 오류를 그대로 돌려줌
+	stop := errors.New("stop")
+	if _, err := rewriteConfigPaths(config, func(path, role string) (string, error) {
+		if role == "firmware" {
+			return "", stop
+		}
+		return path, nil
+	}); err != stop {
+		t.Errorf("err = %v, want %v", err, stop)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import "strings"
+
+// cdromConfig는 VMConfig.CDROM의 CD/DVD 드라이브 한 개입니다.
+// 저장 형식: "ahci:E:\ISO\ubuntu.iso" (경로가 비어 있으면 빈 드라이브)
+type cdromConfig struct {
+	Bus  string // 비어 있으면 머신 종류에 따라 자동
+	Path string
+}
+
+var cdromBusOptions = []string{"ide", "ahci", "virtio-scsi"}
+
+// parseCDROMConfigs는 ';'로 구분된 드라이브 목록을 읽습니다.
+func parseCDROMConfigs(cdrom string) []cdromConfig {
+	var cdroms []cdromConfig
+	if cdrom == "" {
+		return cdroms
+	}
+	for _, item := range strings.Split(cdrom, ";") {
+		bus, path, _ := strings.Cut(item, ":")
+		cdroms = append(cdroms, cdromConfig{Bus: bus, Path: path})
+	}
+	return cdroms
+}
+
+func formatCDROMConfigs(cdroms []cdromConfig) string {
+	var parts []string
+	for _, c := range cdroms {
+		parts = append(parts, c.Bus+":"+c.Path)
+	}
+	return strings.Join(parts, ";")
+}
+
+// 머신 종류별 기본 CD/DVD 버스 (virt는 IDE/AHCI가 없어 virtio-scsi 사용)
+func (c cdromConfig) bus(machine string) string {
+	if c.Bus != "" {
+		return c.Bus
+	}
+	if machine == "virt" {
+		return "virtio-scsi"
+	}
+	return defaultDiskBus(machine)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCDROMConfigs(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []cdromConfig
+	}{
+		{"", nil},
+		{`ahci:E:\ISO\ubuntu.iso`, []cdromConfig{{Bus: "ahci", Path: `E:\ISO\ubuntu.iso`}}},
+		// 빈 드라이브, 자동 버스
+		{`ide:;:C:\x.iso;virtio-scsi:`, []cdromConfig{{Bus: "ide"}, {Path: `C:\x.iso`}, {Bus: "virtio-scsi"}}},
+		{":", []cdromConfig{{}}},
+	}
+	for _, tt := range tests {
+		got := parseCDROMConfigs(tt.in)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseCDROMConfigs(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+		if again := formatCDROMConfigs(got); again != tt.in {
+			t.Errorf("formatCDROMConfigs(%+v) = %q, want %q", got, again, tt.in)
+		}
+	}
+}
+
+func TestCDROMBus(t *testing.T) {
+	for machine, want := range map[string]string{"pc": "ide", "q35": "ahci", "virt": "virtio-scsi", "malta": "ide"} {
+		if got := (cdromConfig{}).bus(machine); got != want {
+			t.Errorf("%s: bus = %q, want %q", machine, got, want)
+		}
+	}
+	if got := (cdromConfig{Bus: "ide"}).bus("q35"); got != "ide" {
+		t.Errorf("지정한 버스 대신 %q", got)
+	}
+}
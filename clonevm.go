@@ -0,0 +1,477 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	sqdialog "github.com/sqweek/dialog"
+)
+
+// 무작위 UUID (버전 4)
+func newVMUUID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// QEMU 기본 OUI(52:54:00)를 사용하는 무작위 MAC 주소
+func newVMMAC() string {
+	b := make([]byte, 3)
+	rand.Read(b)
+	return fmt.Sprintf("52:54:00:%02x:%02x:%02x", b[0], b[1], b[2])
+}
+
+// Windows 경로는 대소문자를 구분하지 않으므로 정규화 후 비교
+func sameDiskPath(a, b string) bool {
+	if absA, err := filepath.Abs(a); err == nil {
+		a = absA
+	}
+	if absB, err := filepath.Abs(b); err == nil {
+		b = absB
+	}
+	return strings.EqualFold(filepath.Clean(a), filepath.Clean(b))
+}
+
+// diskDependents는 path를 기반 이미지로 사용하는 디스크를 "VM 이름: 경로" 형태로 반환합니다.
+func diskDependents(configDir, path string) []string {
+	var dependents []string
+	for _, config := range loadVMConfigs(configDir) {
+		for _, diskPath := range qcow2DiskPaths(config) {
+			if sameDiskPath(diskPath, path) {
+				continue
+			}
+			chain, err := getBackingChain(diskPath)
+			if err != nil || len(chain) < 2 {
+				continue
+			}
+			for _, info := range chain[1:] {
+				if sameDiskPath(info.Filename, path) {
+					dependents = append(dependents, config.Name+": "+diskPath)
+					break
+				}
+			}
+		}
+	}
+	return dependents
+}
+
+// removeDiskFile은 디스크 파일을 삭제하되, 다른 디스크의 기반 이미지이면 먼저 확인을 받습니다.
+func removeDiskFile(configDir, path string, win fyne.Window) {
+	if _, err := os.Stat(path); err != nil {
+		return
+	}
+	dependents := diskDependents(configDir, path)
+	if len(dependents) == 0 {
+		os.Remove(path)
+		deleteDiskPassphrase(path)
+		return
+	}
+	msg := filepath.Base(path) + " 파일은 다음 디스크의 기반 이미지입니다.\n" +
+		strings.Join(dependents, "\n") +
+		"\n삭제하면 연결된 클론을 사용할 수 없습니다. 그래도 삭제하시겠습니까?"
+	dialog.ShowConfirm("기반 이미지 삭제", msg, func(ok bool) {
+		if ok {
+			os.Remove(path)
+			deleteDiskPassphrase(path)
+		}
+	}, win)
+}
+
+// 원본 디스크 경로 옆에 "<새 이름>-<원본 파일명>.qcow2" 로 오버레이 경로를 만듭니다.
+func linkedDiskPath(srcPath, newName string) string {
+	base := filepath.Base(srcPath)
+	base = strings.TrimSuffix(base, filepath.Ext(base))
+	return filepath.Join(filepath.Dir(srcPath), newName+"-"+base+".qcow2")
+}
+
+// clonedConfig는 새 이름과 식별자(UUID, MAC), 새 디스크를 가진 복제 설정을 만들고
+// 가상머신마다 따로 있어야 하는 파일을 준비합니다 (cloneVMFiles 참고).
+// 오류가 나면 만든 파일은 지우며, 디스크는 호출한 쪽에서 지웁니다.
+func clonedConfig(configDir string, src VMConfig, newName string, managed bool, disks []diskConfig) (VMConfig, error) {
+	clone := src
+	clone.Name = newName
+	clone.UUID = newVMUUID()
+	clone.MAC = newVMMAC()
+	clone.Managed = managed
+	clone.Disk = formatDiskConfigs(disks)
+	if err := cloneVMFiles(configDir, &clone); err != nil {
+		return VMConfig{}, err
+	}
+	return clone, nil
+}
+
+// cloneVMFiles는 복제본에 UEFI 변수 저장소 사본, 새 TPM·게스트 에이전트 소켓 경로,
+// 새 cloud-init seed와 무인 설치 응답 파일 ISO를 줍니다. 실패하면 만든 파일을 지웁니다.
+func cloneVMFiles(configDir string, clone *VMConfig) error {
+	if clone.Firmware != "uefi" {
+		clone.NVRAM = ""
+	}
+	if err := cloneFirmware(configDir, clone); err != nil {
+		return err
+	}
+	if clone.GuestAgent != "" {
+		clone.GuestAgent = defaultGuestAgentSocket(configDir, *clone)
+	}
+	clone.CloudInit.Seed = ""
+	clone.Unattend.Media = ""
+	if err := ensureCloudInitSeed(configDir, clone); err != nil {
+		removeClonedVMFiles(*clone)
+		return err
+	}
+	if err := ensureUnattendMedia(configDir, clone); err != nil {
+		removeClonedVMFiles(*clone)
+		return err
+	}
+	return nil
+}
+
+// removeClonedVMFiles는 cloneVMFiles가 만든 파일을 지웁니다.
+func removeClonedVMFiles(clone VMConfig) {
+	for _, path := range []string{clone.NVRAM, clone.CloudInit.Seed, clone.Unattend.Media} {
+		if path != "" {
+			os.Remove(path)
+		}
+	}
+}
+
+func checkCloneName(configDir, newName string) error {
+	if newName == "" {
+		return errEmptyName()
+	}
+	if vmConfigExists(configDir, newName) {
+		return fmt.Errorf("%s 가상머신이 이미 있습니다.", newName)
+	}
+	return nil
+}
+
+// 실행 중인 가상머신의 디스크는 쓰는 중이므로 복제하지 않음
+func checkCloneSource(src VMConfig) error {
+	if vmRunning(src.Name) {
+		return fmt.Errorf("%s 가상머신이 실행 중입니다. 종료한 뒤 복제하십시오.", src.Name)
+	}
+	return nil
+}
+
+// linkedCloneVM은 원본 디스크를 기반 이미지로 하는 QCOW2 오버레이를 만들고 새 설정을 저장합니다.
+// 원본이 관리형이면 오버레이는 새 가상머신 폴더에 만듭니다.
+// 암호화 디스크는 오버레이가 기반 이미지의 암호를 따로 받아야 하므로 연결된 클론을 만들지 않습니다.
+// 실행 중인 가상머신의 디스크는 기반 이미지가 된 뒤에도 계속 바뀌므로 만들지 않습니다.
+func linkedCloneVM(configDir string, src VMConfig, newName string) (VMConfig, error) {
+	if err := checkCloneSource(src); err != nil {
+		return VMConfig{}, err
+	}
+	if err := checkCloneName(configDir, newName); err != nil {
+		return VMConfig{}, err
+	}
+	for _, d := range parseDiskConfigs(src.Disk) {
+		if d.Encrypted {
+			return VMConfig{}, fmt.Errorf("%s 디스크가 암호화되어 있어 연결된 클론을 만들 수 없습니다. 전체 복사를 사용하십시오.", filepath.Base(d.Path))
+		}
+	}
+	newDir := vmStorageDir(configDir, newName)
+	if src.Managed {
+		if err := createVMDir(configDir, newName); err != nil {
+			return VMConfig{}, err
+		}
+	}
+
+	var disks []diskConfig
+	var created []string
+	cleanup := func() {
+		for _, path := range created {
+			os.Remove(path)
+		}
+		if src.Managed {
+			removeEmptyVMDir(newDir)
+		}
+	}
+	for _, d := range parseDiskConfigs(src.Disk) {
+		absPath, err := filepath.Abs(d.Path)
+		if err != nil {
+			absPath = d.Path
+		}
+		newPath := linkedDiskPath(d.Path, newName)
+		if src.Managed {
+			newPath = filepath.Join(newDir, filepath.Base(newPath))
+		}
+		if _, err := os.Stat(newPath); err == nil {
+			cleanup()
+			return VMConfig{}, fmt.Errorf("%s 파일이 이미 있습니다.", newPath)
+		}
+		if err := runQemuImg("create", "-f", "qcow2", "-b", absPath, "-F", qemuImgFormat(d.Type), newPath); err != nil {
+			cleanup()
+			return VMConfig{}, err
+		}
+		created = append(created, newPath)
+		d.Type = "QCOW2"
+		d.Path = newPath
+		disks = append(disks, d)
+	}
+
+	clone, err := clonedConfig(configDir, src, newName, src.Managed, disks)
+	if err != nil {
+		cleanup()
+		return VMConfig{}, err
+	}
+	if err := saveVMConfig(configDir, clone); err != nil {
+		removeClonedVMFiles(clone)
+		cleanup()
+		return VMConfig{}, err
+	}
+	return clone, nil
+}
+
+// fullCloneVM은 모든 디스크를 qemu-img convert로 destDir에 복사하고 새 설정을 저장합니다.
+// diskType이 비어 있으면 원본 디스크 종류를 유지합니다. 진행률(0~1)은 report로 알립니다.
+// destDir이 새 가상머신 폴더(vms\<새 이름>)이면 복제본은 관리형이 됩니다.
+// 실행 중인 가상머신은 복사하는 동안 디스크가 바뀌어 일관되지 않은 사본이 되므로 복제하지 않습니다.
+// 암호화 디스크는 같은 암호의 암호화 QCOW2로 복사하며, 자격 증명 관리자에 없는 암호는 passphrases에서 찾습니다.
+func fullCloneVM(ctx context.Context, configDir string, src VMConfig, newName, destDir, diskType string, passphrases map[string]string, report func(float64)) (VMConfig, error) {
+	if err := checkCloneSource(src); err != nil {
+		return VMConfig{}, err
+	}
+	if err := checkCloneName(configDir, newName); err != nil {
+		return VMConfig{}, err
+	}
+
+	entries := parseDiskConfigs(src.Disk)
+	for _, d := range entries {
+		if d.Encrypted && diskType != "" && diskType != "QCOW2" {
+			return VMConfig{}, fmt.Errorf("%s 디스크는 암호화되어 있어 QCOW2로만 복사할 수 있습니다.", filepath.Base(d.Path))
+		}
+	}
+
+	_, statErr := os.Stat(destDir)
+	createdDir := os.IsNotExist(statErr)
+	if err := os.MkdirAll(destDir, os.ModePerm); err != nil {
+		return VMConfig{}, err
+	}
+	var disks []diskConfig
+	var created []string
+	cleanup := func() {
+		for _, path := range created {
+			os.Remove(path)
+			deleteDiskPassphrase(path)
+		}
+		if createdDir {
+			os.Remove(destDir)
+		}
+	}
+
+	for i, d := range entries {
+		newType := diskType
+		if newType == "" {
+			newType = d.Type
+		}
+		newPath := filepath.Join(destDir, fmt.Sprintf("%s-disk%d%s", newName, i+1, diskFileExt(newType)))
+		if _, err := os.Stat(newPath); err == nil {
+			cleanup()
+			return VMConfig{}, fmt.Errorf("%s 파일이 이미 있습니다.", newPath)
+		}
+		srcOpts, srcImage, closeSecret, err := diskImageArgs(d, passphrases)
+		if err != nil {
+			cleanup()
+			return VMConfig{}, err
+		}
+		// 암호화 디스크는 같은 secret으로 대상도 암호화
+		var options []string
+		if d.Encrypted {
+			options = []string{"encrypt.format=luks", "encrypt.key-secret=sec0"}
+		}
+		created = append(created, newPath)
+		err = convertImage(ctx, srcOpts, srcImage, newPath, qemuImgFormat(newType), func(percent float64) {
+			report((float64(i) + percent/100) / float64(len(entries)))
+		}, options...)
+		closeSecret()
+		if err != nil {
+			cleanup()
+			return VMConfig{}, err
+		}
+		// 원본 암호가 자격 증명 관리자에 있으면 복사본 암호도 보관
+		if passphrase, ok := loadDiskPassphrase(d.Path); ok && d.Encrypted {
+			if err := storeDiskPassphrase(newPath, passphrase); err != nil {
+				cleanup()
+				return VMConfig{}, err
+			}
+		}
+		d.Type = newType
+		d.Path = newPath
+		disks = append(disks, d)
+	}
+
+	managed := sameDiskPath(destDir, vmStorageDir(configDir, newName))
+	if managed {
+		makeVMSubdirs(destDir)
+	}
+	clone, err := clonedConfig(configDir, src, newName, managed, disks)
+	if err != nil {
+		cleanup()
+		return VMConfig{}, err
+	}
+	if err := saveVMConfig(configDir, clone); err != nil {
+		removeClonedVMFiles(clone)
+		cleanup()
+		return VMConfig{}, err
+	}
+	return clone, nil
+}
+
+// 전체 복사 기본 대상 폴더: 원본이 관리형이면 새 가상머신 폴더,
+// 아니면 원본 첫 디스크가 있는 폴더 아래 <새 이름> 폴더
+func defaultCloneDir(configDir string, src VMConfig, newName string) string {
+	if src.Managed {
+		return vmStorageDir(configDir, newName)
+	}
+	for _, diskInfo := range strings.Split(src.Disk, ";") {
+		if _, dPath, _ := parseDiskInfo(diskInfo); dPath != "" {
+			return filepath.Join(filepath.Dir(dPath), newName)
+		}
+	}
+	return ""
+}
+
+// ShowCloneWindow는 VM 복제 창을 엽니다. 전체 복사는 메인 창의 작업 패널에서 진행됩니다.
+func ShowCloneWindow(config VMConfig, configDir string, parent fyne.Window, jobs *jobPanel, onDone func()) {
+	a := fyne.CurrentApp()
+	win := a.NewWindow(config.Name + " 복제")
+	win.Resize(fyne.NewSize(500, 300))
+
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder("새 가상머신 이름")
+	nameEntry.SetText(config.Name + "-clone")
+
+	linkedWarning := widget.NewLabel("연결된 클론은 원본 디스크를 기반 이미지로 사용합니다.\n원본 디스크가 변경되면 클론이 손상되므로 원본은 더 이상 수정하지 마십시오.")
+	linkedWarning.Wrapping = fyne.TextWrapWord
+
+	destEntry := widget.NewEntry()
+	destEntry.SetPlaceHolder("복사할 폴더")
+	destEntry.SetText(defaultCloneDir(configDir, config, nameEntry.Text))
+	destBtn := widget.NewButton("경로선택", func() {
+		dir, err := sqdialog.Directory().Title("복제 디스크 폴더 선택").Browse()
+		if err != nil || dir == "" {
+			return
+		}
+		destEntry.SetText(filepath.Join(dir, strings.TrimSpace(nameEntry.Text)))
+	})
+	nameEntry.OnChanged = func(name string) {
+		destEntry.SetText(defaultCloneDir(configDir, config, strings.TrimSpace(name)))
+	}
+
+	formatSelect := widget.NewSelect([]string{"원본 유지", "QCOW2", "RAW", "VHD", "VMDK"}, nil)
+	formatSelect.SetSelected("원본 유지")
+
+	fullOptions := widget.NewForm(
+		widget.NewFormItem("대상 폴더", container.NewBorder(nil, nil, nil, destBtn, destEntry)),
+		widget.NewFormItem("디스크 형식", formatSelect),
+	)
+
+	modeRadio := widget.NewRadioGroup([]string{"전체 복사", "연결된 클론"}, func(selected string) {
+		if selected == "연결된 클론" {
+			linkedWarning.Show()
+			fullOptions.Hide()
+		} else {
+			linkedWarning.Hide()
+			fullOptions.Show()
+		}
+	})
+	modeRadio.Horizontal = true
+	modeRadio.SetSelected("연결된 클론")
+
+	var chains []string
+	for _, diskInfo := range strings.Split(config.Disk, ";") {
+		_, dPath, _ := parseDiskInfo(diskInfo)
+		if dPath == "" {
+			continue
+		}
+		line := filepath.Base(dPath)
+		if chain := backingChainText(dPath); chain != "" {
+			line += " (" + chain + ")"
+		}
+		chains = append(chains, line)
+	}
+	diskLabel := widget.NewLabel(strings.Join(chains, "\n"))
+	diskLabel.Wrapping = fyne.TextWrapWord
+
+	cloneBtn := widget.NewButton("복제", func() {
+		newName := strings.TrimSpace(nameEntry.Text)
+		if err := checkCloneName(configDir, newName); err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+
+		if modeRadio.Selected == "연결된 클론" {
+			if _, err := linkedCloneVM(configDir, config, newName); err != nil {
+				dialog.ShowError(err, win)
+				return
+			}
+			dialog.ShowInformation("복제", newName+" 가상머신을 만들었습니다.", parent)
+			win.Close()
+			if onDone != nil {
+				onDone()
+			}
+			return
+		}
+
+		destDir := strings.TrimSpace(destEntry.Text)
+		if destDir == "" {
+			dialog.ShowError(errors.New("복사할 폴더를 선택하십시오."), win)
+			return
+		}
+		diskType := formatSelect.Selected
+		if diskType == "원본 유지" {
+			diskType = ""
+		}
+		// 자격 증명 관리자에 없는 디스크 암호는 복사 전에 입력받음
+		askMissingPassphrases(parseDiskConfigs(config.Disk), win, func(passphrases map[string]string) {
+			win.Close()
+			jobs.start(config.Name+" → "+newName+" 복제 중", func(ctx context.Context, report func(float64)) error {
+				_, err := fullCloneVM(ctx, configDir, config, newName, destDir, diskType, passphrases, report)
+				return err
+			}, func(err error) {
+				if err != nil {
+					dialog.ShowError(err, parent)
+					return
+				}
+				dialog.ShowInformation("복제", newName+" 가상머신을 만들었습니다.", parent)
+				if onDone != nil {
+					onDone()
+				}
+			})
+		})
+	})
+	cancelBtn := widget.NewButton("취소", func() {
+		win.Close()
+	})
+
+	if len(chains) == 0 {
+		cloneBtn.Disable()
+		dialog.ShowError(errors.New("복제할 디스크가 없습니다."), win)
+	} else if err := checkCloneSource(config); err != nil {
+		cloneBtn.Disable()
+		dialog.ShowError(err, win)
+	}
+
+	win.SetContent(container.NewVBox(
+		widget.NewForm(
+			widget.NewFormItem("이름", nameEntry),
+			widget.NewFormItem("방식", modeRadio),
+			widget.NewFormItem("디스크", diskLabel),
+		),
+		fullOptions,
+		linkedWarning,
+		container.NewHBox(cloneBtn, cancelBtn),
+	))
+	win.CenterOnScreen()
+	win.Show()
+}
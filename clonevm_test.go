@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLinkedDiskPath(t *testing.T) {
+	dir := filepath.Join("vm", "disks")
+	tests := []struct {
+		src, name, want string
+	}{
+		{filepath.Join(dir, "os.qcow2"), "copy", filepath.Join(dir, "copy-os.qcow2")},
+		// 형식과 관계없이 오버레이는 QCOW2
+		{filepath.Join(dir, "data.img"), "copy", filepath.Join(dir, "copy-data.qcow2")},
+		{filepath.Join(dir, "disk.v1.vhd"), "새 VM", filepath.Join(dir, "새 VM-disk.v1.qcow2")},
+		{filepath.Join(dir, "noext"), "copy", filepath.Join(dir, "copy-noext.qcow2")},
+	}
+	for _, tt := range tests {
+		if got := linkedDiskPath(tt.src, tt.name); got != tt.want {
+			t.Errorf("linkedDiskPath(%q, %q) = %q, want %q", tt.src, tt.name, got, tt.want)
+		}
+	}
+}
+
+// fakeRunningVM은 테스트 동안 config를 이 앱이 시작한 가상머신으로 등록합니다 (QMP 연결은 q).
+func fakeRunningVM(t *testing.T, config VMConfig, q *qmpClient) *runningVM {
+	t.Helper()
+	vm := &runningVM{config: config, qmp: q}
+	runningVMs.Lock()
+	runningVMs.vms[config.Name] = vm
+	runningVMs.Unlock()
+	t.Cleanup(func() {
+		runningVMs.Lock()
+		delete(runningVMs.vms, config.Name)
+		runningVMs.Unlock()
+	})
+	return vm
+}
+
+func TestLinkedCloneVMRefuses(t *testing.T) {
+	configDir := t.TempDir()
+	disk := filepath.Join(configDir, "os.qcow2")
+	src := VMConfig{Name: "src", Disk: formatDiskConfigs([]diskConfig{{Type: "QCOW2", Path: disk}})}
+	writeTestFile(t, disk, "disk")
+	if err := saveVMConfig(configDir, src); err != nil {
+		t.Fatal(err)
+	}
+	encrypted := src
+	encrypted.Name = "secret"
+	encrypted.Disk = formatDiskConfigs([]diskConfig{{Type: "QCOW2", Path: disk, Encrypted: true}})
+
+	tests := []struct {
+		desc    string
+		src     VMConfig
+		newName string
+		running bool
+	}{
+		{"빈 이름", src, "", false},
+		{"이미 있는 이름", src, "src", false},
+		{"암호화 디스크", encrypted, "copy", false},
+		{"실행 중", src, "copy", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if tt.running {
+				fakeRunningVM(t, tt.src, nil)
+			}
+			if _, err := linkedCloneVM(configDir, tt.src, tt.newName); err == nil {
+				t.Error("오류가 없습니다")
+			}
+			if tt.newName != "" && tt.newName != tt.src.Name && vmConfigExists(configDir, tt.newName) {
+				t.Errorf("%s 설정이 만들어졌습니다", tt.newName)
+			}
+			if _, err := os.Stat(linkedDiskPath(disk, tt.newName)); tt.newName != "" && !os.IsNotExist(err) {
+				t.Errorf("오버레이가 만들어졌습니다: %v", err)
+			}
+		})
+	}
+}
+
+func TestClonedConfig(t *testing.T) {
+	configDir := t.TempDir()
+	srcDir := vmStorageDir(configDir, "full")
+	full := fullVMConfig(srcDir)
+	writeTestFile(t, full.NVRAM, "vars")
+	disks := []diskConfig{{Type: "QCOW2", Path: filepath.Join(configDir, "copy.qcow2")}}
+
+	bios := full
+	bios.Firmware = ""
+	bios.TPM, bios.GuestAgent = "", ""
+	bios.CloudInit.Enabled, bios.Unattend.Enabled = false, false
+
+	tests := []struct {
+		desc    string
+		src     VMConfig
+		managed bool
+	}{
+		{"UEFI, TPM, 게스트 에이전트, cloud-init, 무인 설치", full, false},
+		{"관리형", full, true},
+		// BIOS인데 남아 있던 NVRAM 경로는 복사하지 않음
+		{"BIOS", bios, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			clone, err := clonedConfig(configDir, tt.src, "copy", tt.managed, disks)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer removeClonedVMFiles(clone)
+			if clone.Name != "copy" || clone.Managed != tt.managed || clone.Disk != formatDiskConfigs(disks) {
+				t.Errorf("Name = %q, Managed = %v, Disk = %q", clone.Name, clone.Managed, clone.Disk)
+			}
+			if clone.UUID == tt.src.UUID || len(clone.UUID) != 36 || clone.MAC == tt.src.MAC || !strings.HasPrefix(clone.MAC, "52:54:00:") {
+				t.Errorf("UUID = %q, MAC = %q (원본과 같거나 형식이 다름)", clone.UUID, clone.MAC)
+			}
+			// 나머지 설정은 그대로
+			if clone.RAM != tt.src.RAM || clone.Kernel != tt.src.Kernel || clone.BootOrder != tt.src.BootOrder || clone.ExtraArgs != tt.src.ExtraArgs {
+				t.Errorf("clone = %+v", clone)
+			}
+
+			wantNVRAM := ""
+			if tt.src.Firmware == "uefi" {
+				wantNVRAM = defaultNVRAMPath(configDir, clone)
+			}
+			if clone.NVRAM != wantNVRAM {
+				t.Errorf("NVRAM = %q, want %q", clone.NVRAM, wantNVRAM)
+			} else if wantNVRAM != "" && readTestFile(t, clone.NVRAM) != "vars" {
+				t.Error("UEFI 변수 저장소를 복사하지 않았습니다")
+			}
+
+			wantTPM, wantAgent := "", ""
+			if tt.src.TPM != "" {
+				wantTPM = defaultTPMSocket(configDir, clone)
+			}
+			if tt.src.GuestAgent != "" {
+				wantAgent = defaultGuestAgentSocket(configDir, clone)
+			}
+			if clone.TPM != wantTPM || clone.GuestAgent != wantAgent {
+				t.Errorf("TPM = %q, GuestAgent = %q, want %q, %q", clone.TPM, clone.GuestAgent, wantTPM, wantAgent)
+			}
+
+			// seed와 응답 파일 ISO는 복제본 이름으로 새로 만듦
+			for _, media := range []struct {
+				enabled   bool
+				src, path string
+			}{
+				{tt.src.CloudInit.Enabled, tt.src.CloudInit.Seed, clone.CloudInit.Seed},
+				{tt.src.Unattend.Enabled, tt.src.Unattend.Media, clone.Unattend.Media},
+			} {
+				if !media.enabled {
+					if media.path != "" {
+						t.Errorf("꺼진 항목의 ISO 경로 %q", media.path)
+					}
+					continue
+				}
+				if media.path == "" || media.path == media.src {
+					t.Errorf("ISO 경로 = %q (원본 %q)", media.path, media.src)
+				} else if _, err := os.Stat(media.path); err != nil {
+					t.Error(err)
+				}
+			}
+			// 게스트가 새 인스턴스로 알아보도록 instance-id가 바뀜
+			if tt.src.CloudInit.Enabled && string(cloudInitFiles(clone)[1].data) == string(cloudInitFiles(tt.src)[1].data) {
+				t.Errorf("meta-data가 원본과 같습니다:\n%s", cloudInitFiles(clone)[1].data)
+			}
+		})
+	}
+}
+
+func TestFullCloneVMRefusesRunningVM(t *testing.T) {
+	configDir := t.TempDir()
+	disk := filepath.Join(configDir, "os.qcow2")
+	writeTestFile(t, disk, "disk")
+	src := VMConfig{Name: "src", Disk: formatDiskConfigs([]diskConfig{{Type: "QCOW2", Path: disk}})}
+	fakeRunningVM(t, src, nil)
+	destDir := filepath.Join(configDir, "copy")
+	if _, err := fullCloneVM(context.Background(), configDir, src, "copy", destDir, "", nil, func(float64) {}); err == nil {
+		t.Fatal("실행 중인데 오류가 없습니다")
+	}
+	if _, err := os.Stat(destDir); !os.IsNotExist(err) {
+		t.Errorf("대상 폴더가 만들어졌습니다: %v", err)
+	}
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// cloudInitConfig는 cloud-init NoCloud 프로비저닝 설정입니다.
+// 켜져 있으면 user-data, meta-data(, network-config)를 담은 "cidata" ISO를 만들어 CD로 연결합니다.
+type cloudInitConfig struct {
+	Enabled       bool
+	Seed          string // 만든 seed ISO 경로
+	Hostname      string // 비우면 가상머신 이름
+	Users         string // 쉼표로 구분 (비우면 배포판 기본 사용자)
+	Password      string // SHA-512 crypt 해시 ($6$...)
+	SSHKeys       string // 한 줄에 공개 키 하나
+	Packages      string // 쉼표나 공백으로 구분
+	RunCmd        string // 한 줄에 명령 하나 (첫 부팅에 sh로 실행)
+	NetworkConfig string // network-config(v2 YAML). 비우면 DHCP
+}
+
+// 설정 파일은 한 줄에 값 하나이므로 여러 줄 값은 \n으로 바꿔 저장
+func escapeConfigLines(s string) string {
+	s = strings.ReplaceAll(s, "\r", "")
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
+
+func unescapeConfigLines(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+			if s[i] == 'n' {
+				b.WriteByte('\n')
+			} else {
+				b.WriteByte(s[i])
+			}
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// 빈 줄을 뺀 줄 목록
+func nonEmptyLines(s string) []string {
+	var lines []string
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// YAML은 JSON의 상위 집합이므로 문자열은 JSON 문자열로 씀
+func yamlString(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+// cloudInitUserData는 #cloud-config 형식의 user-data를 만듭니다.
+func cloudInitUserData(ci cloudInitConfig, hostname string) string {
+	var b strings.Builder
+	b.WriteString("#cloud-config\n")
+	fmt.Fprintf(&b, "hostname: %s\n", yamlString(hostname))
+	keys := nonEmptyLines(ci.SSHKeys)
+	writeKeys := func(indent string) {
+		if len(keys) == 0 {
+			return
+		}
+		b.WriteString(indent + "ssh_authorized_keys:\n")
+		for _, key := range keys {
+			fmt.Fprintf(&b, "%s  - %s\n", indent, yamlString(key))
+		}
+	}
+
+	users := strings.FieldsFunc(ci.Users, func(r rune) bool { return r == ',' || r == ' ' })
+	if len(users) > 0 {
+		b.WriteString("users:\n")
+		for _, user := range users {
+			fmt.Fprintf(&b, "  - name: %s\n", yamlString(user))
+			b.WriteString("    shell: /bin/bash\n")
+			b.WriteString("    sudo: \"ALL=(ALL) NOPASSWD:ALL\"\n")
+			if ci.Password != "" {
+				b.WriteString("    lock_passwd: false\n")
+				fmt.Fprintf(&b, "    passwd: %s\n", yamlString(ci.Password))
+			}
+			writeKeys("    ")
+		}
+	} else {
+		// 배포판 기본 사용자(ubuntu 등)에 암호와 키 설정
+		if ci.Password != "" {
+			fmt.Fprintf(&b, "password: %s\n", yamlString(ci.Password))
+			b.WriteString("chpasswd:\n  expire: false\n")
+		}
+		writeKeys("")
+	}
+	if ci.Password != "" {
+		b.WriteString("ssh_pwauth: true\n")
+	}
+
+	if packages := strings.FieldsFunc(ci.Packages, func(r rune) bool { return r == ',' || r == ' ' || r == '\n' }); len(packages) > 0 {
+		b.WriteString("package_update: true\npackages:\n")
+		for _, p := range packages {
+			fmt.Fprintf(&b, "  - %s\n", yamlString(p))
+		}
+	}
+	if cmds := nonEmptyLines(ci.RunCmd); len(cmds) > 0 {
+		b.WriteString("runcmd:\n")
+		for _, cmd := range cmds {
+			fmt.Fprintf(&b, "  - %s\n", yamlString(cmd))
+		}
+	}
+	return b.String()
+}
+
+// cloudInitFiles는 seed ISO에 넣을 파일을 만듭니다.
+// instance-id는 내용과 가상머신 식별자의 해시이므로 설정이 바뀌거나 복제하면 달라져
+// cloud-init이 다음 부팅에서 다시 적용합니다.
+func cloudInitFiles(config VMConfig) []isoFile {
+	ci := config.CloudInit
+	hostname := ci.Hostname
+	if hostname == "" {
+		hostname = config.Name
+	}
+	userData := cloudInitUserData(ci, hostname)
+	sum := sha256.Sum256([]byte(config.Name + "\n" + config.UUID + "\n" + userData + "\n" + ci.NetworkConfig))
+	metaData := "instance-id: iid-" + hex.EncodeToString(sum[:8]) + "\n" +
+		"local-hostname: " + yamlString(hostname) + "\n"
+	files := []isoFile{
+		{name: "user-data", data: []byte(userData)},
+		{name: "meta-data", data: []byte(metaData)},
+	}
+	if strings.TrimSpace(ci.NetworkConfig) != "" {
+		files = append(files, isoFile{name: "network-config", data: []byte(strings.TrimSpace(ci.NetworkConfig) + "\n")})
+	}
+	return files
+}
+
+// 기본 seed ISO 경로 (관리형이면 가상머신 폴더)
+func defaultCloudInitSeed(configDir string, config VMConfig) string {
+	if config.Managed {
+		return filepath.Join(vmStorageDir(configDir, config.Name), "seed.iso")
+	}
+	return filepath.Join(configDir, "cloud-init", config.Name+"-seed.iso")
+}
+
+// ensureCloudInitSeed는 cloud-init이 켜져 있으면 seed ISO를 만들고, 설정이 바뀌었으면 다시 만듭니다.
+func ensureCloudInitSeed(configDir string, config *VMConfig) error {
+	if !config.CloudInit.Enabled {
+		return nil
+	}
+	if config.CloudInit.Seed == "" {
+		config.CloudInit.Seed = defaultCloudInitSeed(configDir, *config)
+	}
+	if err := os.MkdirAll(filepath.Dir(config.CloudInit.Seed), os.ModePerm); err != nil {
+		return err
+	}
+	_, err := writeISO9660(config.CloudInit.Seed, "cidata", cloudInitFiles(*config))
+	return err
+}
+
+// vmCDROMs는 설정의 CD/DVD 드라이브 뒤에 cloud-init seed와
+// Windows 자동 설치 응답 파일, 드라이버 ISO 드라이브를 붙여 반환합니다.
+func vmCDROMs(config VMConfig) []cdromConfig {
+	cdroms := parseCDROMConfigs(config.CDROM)
+	if config.CloudInit.Enabled {
+		cdroms = append(cdroms, cdromConfig{Path: config.CloudInit.Seed})
+	}
+	if config.Unattend.Enabled {
+		cdroms = append(cdroms, cdromConfig{Path: config.Unattend.Media})
+		if config.Unattend.DriverISO != "" {
+			cdroms = append(cdroms, cdromConfig{Path: config.Unattend.DriverISO})
+		}
+	}
+	return cdroms
+}
+
+// SHA-512 crypt(3) ($6$) 해시. cloud-init의 passwd 값으로 씀
+const cryptAlphabet = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+func sha512Crypt(password string) (string, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	salt := make([]byte, 16)
+	for i, b := range raw {
+		salt[i] = cryptAlphabet[int(b)%len(cryptAlphabet)]
+	}
+	return sha512CryptSalt([]byte(password), salt), nil
+}
+
+func sha512CryptSalt(pw, salt []byte) string {
+	const rounds = 5000
+	repeat := func(src []byte, n int) []byte {
+		out := make([]byte, 0, n)
+		for len(out) < n {
+			out = append(out, src[:min(len(src), n-len(out))]...)
+		}
+		return out
+	}
+
+	b := sha512.New()
+	b.Write(pw)
+	b.Write(salt)
+	b.Write(pw)
+	sumB := b.Sum(nil)
+
+	a := sha512.New()
+	a.Write(pw)
+	a.Write(salt)
+	a.Write(repeat(sumB, len(pw)))
+	for n := len(pw); n > 0; n >>= 1 {
+		if n&1 != 0 {
+			a.Write(sumB)
+		} else {
+			a.Write(pw)
+		}
+	}
+	sumA := a.Sum(nil)
+
+	dp := sha512.New()
+	for range pw {
+		dp.Write(pw)
+	}
+	p := repeat(dp.Sum(nil), len(pw))
+
+	ds := sha512.New()
+	for i := 0; i < 16+int(sumA[0]); i++ {
+		ds.Write(salt)
+	}
+	s := repeat(ds.Sum(nil), len(salt))
+
+	for i := 0; i < rounds; i++ {
+		c := sha512.New()
+		if i&1 != 0 {
+			c.Write(p)
+		} else {
+			c.Write(sumA)
+		}
+		if i%3 != 0 {
+			c.Write(s)
+		}
+		if i%7 != 0 {
+			c.Write(p)
+		}
+		if i&1 != 0 {
+			c.Write(sumA)
+		} else {
+			c.Write(p)
+		}
+		sumA = c.Sum(nil)
+	}
+
+	var out strings.Builder
+	out.WriteString("$6$" + string(salt) + "$")
+	encode := func(b2, b1, b0 byte, n int) {
+		w := uint(b2)<<16 | uint(b1)<<8 | uint(b0)
+		for ; n > 0; n-- {
+			out.WriteByte(cryptAlphabet[w&0x3f])
+			w >>= 6
+		}
+	}
+	// 바이트 순서는 (i, i+21, i+42)를 i%3만큼 돌린 것
+	for i := 0; i < 21; i++ {
+		x, y, z := sumA[i], sumA[i+21], sumA[i+42]
+		switch i % 3 {
+		case 1:
+			x, y, z = y, z, x
+		case 2:
+			x, y, z = z, x, y
+		}
+		encode(x, y, z, 4)
+	}
+	encode(0, 0, sumA[63], 2)
+	return out.String()
+}
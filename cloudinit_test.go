@@ -0,0 +1,102 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEscapeConfigLines(t *testing.T) {
+	for _, s := range []string{"", "one line", "a\nb\n", `C:\new\dir`, "\\n literal", "trailing\\", "ssh-ed25519 AAAA one\r\nssh-rsa BBBB two"} {
+		escaped := escapeConfigLines(s)
+		if strings.ContainsAny(escaped, "\r\n") {
+			t.Errorf("escapeConfigLines(%q) = %q에 줄바꿈이 남았습니다", s, escaped)
+		}
+		if got, want := unescapeConfigLines(escaped), strings.ReplaceAll(s, "\r", ""); got != want {
+			t.Errorf("unescapeConfigLines(%q) = %q, want %q", escaped, got, want)
+		}
+	}
+}
+
+func TestSHA512Crypt(t *testing.T) {
+	// crypt(3) SHA-512 규격의 예와 openssl passwd -6 결과
+	tests := []struct{ password, salt, want string }{
+		{"Hello world!", "saltstring", "$6$saltstring$svn8UoSVapNtMuq1ukKS4tPQd8iKwSMHWjl/O817G3uBnIFNjnQJuesI68u4OTLiBFdcbYEdFCoEOfaS35inz1"},
+		{"비밀번호 with spaces and a long tail 0123456789012345678901234567890123456789012345678901234567890123456789", "abcdefghijklmnop",
+			"$6$abcdefghijklmnop$gVPvl1qYfPxb3xywbplRbXJ92ZxJsM9aW3EqyAj28ucnfylkGLqe4BMu6rc/TaUd.kSxqETR3OaWOBZDdGaau0"},
+	}
+	for _, tt := range tests {
+		if got := sha512CryptSalt([]byte(tt.password), []byte(tt.salt)); got != tt.want {
+			t.Errorf("sha512CryptSalt(%q, %q) = %q, want %q", tt.password, tt.salt, got, tt.want)
+		}
+	}
+
+	hash, err := sha512Crypt("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(hash, "$")
+	if len(parts) != 4 || parts[1] != "6" || len(parts[2]) != 16 || len(parts[3]) != 86 {
+		t.Fatalf("sha512Crypt = %q", hash)
+	}
+	if again := sha512CryptSalt([]byte("secret"), []byte(parts[2])); again != hash {
+		t.Errorf("같은 소금으로 다시 계산한 값이 다릅니다: %q", again)
+	}
+}
+
+func TestCloudInitUserData(t *testing.T) {
+	ci := cloudInitConfig{
+		Users: "ubuntu, admin", Password: "$6$salt$hash", SSHKeys: "ssh-ed25519 AAAA me\n\n",
+		Packages: "qemu-guest-agent,htop", RunCmd: "echo \"hi\" > /root/hi\n",
+	}
+	want := `#cloud-config
+hostname: "web"
+users:
+  - name: "ubuntu"
+    shell: /bin/bash
+    sudo: "ALL=(ALL) NOPASSWD:ALL"
+    lock_passwd: false
+    passwd: "$6$salt$hash"
+    ssh_authorized_keys:
+      - "ssh-ed25519 AAAA me"
+  - name: "admin"
+    shell: /bin/bash
+    sudo: "ALL=(ALL) NOPASSWD:ALL"
+    lock_passwd: false
+    passwd: "$6$salt$hash"
+    ssh_authorized_keys:
+      - "ssh-ed25519 AAAA me"
+ssh_pwauth: true
+package_update: true
+packages:
+  - "qemu-guest-agent"
+  - "htop"
+runcmd:
+  - "echo \"hi\" \u003e /root/hi"
+`
+	if got := cloudInitUserData(ci, "web"); got != want {
+		t.Errorf("cloudInitUserData =\n%s\nwant\n%s", got, want)
+	}
+
+	// 사용자를 지정하지 않으면 배포판 기본 사용자에 적용
+	got := cloudInitUserData(cloudInitConfig{Password: "$6$x", SSHKeys: "k"}, "h")
+	if !strings.Contains(got, "\npassword: \"$6$x\"\nchpasswd:\n  expire: false\nssh_authorized_keys:\n  - \"k\"\n") {
+		t.Errorf("cloudInitUserData =\n%s", got)
+	}
+}
+
+func TestCloudInitFiles(t *testing.T) {
+	config := VMConfig{Name: "web", UUID: "u1", CloudInit: cloudInitConfig{Enabled: true, NetworkConfig: "version: 2\n\n"}}
+	files := cloudInitFiles(config)
+	if len(files) != 3 || files[0].name != "user-data" || files[1].name != "meta-data" || string(files[2].data) != "version: 2\n" {
+		t.Fatalf("files = %q", files)
+	}
+	// 복제해서 UUID가 바뀌면 instance-id도 바뀌어 cloud-init이 다시 실행됨
+	config.UUID = "u2"
+	if string(cloudInitFiles(config)[1].data) == string(files[1].data) {
+		t.Error("UUID가 바뀌었는데 instance-id가 같습니다")
+	}
+	config.CloudInit.NetworkConfig = " \n"
+	if len(cloudInitFiles(config)) != 2 {
+		t.Error("빈 network-config를 넣었습니다")
+	}
+}
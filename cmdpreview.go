@@ -0,0 +1,206 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	sqdialog "github.com/sqweek/dialog"
+)
+
+// POSIX 셸 규칙으로 인자 하나를 따옴표 처리
+func quoteShellArg(arg string) string {
+	if arg != "" && strings.Trim(arg, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_=+,.:/@%") == "" {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// 옵션과 그 값을 한 줄씩 묶음 (스크립트 가독성용)
+func groupArgs(args []string) [][]string {
+	var lines [][]string
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") || len(lines) == 0 || len(lines[len(lines)-1]) == 2 {
+			lines = append(lines, []string{arg})
+		} else {
+			lines[len(lines)-1] = append(lines[len(lines)-1], arg)
+		}
+	}
+	return lines
+}
+
+// commandScript는 확장자(.cmd/.bat 또는 .sh)에 맞는 실행 스크립트를 만듭니다.
+func commandScript(path, binary string, args []string) string {
+	var b strings.Builder
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".cmd", ".bat":
+		b.WriteString("@echo off\r\n")
+		b.WriteString(quoteWindowsArg(binary))
+		for _, line := range groupArgs(args) {
+			b.WriteString(" ^\r\n ")
+			for i, arg := range line {
+				if i > 0 {
+					b.WriteByte(' ')
+				}
+				// 배치 파일에서는 %를 두 번 써야 그대로 전달됨
+				b.WriteString(strings.ReplaceAll(quoteWindowsArg(arg), "%", "%%"))
+			}
+		}
+		b.WriteString("\r\n")
+	default:
+		b.WriteString("#!/bin/sh\n")
+		b.WriteString("exec " + quoteShellArg(binary))
+		for _, line := range groupArgs(args) {
+			b.WriteString(" \\\n ")
+			for i, arg := range line {
+				if i > 0 {
+					b.WriteByte(' ')
+				}
+				b.WriteString(quoteShellArg(arg))
+			}
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// watchInputs는 obj 아래의 입력 위젯이 바뀔 때마다 onChange를 호출하도록 기존 콜백을 감쌉니다.
+// 행 추가 등으로 새로 생긴 위젯도 잡을 수 있게 여러 번 호출해도 되며, seen으로 중복을 막습니다.
+// 버튼은 감싸지 않습니다. 대화상자를 여는 버튼은 누른 직후가 아니라 확인했을 때 값이 바뀌므로
+// 행 추가·삭제, 순서 변경, 대화상자 확인 콜백에서 직접 갱신해야 합니다.
+func watchInputs(obj fyne.CanvasObject, onChange func(), seen map[fyne.CanvasObject]bool) {
+	if obj == nil || seen[obj] {
+		return
+	}
+	switch w := obj.(type) {
+	case *widget.Entry:
+		seen[obj] = true
+		prev := w.OnChanged
+		w.OnChanged = func(s string) {
+			if prev != nil {
+				prev(s)
+			}
+			onChange()
+		}
+	case *widget.Select:
+		seen[obj] = true
+		prev := w.OnChanged
+		w.OnChanged = func(s string) {
+			if prev != nil {
+				prev(s)
+			}
+			onChange()
+		}
+	case *widget.Check:
+		seen[obj] = true
+		prev := w.OnChanged
+		w.OnChanged = func(b bool) {
+			if prev != nil {
+				prev(b)
+			}
+			onChange()
+		}
+	case *widget.Form:
+		for _, item := range w.Items {
+			watchInputs(item.Widget, onChange, seen)
+		}
+	case *container.Scroll:
+		watchInputs(w.Content, onChange, seen)
+	case *fyne.Container:
+		for _, child := range w.Objects {
+			watchInputs(child, onChange, seen)
+		}
+	}
+}
+
+// commandPreview는 편집 중인 설정으로 만들어질 QEMU 명령줄을 보여 주는 패널입니다.
+type commandPreview struct {
+	box   *fyne.Container
+	entry *widget.Entry
+
+	build  func() (string, []string, error)
+	binary string
+	args   []string
+
+	mu    sync.Mutex
+	timer *time.Timer
+}
+
+// 입력이 멈춘 뒤 미리보기를 다시 만들기까지 기다리는 시간
+const previewDelay = 300 * time.Millisecond
+
+// schedule은 입력이 이어지는 동안 갱신을 미루다가 previewDelay 뒤에 한 번만 refresh합니다.
+// 글자를 칠 때마다 디스크 경로를 확인하며 명령줄을 만들지 않기 위한 것입니다.
+func (p *commandPreview) schedule() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.timer != nil {
+		p.timer.Stop()
+	}
+	p.timer = time.AfterFunc(previewDelay, p.refresh)
+}
+
+// refresh는 현재 설정으로 명령줄을 다시 만들어 표시합니다.
+func (p *commandPreview) refresh() {
+	binary, args, err := p.build()
+	p.mu.Lock()
+	p.binary, p.args = binary, args
+	p.mu.Unlock()
+	if err != nil {
+		p.entry.SetText("오류: " + err.Error())
+		return
+	}
+	p.entry.SetText(commandLineString(binary, args))
+}
+
+// current는 마지막으로 표시한 명령줄입니다 (오류였으면 binary가 빈 문자열).
+func (p *commandPreview) current() (string, []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.binary, p.args
+}
+
+// newCommandPreview는 build가 돌려주는 명령줄을 표시하며 복사와 스크립트 저장 버튼을 제공합니다.
+func newCommandPreview(win fyne.Window, build func() (string, []string, error)) *commandPreview {
+	p := &commandPreview{entry: widget.NewMultiLineEntry(), build: build}
+	p.entry.Wrapping = fyne.TextWrapBreak
+	p.entry.SetMinRowsVisible(3)
+	p.entry.Disable()
+
+	copyBtn := widget.NewButton("복사", func() {
+		binary, args := p.current()
+		if binary == "" {
+			return
+		}
+		win.Clipboard().SetContent(commandLineString(binary, args))
+	})
+	exportBtn := widget.NewButton("스크립트로 저장", func() {
+		if _, _, err := build(); err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		p.refresh()
+		path, err := sqdialog.File().Title("실행 스크립트 저장").Filter("Windows 배치 파일", "cmd", "bat").Filter("셸 스크립트", "sh").Save()
+		if err != nil {
+			return
+		}
+		if filepath.Ext(path) == "" {
+			path += ".cmd"
+		}
+		binary, args := p.current()
+		if err := os.WriteFile(path, []byte(commandScript(path, binary, args)), 0755); err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		dialog.ShowInformation("스크립트 저장", path+" 파일에 저장했습니다.", win)
+	})
+	p.box = container.NewBorder(nil, nil, widget.NewLabel("명령줄"), container.NewVBox(copyBtn, exportBtn), p.entry)
+	p.refresh()
+	return p
+}
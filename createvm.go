@@ -1,9 +1,7 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -34,7 +32,36 @@ type VMConfig struct {
 	Disk           string
 	GPU            string
 	Network        string
-	HW             string
+	UUID           string
+	MAC            string
+	Machine        string
+	CDROM          string
+
+	BootOrder       string // 장치 ID를 ','로 나열 (disk0,cd0,net0)
+	BootMenu        string
+	BootMenuTimeout string // ms
+	BootOnce        string // 다음 실행에서만 먼저 부팅할 장치 ID
+
+	// 직접 커널 부팅
+	Kernel       string
+	Initrd       string
+	KernelAppend string
+	DTB          string
+
+	// UEFI 펌웨어와 TPM
+	Firmware string // "uefi"이면 EDK2 pflash, 비어 있으면 BIOS
+	NVRAM    string // UEFI 변수 저장소 (가상머신마다 복사본)
+	TPM      string // swtpm 제어 소켓 경로 (비어 있으면 TPM 없음)
+
+	CloudInit cloudInitConfig // cloud-init NoCloud 프로비저닝
+	Unattend  unattendConfig  // Windows 자동 설치 (autounattend.xml)
+
+	GuestAgent string // qemu-ga 채널 소켓 경로 (비어 있으면 채널 없음)
+
+	ExtraArgs string // 명령줄 끝에 덧붙일 추가 인자 (splitArgs 규칙)
+
+	// 관리형 레이아웃(vms\<이름>\vm.conf) 여부. 설정 파일 위치로 정해지므로 저장하지 않음
+	Managed bool
 }
 
 type MemoryStatusEx struct {
@@ -78,41 +105,25 @@ func getDiskFileSizeMB(path string, diskType string) int64 {
 		return 0
 	}
 	switch diskType {
-	case "QCOW2":
-		output, err := exec.Command("qemu-img", "info", "--output=json", path).Output()
+	case "QCOW2", "VHD", "VMDK":
+		info, err := getDiskImageInfo(path)
 		if err != nil {
 			return 0
 		}
-		var info struct {
-			VirtualSize int64 `json:"virtual-size"`
-		}
-		if err := json.Unmarshal(output, &info); err != nil {
-			return 0
-		}
 		return info.VirtualSize / (1024 * 1024)
 	case "RAW":
 		if fi, err := os.Stat(path); err == nil {
 			return fi.Size() / (1024 * 1024)
 		}
 		return 0
-	case "VHD", "VMDK":
-		output, err := exec.Command("qemu-img", "info", "--output=json", path).Output()
-		if err != nil {
-			return 0
-		}
-		var info struct {
-			VirtualSize int64 `json:"virtual-size"`
-		}
-		if err := json.Unmarshal(output, &info); err != nil {
-			return 0
-		}
-		return info.VirtualSize / (1024 * 1024)
 	}
 	return 0
 }
 
 // "QCOW2:E:\QEMU\disk.qcow2:10240" → (diskType, diskPath, diskCapacity)
+// ("|" 뒤의 디스크 옵션은 무시, parseDiskConfig 참고)
 func parseDiskInfo(diskInfo string) (string, string, string) {
+	diskInfo, _, _ = strings.Cut(diskInfo, "|")
 	parts := strings.SplitN(diskInfo, ":", 2)
 	if len(parts) < 2 {
 		return "", "", ""
@@ -144,6 +155,23 @@ func parseGPUString(gpuStr string) map[string]string {
 	return result
 }
 
+// 편집 창과 명령줄 가져오기에서 함께 쓰는 선택 목록
+var (
+	cpuModelOptions = []string{
+		"Intel: Cascadelake-Server", "Intel: Skylake-Server/Client", "Intel: Broadwell",
+		"Intel: Haswell", "Intel: IvyBridge", "Intel: SandyBridge", "Intel: Westmere",
+		"Intel: Nehalem", "Intel: Penryn", "Intel: Conroe", "AMD: EPYC", "AMD: Opteron_G5",
+		"AMD: Opteron_G4", "AMD: Opteron_G3", "AMD: Opteron_G2", "AMD: Opteron_G1", "Basic: qemu32",
+		"Basic: qemu64", "ARM: Cortex-A57", "ARM: Cortex-M0", "ARM: Cortex-M4", "ARM: Cortex-M33",
+		"MIPS: mips32r6-generic", "MIPS: P5600", "MIPS: M14K/M14Kc", "MIPS: 74Kf", "MIPS: 34Kf",
+		"MIPS: 24Kc/24KEc/24Kf", "MIPS: 4Kc/4Km/4KEcR1/4KEmR1/4KEc/4KEm",
+	}
+	acceleratorOptions = []string{"TCG", "KVM", "Xen", "hvf", "whpx", "nvmm"}
+	gpuFrontendOptions = []string{"cirrus", "std", "qxl", "virtio"}
+	gpuDisplayOptions  = []string{"gtk", "sdl", "vnc", "none"}
+	gpuDeviceOptions   = []string{"virtio-vga", "virtio-gpu", "virtio-gpu-gl", "vhost-user-vga", "vhost-user-gpu"}
+)
+
 func EditVMConfig(vmName string, parent fyne.Window, onSave func()) {
 	a := fyne.CurrentApp()
 	winTitle := "가상머신 생성"
@@ -160,49 +188,8 @@ func EditVMConfig(vmName string, parent fyne.Window, onSave func()) {
 
 	config := &VMConfig{}
 	if vmName != "" {
-		configPath := filepath.Join(configDir, vmName+".conf")
-		if data, err := ioutil.ReadFile(configPath); err == nil {
-			lines := splitLines(string(data))
-			for _, line := range lines {
-				if len(line) == 0 {
-					continue
-				}
-				parts := splitKeyValue(line)
-				if len(parts) != 2 {
-					continue
-				}
-				key, value := parts[0], parts[1]
-				switch key {
-				case "name":
-					config.Name = value
-				case "cpu":
-					config.CPU = value
-				case "cpuModel":
-					config.CPUModel = value
-				case "cpuCores":
-					config.CPUCores = value
-				case "cpuSockets":
-					config.CPUSockets = value
-				case "cpuThreads":
-					config.CPUThreads = value
-				case "cpuFeatures":
-					config.CPUFeatures = value
-				case "cpuAccel":
-					config.CPUAccel = value
-				case "cpuAccelerator":
-					config.CPUAccelerator = value
-				case "ram":
-					config.RAM = value
-				case "disk":
-					config.Disk = value
-				case "gpu":
-					config.GPU = value
-				case "network":
-					config.Network = value
-				case "hw":
-					config.HW = value
-				}
-			}
+		if loaded, err := loadVMConfig(configDir, vmName); err == nil {
+			*config = loaded
 		}
 	}
 	if vmName != "" {
@@ -215,17 +202,20 @@ func EditVMConfig(vmName string, parent fyne.Window, onSave func()) {
 	nameEntry.SetPlaceHolder("가상머신 이름")
 	nameEntry.SetText(config.Name)
 
-	// CPU
-	cpuModels := []string{
-		"Intel: Cascadelake-Server", "Intel: Skylake-Server/Client", "Intel: Broadwell",
-		"Intel: Haswell", "Intel: IvyBridge", "Intel: SandyBridge", "Intel: Westmere",
-		"Intel: Nehalem", "Intel: Penryn", "Intel: Conroe", "AMD: EPYC", "AMD: Opteron_G5",
-		"AMD: Opteron_G4", "AMD: Opteron_G3", "AMD: Opteron_G2", "AMD: Opteron_G1", "Basic: qemu32",
-		"Basic: qemu64", "ARM: Cortex-A57", "ARM: Cortex-M0", "ARM: Cortex-M4", "ARM: Cortex-M33",
-		"MIPS: mips32r6-generic", "MIPS: P5600", "MIPS: M14K/M14Kc", "MIPS: 74Kf", "MIPS: 34Kf",
-		"MIPS: 24Kc/24KEc/24Kf", "MIPS: 4Kc/4Km/4KEcR1/4KEmR1/4KEc/4KEm",
+	// 관리형 레이아웃은 새로 만들 때만 고를 수 있음
+	managedCheck := widget.NewCheck("가상머신 폴더(vms\\이름)에 디스크와 함께 보관 (경로를 비운 디스크는 폴더에 생성)", nil)
+	managedCheck.SetChecked(config.Managed)
+	if vmName != "" {
+		managedCheck.Disable()
 	}
-	cpuModelSelect := widget.NewSelect(cpuModels, nil)
+
+	// 머신 종류 (선택하지 않으면 CPU 모델에 따라 자동)
+	machineSelect := widget.NewSelect(machineOptions, nil)
+	machineSelect.PlaceHolder = "자동 (CPU 모델에 따름)"
+	machineSelect.SetSelected(config.Machine)
+
+	// CPU
+	cpuModelSelect := widget.NewSelect(cpuModelOptions, nil)
 	cpuModelSelect.PlaceHolder = "CPU 모델 선택"
 	if config.CPUModel != "" {
 		cpuModelSelect.SetSelected(config.CPUModel)
@@ -259,7 +249,6 @@ func EditVMConfig(vmName string, parent fyne.Window, onSave func()) {
 	cpuFeaturesEntry.SetPlaceHolder("추가 CPU 옵션 (예: +ssse3,-sse4.2)")
 	cpuFeaturesEntry.SetText(config.CPUFeatures)
 
-	acceleratorOptions := []string{"TCG", "KVM", "Xen", "hvf", "whpx", "nvmm"}
 	acceleratorSelect := widget.NewSelect(acceleratorOptions, nil)
 	acceleratorSelect.PlaceHolder = "가속기 선택"
 	acceleratorSelect.Disable()
@@ -420,238 +409,211 @@ func EditVMConfig(vmName string, parent fyne.Window, onSave func()) {
 		ramWarningLabel,
 	)
 
+	// 명령줄 미리보기 갱신 (입력 위젯은 watchInputs가 잡고, 행 추가·삭제나
+	// 대화상자에서 바뀌는 값은 아래 inputChanged를 직접 호출함)
+	var onInput func()
+	inputChanged := func() {
+		if onInput != nil {
+			onInput()
+		}
+	}
+
 	// ─────────────────────────────────────────────
 	// 하드디스크 탭
 	fullAllocCheck := widget.NewCheck("디스크 공간 미리 할당", func(bool) {})
 
-	var diskRows []*fyne.Container
+	var diskRows []*diskRow
 	diskRowsContainer := container.NewVBox()
 
 	// 스크롤 컨테이너로 감싸서 창이 넘칠 경우 스크롤
 	diskScroll := container.NewScroll(diskRowsContainer)
 	diskScroll.SetMinSize(fyne.NewSize(0, 200)) // 세로 200px 정도 공간
 
-	addDiskButton := widget.NewButton("+", func() {
+	// 디스크 행 추가 (d.Path가 비어 있으면 새 디스크)
+	addDiskRow := func(d diskConfig) {
+		row := &diskRow{}
 		diskNumber := len(diskRows) + 1
 		label := widget.NewLabel(fmt.Sprintf("하드디스크 %d", diskNumber))
-	
+
 		diskTypes := []string{"QCOW2", "RAW", "VHD", "VMDK"}
-		diskTypeSelect := widget.NewSelect(diskTypes, nil)
-		diskTypeSelect.PlaceHolder = "디스크 종류 선택"
-		diskTypeSelect.SetSelected(diskTypes[0]) // 기본값
-	
-		pathEntry := widget.NewEntry()
-		pathEntry.SetPlaceHolder("경로 입력")
-	
-		capacityEntry := widget.NewEntry()
-		capacityEntry.SetPlaceHolder("디스크 용량(MB)")
-	
+		row.typeSelect = widget.NewSelect(diskTypes, nil)
+		row.typeSelect.PlaceHolder = "디스크 종류 선택"
+		if d.Type != "" {
+			row.typeSelect.SetSelected(d.Type)
+		} else {
+			row.typeSelect.SetSelected(diskTypes[0]) // 기본값
+		}
+
+		row.pathEntry = widget.NewEntry()
+		row.pathEntry.SetPlaceHolder("경로 입력")
+		row.pathEntry.SetText(d.Path)
+
+		row.capacityEntry = widget.NewEntry()
+		row.capacityEntry.SetPlaceHolder("디스크 용량(MB)")
+		row.capacityEntry.SetText(d.Capacity)
+
 		var baseSizeMB int64
-		var row *fyne.Container
-	
-		// "새로 생성" 버튼
-		createBtn := widget.NewButton("경로선택", func() {
-			path, err := sqdialog.File().Title("새 디스크 파일 생성").Save()
-			if err != nil || path == "" {
-				return
+		if stMB := getDiskFileSizeMB(d.Path, d.Type); stMB > 0 {
+			baseSizeMB = stMB
+			if diskCapVal, err := strconv.ParseInt(d.Capacity, 10, 64); err == nil && diskCapVal < stMB {
+				row.capacityEntry.SetText(strconv.FormatInt(stMB, 10))
 			}
-			ext := filepath.Ext(path)
-			if ext == "" {
-				defaultExt := map[string]string{
-					"QCOW2": ".qcow2",
-					"RAW":   ".img",
-					"VHD":   ".vhd",
-					"VMDK":  ".vmdk",
-				}
-				if def, ok := defaultExt[diskTypeSelect.Selected]; ok {
-					path += def
+		}
+		row.capacityEntry.OnChanged = func(text string) {
+			if baseSizeMB > 0 {
+				if val, err := strconv.ParseInt(text, 10, 64); err == nil && val < baseSizeMB {
+					row.capacityEntry.SetText(strconv.FormatInt(baseSizeMB, 10))
 				}
 			}
-			pathEntry.SetText(path)
-			// 파일 경로가 설정되었으므로, 디스크 타입 드롭다운 비활성화
-			diskTypeSelect.Disable()
+		}
+
+		// 이미 경로가 있으면 디스크 타입 드롭다운 비활성화
+		if d.Path != "" {
+			row.typeSelect.Disable()
+		}
+
+		// 버스/캐시/AIO/discard 옵션 (선택하지 않으면 자동/QEMU 기본값)
+		row.busSelect = widget.NewSelect(diskBusOptions, nil)
+		row.busSelect.PlaceHolder = "버스 (자동)"
+		row.busSelect.SetSelected(d.Bus)
+		row.cacheSelect = widget.NewSelect(diskCacheOptions, nil)
+		row.cacheSelect.PlaceHolder = "캐시 (writeback)"
+		row.cacheSelect.SetSelected(d.Cache)
+		row.aioSelect = widget.NewSelect(diskAIOOptions, nil)
+		row.aioSelect.PlaceHolder = "AIO (threads)"
+		row.aioSelect.SetSelected(d.AIO)
+		row.discardCheck = widget.NewCheck("discard=unmap", nil)
+		row.discardCheck.SetChecked(d.Discard)
+		row.detectZeroesSelect = widget.NewSelect(diskDetectZeroesOptions, nil)
+		row.detectZeroesSelect.PlaceHolder = "detect-zeroes (off)"
+		row.detectZeroesSelect.SetSelected(d.DetectZeroes)
+
+		// I/O 제한 (IOPS/대역폭, 버스트 포함)
+		row.throttle = d.Throttle
+		var throttleBtn *widget.Button
+		throttleBtn = widget.NewButton(throttleButtonText(row.throttle), func() {
+			showThrottleForm(row.throttle, win, func(throttle map[string]string) {
+				row.throttle = throttle
+				throttleBtn.SetText(throttleButtonText(throttle))
+				inputChanged()
+			})
 		})
-	
+
+		// 암호화는 새로 만드는 QCOW2 디스크에서만 선택 가능
+		row.encryptCheck = widget.NewCheck("암호화 (LUKS)", nil)
+		row.encryptCheck.SetChecked(d.Encrypted)
+		if d.Path != "" {
+			row.encryptCheck.Disable()
+		}
+		row.typeSelect.OnChanged = func(selected string) {
+			if selected != "QCOW2" {
+				row.encryptCheck.SetChecked(false)
+				row.encryptCheck.Disable()
+			} else if d.Path == "" {
+				row.encryptCheck.Enable()
+			}
+		}
+
+		diskInfoLabel := widget.NewLabel("")
+		setDiskInfoLabel(diskInfoLabel, d.Path)
+
+		removeRow := func() {
+			for i, rowItem := range diskRows {
+				if rowItem == row {
+					diskRows = append(diskRows[:i], diskRows[i+1:]...)
+					diskRowsContainer.Remove(row.box)
+					diskRowsContainer.Refresh()
+					break
+				}
+			}
+			inputChanged()
+		}
+
 		// "디스크 가져오기" 버튼
 		loadBtn := widget.NewButton("디스크 가져오기", func() {
 			path, err := sqdialog.File().Title("디스크 파일 가져오기").Load()
 			if err != nil || path == "" {
 				return
 			}
-			pathEntry.SetText(path)
+			row.pathEntry.SetText(path)
+			// 기존 이미지의 암호화 여부는 qemu-img info로 확인
+			if info, err := getDiskImageInfo(path); err == nil {
+				row.encryptCheck.SetChecked(info.Encrypted)
+			}
+			row.encryptCheck.Disable()
+			setDiskInfoLabel(diskInfoLabel, path)
 			// 만약 파일 크기 확인 가능하다면
-			if diskSize := getDiskFileSizeMB(path, diskTypeSelect.Selected); diskSize > 0 {
+			if diskSize := getDiskFileSizeMB(path, row.typeSelect.Selected); diskSize > 0 {
 				baseSizeMB = diskSize
-				capacityEntry.SetText(strconv.FormatInt(diskSize, 10))
+				row.capacityEntry.SetText(strconv.FormatInt(diskSize, 10))
 			} else {
 				baseSizeMB = 0
-				capacityEntry.SetText("10240")
+				row.capacityEntry.SetText("10240")
 			}
 			// 파일 경로가 설정되었으므로, 디스크 타입 드롭다운 비활성화
-			diskTypeSelect.Disable()
+			row.typeSelect.Disable()
 		})
-	
+
 		removeBtn := widget.NewButton("-", func() {
 			// 경로가 비어있으면 그냥 제거
-			if pathEntry.Text == "" {
-				for i, rowItem := range diskRows {
-					if rowItem == row {
-						diskRows = append(diskRows[:i], diskRows[i+1:]...)
-						diskRowsContainer.Remove(row)
-						diskRowsContainer.Refresh()
-						break
-					}
-				}
+			if row.pathEntry.Text == "" {
+				removeRow()
 				return
 			}
 			// 실제 경로가 있다면 Confirm dialog
 			dialog.ShowConfirm("디스크 삭제", "디스크 파일도 삭제하시겠습니까?", func(deleteFile bool) {
 				if deleteFile {
-					if _, err := os.Stat(pathEntry.Text); err == nil {
-						os.Remove(pathEntry.Text)
-					}
-				}
-				for i, rowItem := range diskRows {
-					if rowItem == row {
-						diskRows = append(diskRows[:i], diskRows[i+1:]...)
-						diskRowsContainer.Remove(row)
-						diskRowsContainer.Refresh()
-						break
-					}
+					removeDiskFile(configDir, row.pathEntry.Text, win)
 				}
+				removeRow()
 			}, win)
 		})
-	
-		capacityEntry.OnChanged = func(text string) {
-			if baseSizeMB > 0 {
-				if val, err := strconv.ParseInt(text, 10, 64); err == nil {
-					if val < baseSizeMB {
-						capacityEntry.SetText(strconv.FormatInt(baseSizeMB, 10))
-					}
+
+		buttons := container.NewHBox(loadBtn, removeBtn)
+		if d.Path == "" {
+			// "새로 생성" 버튼
+			createBtn := widget.NewButton("경로선택", func() {
+				path, err := sqdialog.File().Title("새 디스크 파일 생성").Save()
+				if err != nil || path == "" {
+					return
 				}
-			}
+				ext := filepath.Ext(path)
+				if ext == "" {
+					path += diskFileExt(row.typeSelect.Selected)
+				}
+				row.pathEntry.SetText(path)
+				// 파일 경로가 설정되었으므로, 디스크 타입 드롭다운 비활성화
+				row.typeSelect.Disable()
+			})
+			buttons = container.NewHBox(createBtn, loadBtn, removeBtn)
 		}
-	
-		row = container.NewVBox(
-			container.NewHBox(label, diskTypeSelect),
-			container.NewBorder(nil, nil, container.NewHBox(createBtn, loadBtn, removeBtn), nil, pathEntry),
+
+		row.box = container.NewVBox(
+			container.NewHBox(label, row.typeSelect),
+			container.NewBorder(nil, nil, buttons, nil, row.pathEntry),
 			widget.NewForm(
-				widget.NewFormItem("용량(MB)", capacityEntry),
+				widget.NewFormItem("용량(MB)", row.capacityEntry),
 			),
+			container.NewGridWithColumns(3, row.busSelect, row.cacheSelect, row.aioSelect),
+			container.NewHBox(row.discardCheck, row.detectZeroesSelect, throttleBtn, row.encryptCheck),
+			diskInfoLabel,
 		)
-	
+
 		diskRows = append(diskRows, row)
-		diskRowsContainer.Add(row)
+		diskRowsContainer.Add(row.box)
 		diskRowsContainer.Refresh()
-	})	
+	}
+
+	addDiskButton := widget.NewButton("+", func() {
+		addDiskRow(diskConfig{})
+		inputChanged()
+	})
 
 	// 기존 디스크 로드
-	if config.Disk != "" {
-		disks := strings.Split(config.Disk, ";")
-		for _, diskInfo := range disks {
-			dType, dPath, dCap := parseDiskInfo(diskInfo)
-			if dType == "" && dPath == "" {
-				continue
-			}
-	
-			diskNumber := len(diskRows) + 1
-			label := widget.NewLabel(fmt.Sprintf("하드디스크 %d", diskNumber))
-	
-			diskTypes := []string{"QCOW2", "RAW", "VHD", "VMDK"}
-			diskTypeSelect := widget.NewSelect(diskTypes, nil)
-			diskTypeSelect.PlaceHolder = "디스크 종류 선택"
-			diskTypeSelect.SetSelected(dType)
-	
-			pathEntry := widget.NewEntry()
-			pathEntry.SetPlaceHolder("경로 입력")
-			pathEntry.SetText(dPath)
-	
-			capacityEntry := widget.NewEntry()
-			capacityEntry.SetPlaceHolder("디스크 용량(MB)")
-			capacityEntry.SetText(dCap)
-	
-			var baseSizeMB int64
-			if stMB := getDiskFileSizeMB(dPath, dType); stMB > 0 {
-				baseSizeMB = stMB
-				if diskCapVal, err := strconv.ParseInt(dCap, 10, 64); err == nil && diskCapVal < stMB {
-					capacityEntry.SetText(strconv.FormatInt(stMB, 10))
-				}
-			}
-	
-			capacityEntry.OnChanged = func(text string) {
-				if baseSizeMB > 0 {
-					val, err := strconv.ParseInt(text, 10, 64)
-					if err == nil && val < baseSizeMB {
-						capacityEntry.SetText(strconv.FormatInt(baseSizeMB, 10))
-					}
-				}
-			}
-	
-			// 이미 dPath가 존재하므로, 디스크 타입 드롭다운을 비활성화
-			if dPath != "" {
-				diskTypeSelect.Disable()
-			}
-	
-			var row *fyne.Container
-			loadBtn := widget.NewButton("디스크 가져오기", func() {
-				path, err := sqdialog.File().Title("디스크 파일 가져오기").Load()
-				if err != nil || path == "" {
-					return
-				}
-				pathEntry.SetText(path)
-				if diskSize := getDiskFileSizeMB(path, diskTypeSelect.Selected); diskSize > 0 {
-					baseSizeMB = diskSize
-					capacityEntry.SetText(strconv.FormatInt(diskSize, 10))
-				} else {
-					baseSizeMB = 0
-					capacityEntry.SetText("10240")
-				}
-				// 경로가 바뀌면(=새 파일을 불러오면) 다시 타입 변경 불가
-				diskTypeSelect.Disable()
-			})
-			removeBtn := widget.NewButton("-", func() {
-				if pathEntry.Text == "" {
-					for i, rowItem := range diskRows {
-						if rowItem == row {
-							diskRows = append(diskRows[:i], diskRows[i+1:]...)
-							diskRowsContainer.Remove(row)
-							diskRowsContainer.Refresh()
-							break
-						}
-					}
-					return
-				}
-				dialog.ShowConfirm("디스크 삭제", "디스크 파일도 삭제하시겠습니까?", func(deleteFile bool) {
-					if deleteFile {
-						if _, err := os.Stat(pathEntry.Text); err == nil {
-							os.Remove(pathEntry.Text)
-						}
-					}
-					for i, rowItem := range diskRows {
-						if rowItem == row {
-							diskRows = append(diskRows[:i], diskRows[i+1:]...)
-							diskRowsContainer.Remove(row)
-							diskRowsContainer.Refresh()
-							break
-						}
-					}
-				}, win)
-			})
-	
-			row = container.NewVBox(
-				container.NewHBox(label, diskTypeSelect),
-				container.NewBorder(nil, nil, container.NewHBox(loadBtn, removeBtn), nil, pathEntry),
-				widget.NewForm(
-					widget.NewFormItem("용량(MB)", capacityEntry),
-				),
-			)
-	
-			diskRows = append(diskRows, row)
-			diskRowsContainer.Add(row)
-		}
-		diskRowsContainer.Refresh()
+	for _, d := range parseDiskConfigs(config.Disk) {
+		addDiskRow(d)
 	}
-	
+
 	// header: +버튼, 체크박스 동일 너비
 	headerGrid := container.NewGridWithColumns(2, addDiskButton, fullAllocCheck)
 	header := container.NewVBox(
@@ -660,10 +622,177 @@ func EditVMConfig(vmName string, parent fyne.Window, onSave func()) {
 	)
 	diskPanel := container.NewBorder(header, nil, nil, nil, diskScroll)
 
+	// ─────────────────────────────────────────────
+	// CD/DVD 탭
+	var cdromRows []*cdromRow
+	cdromRowsContainer := container.NewVBox()
+
+	addCDROMRow := func(c cdromConfig) {
+		row := &cdromRow{}
+		label := widget.NewLabel(fmt.Sprintf("CD/DVD %d", len(cdromRows)+1))
+
+		row.busSelect = widget.NewSelect(cdromBusOptions, nil)
+		row.busSelect.PlaceHolder = "버스 (자동)"
+		row.busSelect.SetSelected(c.Bus)
+
+		row.pathEntry = widget.NewEntry()
+		row.pathEntry.SetPlaceHolder("ISO 경로 (비우면 빈 드라이브)")
+		row.pathEntry.SetText(c.Path)
+
+		isoBtn := widget.NewButton("ISO 선택", func() {
+			path, err := sqdialog.File().Title("ISO 이미지 선택").Filter("ISO 이미지", "iso", "img").Load()
+			if err != nil || path == "" {
+				return
+			}
+			row.pathEntry.SetText(path)
+		})
+		libraryBtn := widget.NewButton("라이브러리", func() {
+			pickMediaItem(configDir, win, row.pathEntry.SetText)
+		})
+		ejectBtn := widget.NewButton("꺼내기", func() {
+			row.pathEntry.SetText("")
+		})
+		removeBtn := widget.NewButton("-", func() {
+			for i, rowItem := range cdromRows {
+				if rowItem == row {
+					cdromRows = append(cdromRows[:i], cdromRows[i+1:]...)
+					cdromRowsContainer.Remove(row.box)
+					cdromRowsContainer.Refresh()
+					break
+				}
+			}
+			inputChanged()
+		})
+
+		row.box = container.NewVBox(
+			container.NewHBox(label, row.busSelect),
+			container.NewBorder(nil, nil, container.NewHBox(isoBtn, libraryBtn, ejectBtn, removeBtn), nil, row.pathEntry),
+		)
+		cdromRows = append(cdromRows, row)
+		cdromRowsContainer.Add(row.box)
+		cdromRowsContainer.Refresh()
+	}
+	for _, c := range parseCDROMConfigs(config.CDROM) {
+		addCDROMRow(c)
+	}
+
+	addCDROMButton := widget.NewButton("+", func() {
+		addCDROMRow(cdromConfig{})
+		inputChanged()
+	})
+	cdromPanel := container.NewBorder(
+		container.NewVBox(widget.NewLabel("CD/DVD 드라이브"), addCDROMButton),
+		nil, nil, nil,
+		container.NewVScroll(cdromRowsContainer),
+	)
+
+	// ─────────────────────────────────────────────
+	// 부팅 탭 (순서를 직접 지정하지 않으면 펌웨어 기본 순서)
+	var bootOrder []string
+	if config.BootOrder != "" {
+		bootOrder = strings.Split(config.BootOrder, ",")
+	}
+	selectedBoot := -1
+
+	bootList := widget.NewList(
+		func() int { return len(bootOrder) },
+		func() fyne.CanvasObject { return widget.NewLabel("template") },
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			o.(*widget.Label).SetText(fmt.Sprintf("%d. %s", i+1, bootDeviceLabel(*config, bootOrder[i])))
+		},
+	)
+	bootList.OnSelected = func(id widget.ListItemID) {
+		selectedBoot = id
+	}
+	moveBoot := func(delta int) {
+		target := selectedBoot + delta
+		if selectedBoot < 0 || target < 0 || target >= len(bootOrder) {
+			return
+		}
+		bootOrder[selectedBoot], bootOrder[target] = bootOrder[target], bootOrder[selectedBoot]
+		bootList.Refresh()
+		bootList.Select(target)
+		inputChanged()
+	}
+	bootUpBtn := widget.NewButton("위로", func() { moveBoot(-1) })
+	bootDownBtn := widget.NewButton("아래로", func() { moveBoot(1) })
+
+	customBootCheck := widget.NewCheck("부팅 순서 직접 지정", func(checked bool) {
+		if checked {
+			bootUpBtn.Enable()
+			bootDownBtn.Enable()
+		} else {
+			bootUpBtn.Disable()
+			bootDownBtn.Disable()
+		}
+	})
+	customBootCheck.SetChecked(config.BootOrder != "")
+	customBootCheck.OnChanged(customBootCheck.Checked)
+
+	bootMenuCheck := widget.NewCheck("부팅 메뉴 표시", nil)
+	bootMenuCheck.SetChecked(config.BootMenu == "true")
+	bootTimeoutEntry := widget.NewEntry()
+	bootTimeoutEntry.SetPlaceHolder("메뉴 대기 시간(ms, 예: 5000)")
+	bootTimeoutEntry.SetText(config.BootMenuTimeout)
+
+	// UEFI 펌웨어, TPM (변수 저장소와 swtpm 소켓 경로는 저장할 때 정해짐)
+	firmwareSelect := widget.NewSelect([]string{"BIOS", "UEFI"}, nil)
+	firmwareSelect.SetSelected("BIOS")
+	if config.Firmware == "uefi" {
+		firmwareSelect.SetSelected("UEFI")
+	}
+	tpmCheck := widget.NewCheck("TPM 2.0 (swtpm 에뮬레이터)", nil)
+	tpmCheck.SetChecked(config.TPM != "")
+
+	// 1회 부팅: "disk0 - 하드디스크 1 (...)" 형태로 표시하고 ID만 저장
+	bootOnceSelect := widget.NewSelect(nil, nil)
+	bootOnceSelect.PlaceHolder = "사용 안 함"
+	bootOnceClearBtn := widget.NewButton("해제", func() {
+		bootOnceSelect.ClearSelected()
+	})
+
+	// 현재 입력값(config)의 장치 목록에 맞춰 부팅 목록 갱신
+	refreshBootPanel := func() {
+		devices := bootDevices(*config)
+		bootOrder = syncBootOrder(bootOrder, devices)
+		selectedBoot = -1
+		bootList.UnselectAll()
+		bootList.Refresh()
+
+		var options []string
+		selected := ""
+		for _, id := range devices {
+			option := id + " - " + bootDeviceLabel(*config, id)
+			options = append(options, option)
+			if id == config.BootOnce {
+				selected = option
+			}
+		}
+		bootOnceSelect.Options = options
+		if selected != "" {
+			bootOnceSelect.SetSelected(selected)
+		} else {
+			bootOnceSelect.ClearSelected()
+		}
+		bootOnceSelect.Refresh()
+	}
+
+	bootPanel := container.NewBorder(
+		container.NewVBox(
+			widget.NewLabel("부팅 순서"),
+			container.NewHBox(customBootCheck, bootUpBtn, bootDownBtn),
+		),
+		widget.NewForm(
+			widget.NewFormItem("부팅 메뉴", container.NewVBox(bootMenuCheck, bootTimeoutEntry)),
+			widget.NewFormItem("1회 부팅", container.NewBorder(nil, nil, nil, bootOnceClearBtn, bootOnceSelect)),
+			widget.NewFormItem("펌웨어", firmwareSelect),
+			widget.NewFormItem("TPM", tpmCheck),
+		),
+		nil, nil,
+		bootList,
+	)
+
 	// GPU
-	gpuFrontendOptions := []string{"cirrus", "std", "qxl", "virtio"}
-	gpuDisplayOptions := []string{"gtk", "sdl", "vnc", "none"}
-	gpuDeviceOptions := []string{"virtio-vga", "virtio-gpu", "virtio-gpu-gl", "vhost-user-vga", "vhost-user-gpu"}
 	glOptions := []string{"off", "on"}
 	gpuMemOptions := []string{"128M", "256M", "512M", "1G", "2G", "4G", "8G"}
 
@@ -709,81 +838,226 @@ func EditVMConfig(vmName string, parent fyne.Window, onSave func()) {
 		),
 	)
 
-	// 네트워크, 하드웨어
+	// 직접 커널 부팅 (ARM/MIPS 보드 등)
+	kernelEntry := widget.NewEntry()
+	kernelEntry.SetPlaceHolder("커널 이미지 경로 (-kernel)")
+	kernelEntry.SetText(config.Kernel)
+	initrdEntry := widget.NewEntry()
+	initrdEntry.SetPlaceHolder("initrd 경로 (-initrd)")
+	initrdEntry.SetText(config.Initrd)
+	kernelAppendEntry := widget.NewEntry()
+	kernelAppendEntry.SetPlaceHolder("커널 명령줄 (예: console=ttyAMA0 root=/dev/vda2)")
+	kernelAppendEntry.SetText(config.KernelAppend)
+	dtbEntry := widget.NewEntry()
+	dtbEntry.SetPlaceHolder("디바이스 트리 경로 (-dtb)")
+	dtbEntry.SetText(config.DTB)
+
+	// 파일 선택 버튼이 붙은 입력칸
+	fileField := func(entry *widget.Entry, title string) fyne.CanvasObject {
+		btn := widget.NewButton("선택", func() {
+			path, err := sqdialog.File().Title(title).Load()
+			if err != nil || path == "" {
+				return
+			}
+			entry.SetText(path)
+		})
+		return container.NewBorder(nil, nil, nil, btn, entry)
+	}
+	kernelPanel := container.NewVBox(
+		widget.NewForm(
+			widget.NewFormItem("커널", fileField(kernelEntry, "커널 이미지 선택")),
+			widget.NewFormItem("initrd", fileField(initrdEntry, "initrd 선택")),
+			widget.NewFormItem("명령줄", kernelAppendEntry),
+			widget.NewFormItem("DTB", fileField(dtbEntry, "디바이스 트리 선택")),
+		),
+	)
+
+	// cloud-init 프로비저닝 (클라우드 이미지용 NoCloud seed ISO)
+	ci := config.CloudInit
+	cloudInitCheck := widget.NewCheck("cloud-init 사용 (seed ISO를 CD로 연결)", nil)
+	cloudInitCheck.SetChecked(ci.Enabled)
+	ciHostnameEntry := widget.NewEntry()
+	ciHostnameEntry.SetPlaceHolder("비우면 가상머신 이름")
+	ciHostnameEntry.SetText(ci.Hostname)
+	ciUsersEntry := widget.NewEntry()
+	ciUsersEntry.SetPlaceHolder("쉼표로 구분 (비우면 배포판 기본 사용자)")
+	ciUsersEntry.SetText(ci.Users)
+	ciPasswordEntry := widget.NewPasswordEntry()
+	ciPasswordEntry.SetPlaceHolder("새 암호 (비우면 그대로)")
+	ciPasswordClear := false
+	ciPasswordLabel := widget.NewLabel("")
+	ciPasswordClearBtn := widget.NewButton("지우기", nil)
+	setPasswordLabel := func() {
+		switch {
+		case ciPasswordEntry.Text != "":
+			ciPasswordLabel.SetText("저장할 때 설정")
+		case ci.Password != "" && !ciPasswordClear:
+			ciPasswordLabel.SetText("설정됨")
+		default:
+			ciPasswordLabel.SetText("없음")
+		}
+	}
+	ciPasswordEntry.OnChanged = func(string) { setPasswordLabel() }
+	ciPasswordClearBtn.OnTapped = func() {
+		ciPasswordClear = true
+		ciPasswordEntry.SetText("")
+		setPasswordLabel()
+	}
+	setPasswordLabel()
+	ciSSHKeysEntry := widget.NewMultiLineEntry()
+	ciSSHKeysEntry.SetPlaceHolder("SSH 공개 키 (한 줄에 하나)")
+	ciSSHKeysEntry.SetText(ci.SSHKeys)
+	ciPackagesEntry := widget.NewEntry()
+	ciPackagesEntry.SetPlaceHolder("설치할 패키지 (예: qemu-guest-agent, curl)")
+	ciPackagesEntry.SetText(ci.Packages)
+	ciRunCmdEntry := widget.NewMultiLineEntry()
+	ciRunCmdEntry.SetPlaceHolder("첫 부팅에 실행할 명령 (한 줄에 하나)")
+	ciRunCmdEntry.SetText(ci.RunCmd)
+	ciNetworkEntry := widget.NewMultiLineEntry()
+	ciNetworkEntry.SetPlaceHolder("network-config (v2 YAML, 비우면 DHCP)")
+	ciNetworkEntry.SetText(ci.NetworkConfig)
+
+	// Windows 자동 설치 (autounattend.xml ISO)
+	ua := config.Unattend
+	unattendCheck := widget.NewCheck("Windows 자동 설치 (응답 파일 ISO를 CD로 연결)", nil)
+	unattendCheck.SetChecked(ua.Enabled)
+	uaEditionEntry := widget.NewEntry()
+	uaEditionEntry.SetPlaceHolder("이미지 이름 (예: Windows 11 Pro, 비우면 설치 중 선택)")
+	uaEditionEntry.SetText(ua.Edition)
+	uaProductKeyEntry := widget.NewEntry()
+	uaProductKeyEntry.SetPlaceHolder("XXXXX-XXXXX-XXXXX-XXXXX-XXXXX (선택)")
+	uaProductKeyEntry.SetText(ua.ProductKey)
+	uaLocaleEntry := widget.NewEntry()
+	uaLocaleEntry.SetPlaceHolder(defaultUnattendLocale)
+	uaLocaleEntry.SetText(ua.Locale)
+	uaUserEntry := widget.NewEntry()
+	uaUserEntry.SetPlaceHolder(defaultUnattendUser)
+	uaUserEntry.SetText(ua.User)
+	uaPasswordEntry := widget.NewPasswordEntry()
+	uaPasswordEntry.SetPlaceHolder("새 암호 (비우면 그대로)")
+	uaPasswordClear := false
+	uaPasswordLabel := widget.NewLabel("")
+	uaPasswordClearBtn := widget.NewButton("지우기", nil)
+	setUAPasswordLabel := func() {
+		switch {
+		case uaPasswordEntry.Text != "":
+			uaPasswordLabel.SetText("저장할 때 설정")
+		case ua.Password != "" && !uaPasswordClear:
+			uaPasswordLabel.SetText("설정됨")
+		default:
+			uaPasswordLabel.SetText("없음")
+		}
+	}
+	uaPasswordEntry.OnChanged = func(string) { setUAPasswordLabel() }
+	uaPasswordClearBtn.OnTapped = func() {
+		uaPasswordClear = true
+		uaPasswordEntry.SetText("")
+		setUAPasswordLabel()
+	}
+	setUAPasswordLabel()
+	partitionOptions := []string{"자동 (디스크 0 전체 사용)", "설치 중 직접 지정"}
+	uaPartitionSelect := widget.NewSelect(partitionOptions, nil)
+	uaPartitionSelect.SetSelected(partitionOptions[0])
+	if ua.Partition == "manual" {
+		uaPartitionSelect.SetSelected(partitionOptions[1])
+	}
+	uaDriverISOEntry := widget.NewEntry()
+	uaDriverISOEntry.SetPlaceHolder("virtio-win.iso 등 (선택)")
+	uaDriverISOEntry.SetText(ua.DriverISO)
+	uaDriversEntry := widget.NewMultiLineEntry()
+	uaDriversEntry.SetPlaceHolder("드라이버 폴더 (한 줄에 하나, 드라이브 문자가 없으면 D:~H:에서 찾음)")
+	uaDriversEntry.SetText(ua.Drivers)
+	uaVirtioBtn := widget.NewButton("virtio-win 기본값", func() {
+		uaDriversEntry.SetText(virtioWinDrivers(VMConfig{CPUModel: cpuModelSelect.Selected}))
+	})
+
+	// 게스트 에이전트 채널 (소켓 경로는 저장할 때 정해짐)
+	guestAgentCheck := widget.NewCheck("QEMU 게스트 에이전트 채널 (게스트에 qemu-ga 설치 필요)", nil)
+	guestAgentCheck.SetChecked(config.GuestAgent != "")
+
+	provisionPanel := container.NewVScroll(container.NewVBox(
+		guestAgentCheck,
+		widget.NewSeparator(),
+		cloudInitCheck,
+		widget.NewForm(
+			widget.NewFormItem("호스트 이름", ciHostnameEntry),
+			widget.NewFormItem("사용자", ciUsersEntry),
+			widget.NewFormItem("암호", container.NewBorder(nil, nil, nil, container.NewHBox(ciPasswordLabel, ciPasswordClearBtn), ciPasswordEntry)),
+			widget.NewFormItem("SSH 키", ciSSHKeysEntry),
+			widget.NewFormItem("패키지", ciPackagesEntry),
+			widget.NewFormItem("runcmd", ciRunCmdEntry),
+			widget.NewFormItem("network-config", ciNetworkEntry),
+		),
+		widget.NewSeparator(),
+		unattendCheck,
+		widget.NewForm(
+			widget.NewFormItem("에디션", uaEditionEntry),
+			widget.NewFormItem("제품 키", uaProductKeyEntry),
+			widget.NewFormItem("로캘", uaLocaleEntry),
+			widget.NewFormItem("사용자", uaUserEntry),
+			widget.NewFormItem("암호", container.NewBorder(nil, nil, nil, container.NewHBox(uaPasswordLabel, uaPasswordClearBtn), uaPasswordEntry)),
+			widget.NewFormItem("파티션", uaPartitionSelect),
+			widget.NewFormItem("드라이버 ISO", fileField(uaDriverISOEntry, "드라이버 ISO 선택")),
+			widget.NewFormItem("드라이버 경로", container.NewBorder(nil, uaVirtioBtn, nil, nil, uaDriversEntry)),
+		),
+	))
+
+	// 네트워크
 	networkEntry := widget.NewEntry()
 	networkEntry.SetPlaceHolder("네트워크 설정 (예: user)")
 	networkEntry.SetText(config.Network)
 
-	hwEntry := widget.NewMultiLineEntry()
-	hwEntry.SetPlaceHolder("하드웨어 설정 (커널, 바이오스, 디스크 파일 등)")
-	hwEntry.SetText(config.HW)
+	// 추가 인자: 설정으로 만들 수 없는 QEMU 옵션을 그대로 덧붙임
+	extraArgsEntry := widget.NewEntry()
+	extraArgsEntry.SetPlaceHolder(`추가 인자 (예: -device usb-tablet -rtc base=localtime)`)
+	extraArgsEntry.SetText(config.ExtraArgs)
 
 	rightPanel := container.NewMax()
 
-	updateConfigFromEntries := func() {
-		config.Name = nameEntry.Text
-		config.CPUModel = cpuModelSelect.Selected
-		config.CPUCores = cpuCoresSelect.Selected
-		config.CPUSockets = cpuSocketsSelect.Selected
-		config.CPUThreads = cpuThreadsEntry.Text
-		config.CPUFeatures = cpuFeaturesEntry.Text
-		config.CPUAccel = strconv.FormatBool(cpuAccelCheck.Checked)
-		if cpuAccelCheck.Checked && acceleratorSelect.Selected == "" {
-			acceleratorSelect.SetSelected(acceleratorOptions[0])
+	// fillConfig는 입력 값을 c에 옮깁니다. 미리보기는 config의 복사본에 채워서 씁니다.
+	fillConfig := func(c *VMConfig) {
+		c.Name = nameEntry.Text
+		c.Managed = managedCheck.Checked
+		c.Machine = machineSelect.Selected
+		c.CPUModel = cpuModelSelect.Selected
+		c.CPUCores = cpuCoresSelect.Selected
+		c.CPUSockets = cpuSocketsSelect.Selected
+		c.CPUThreads = cpuThreadsEntry.Text
+		c.CPUFeatures = cpuFeaturesEntry.Text
+		c.CPUAccel = strconv.FormatBool(cpuAccelCheck.Checked)
+		c.CPUAccelerator = acceleratorSelect.Selected
+		if cpuAccelCheck.Checked && c.CPUAccelerator == "" {
+			c.CPUAccelerator = acceleratorOptions[0]
 		}
-		config.CPUAccelerator = acceleratorSelect.Selected
-		config.RAM = ramEntry.Text + ramUnitSelect.Selected
+		c.RAM = ramEntry.Text + ramUnitSelect.Selected
 
-		var disks []string
+		var disks []diskConfig
+		var usedPaths []string
 		for _, row := range diskRows {
-			if len(row.Objects) < 2 {
-				continue
-			}
-			hbox, ok := row.Objects[0].(*fyne.Container)
-			if !ok || len(hbox.Objects) < 2 {
-				continue
-			}
-			diskTypeSelect, ok := hbox.Objects[1].(*widget.Select)
-			if !ok {
-				continue
-			}
-			dType := diskTypeSelect.Selected
-
-			border, ok := row.Objects[1].(*fyne.Container)
-			if !ok {
-				continue
-			}
-			var pathEntry *widget.Entry
-			for _, obj := range border.Objects {
-				if entry, ok2 := obj.(*widget.Entry); ok2 {
-					pathEntry = entry
-					break
-				}
-			}
-			var capacityEntry *widget.Entry
-			if len(row.Objects) > 2 {
-				if formObj, ok := row.Objects[2].(*widget.Form); ok {
-					if len(formObj.Items) > 0 {
-						if e, ok2 := formObj.Items[0].Widget.(*widget.Entry); ok2 {
-							capacityEntry = e
-						}
-					}
-				}
+			usedPaths = append(usedPaths, row.pathEntry.Text)
+		}
+		// 관리형이면 경로를 비워 둔 디스크는 가상머신 폴더에 만듦 (이름을 바꾸는 중이면 옮기기 전 폴더 기준)
+		vmDir := vmStorageDir(configDir, c.Name)
+		if vmName != "" {
+			vmDir = vmStorageDir(configDir, vmName)
+		}
+		for _, row := range diskRows {
+			d := row.diskConfig()
+			if d.Path == "" && c.Managed && c.Name != "" {
+				d.Path = newVMDiskPath(vmDir, diskFileExt(d.Type), usedPaths)
+				usedPaths = append(usedPaths, d.Path)
 			}
-			if pathEntry != nil && pathEntry.Text != "" {
-				dPath := pathEntry.Text
-				dCap := ""
-				if capacityEntry != nil {
-					dCap = capacityEntry.Text
-				}
-				disks = append(disks, dType+":"+dPath+":"+dCap)
+			if d.Path != "" {
+				disks = append(disks, d)
 			}
 		}
-		if len(disks) > 0 {
-			config.Disk = strings.Join(disks, ";")
-		} else {
-			config.Disk = ""
+		c.Disk = formatDiskConfigs(disks)
+
+		var cdroms []cdromConfig
+		for _, row := range cdromRows {
+			cdroms = append(cdroms, cdromConfig{Bus: row.busSelect.Selected, Path: row.pathEntry.Text})
 		}
+		c.CDROM = formatCDROMConfigs(cdroms)
 
 		var gpuPairs []string
 		if gpuFrontendSelect.Selected != "" {
@@ -801,10 +1075,67 @@ func EditVMConfig(vmName string, parent fyne.Window, onSave func()) {
 		if gpuMemSelect.Selected != "" {
 			gpuPairs = append(gpuPairs, "hostmem="+gpuMemSelect.Selected)
 		}
-		config.GPU = strings.Join(gpuPairs, ",")
+		c.GPU = strings.Join(gpuPairs, ",")
+
+		c.Network = networkEntry.Text
+		c.ExtraArgs = strings.TrimSpace(extraArgsEntry.Text)
+		c.Kernel = strings.TrimSpace(kernelEntry.Text)
+		c.Initrd = strings.TrimSpace(initrdEntry.Text)
+		c.KernelAppend = strings.TrimSpace(kernelAppendEntry.Text)
+		c.DTB = strings.TrimSpace(dtbEntry.Text)
+
+		if customBootCheck.Checked {
+			c.BootOrder = strings.Join(syncBootOrder(bootOrder, bootDevices(*c)), ",")
+		} else {
+			c.BootOrder = ""
+		}
+		c.BootMenu = strconv.FormatBool(bootMenuCheck.Checked)
+		c.BootMenuTimeout = strings.TrimSpace(bootTimeoutEntry.Text)
+		c.BootOnce, _, _ = strings.Cut(bootOnceSelect.Selected, " - ")
+
+		c.Firmware = ""
+		if firmwareSelect.Selected == "UEFI" {
+			c.Firmware = "uefi"
+		}
+		switch {
+		case !tpmCheck.Checked:
+			c.TPM = ""
+		case c.TPM == "" || vmName == "":
+			c.TPM = defaultTPMSocket(configDir, *c)
+		}
 
-		config.Network = networkEntry.Text
-		config.HW = hwEntry.Text
+		switch {
+		case !guestAgentCheck.Checked:
+			c.GuestAgent = ""
+		case c.GuestAgent == "" || vmName == "":
+			c.GuestAgent = defaultGuestAgentSocket(configDir, *c)
+		}
+
+		// 암호는 저장할 때 해시로 바꿈
+		c.CloudInit.Enabled = cloudInitCheck.Checked
+		c.CloudInit.Hostname = strings.TrimSpace(ciHostnameEntry.Text)
+		c.CloudInit.Users = strings.TrimSpace(ciUsersEntry.Text)
+		c.CloudInit.SSHKeys = strings.TrimSpace(ciSSHKeysEntry.Text)
+		c.CloudInit.Packages = strings.TrimSpace(ciPackagesEntry.Text)
+		c.CloudInit.RunCmd = strings.TrimSpace(ciRunCmdEntry.Text)
+		c.CloudInit.NetworkConfig = strings.TrimSpace(ciNetworkEntry.Text)
+		c.Unattend.Enabled = unattendCheck.Checked
+		c.Unattend.Edition = strings.TrimSpace(uaEditionEntry.Text)
+		c.Unattend.ProductKey = strings.TrimSpace(uaProductKeyEntry.Text)
+		c.Unattend.Locale = strings.TrimSpace(uaLocaleEntry.Text)
+		c.Unattend.User = strings.TrimSpace(uaUserEntry.Text)
+		c.Unattend.Partition = ""
+		if uaPartitionSelect.Selected == partitionOptions[1] {
+			c.Unattend.Partition = "manual"
+		}
+		c.Unattend.DriverISO = strings.TrimSpace(uaDriverISOEntry.Text)
+		c.Unattend.Drivers = strings.TrimSpace(uaDriversEntry.Text)
+	}
+	updateConfigFromEntries := func() {
+		if cpuAccelCheck.Checked && acceleratorSelect.Selected == "" {
+			acceleratorSelect.SetSelected(acceleratorOptions[0])
+		}
+		fillConfig(config)
 	}
 
 	setRightPanel := func(content fyne.CanvasObject) {
@@ -815,6 +1146,8 @@ func EditVMConfig(vmName string, parent fyne.Window, onSave func()) {
 	basicPanel := container.NewVBox(
 		widget.NewForm(
 			widget.NewFormItem("이름", nameEntry),
+			widget.NewFormItem("보관", managedCheck),
+			widget.NewFormItem("머신 종류", machineSelect),
 		),
 	)
 	networkPanel := container.NewVBox(
@@ -822,152 +1155,367 @@ func EditVMConfig(vmName string, parent fyne.Window, onSave func()) {
 			widget.NewFormItem("네트워크", networkEntry),
 		),
 	)
-	hwPanel := container.NewVBox(
-		widget.NewForm(
-			widget.NewFormItem("하드웨어", hwEntry),
-		),
+
+	extraArgsPanel := container.NewVBox(
+		widget.NewForm(widget.NewFormItem("추가 인자", extraArgsEntry)),
+		widget.NewLabel("공백으로 구분하며, 공백이 있는 값은 따옴표로 감쌉니다. 설정에서 만든 옵션과 겹치면 안 됩니다."),
 	)
 
 	btnBasic := widget.NewButton("기본정보", func() { setRightPanel(basicPanel) })
 	btnCPU := widget.NewButton("CPU", func() { setRightPanel(cpuPanel) })
 	btnRAM := widget.NewButton("RAM", func() { setRightPanel(ramPanel) })
 	btnDisk := widget.NewButton("하드디스크", func() { setRightPanel(diskPanel) })
+	btnCDROM := widget.NewButton("CD/DVD", func() { setRightPanel(cdromPanel) })
+	btnBoot := widget.NewButton("부팅", func() {
+		updateConfigFromEntries()
+		refreshBootPanel()
+		setRightPanel(bootPanel)
+	})
+	btnKernel := widget.NewButton("커널", func() { setRightPanel(kernelPanel) })
 	btnGPU := widget.NewButton("GPU", func() { setRightPanel(gpuPanel) })
+	btnProvision := widget.NewButton("프로비저닝", func() { setRightPanel(provisionPanel) })
 	btnNetwork := widget.NewButton("네트워크", func() { setRightPanel(networkPanel) })
-	btnHW := widget.NewButton("하드웨어", func() { setRightPanel(hwPanel) })
-	leftPanel := container.NewVBox(btnBasic, btnCPU, btnRAM, btnDisk, btnGPU, btnNetwork, btnHW)
+	btnExtraArgs := widget.NewButton("추가 인자", func() { setRightPanel(extraArgsPanel) })
+	leftPanel := container.NewVBox(btnBasic, btnCPU, btnRAM, btnDisk, btnCDROM, btnBoot, btnKernel, btnProvision, btnGPU, btnNetwork, btnExtraArgs)
 
 	setRightPanel(basicPanel)
 
-	saveBtn := widget.NewButton("저장", func() {
-		updateConfigFromEntries()
-		if config.Name == "" {
-			dialog.ShowError(errEmptyName(), win)
-			return
-		}
+	// 설정 저장 (새 암호화 디스크의 암호는 passphrases에, 자격 증명 저장 여부는 storePass에 경로별로 담김)
+	saveConfig := func(passphrases map[string]string, storePass map[string]bool) {
 		if vmName != "" && vmName != config.Name {
-			oldPath := filepath.Join(configDir, vmName+".conf")
-			newPath := filepath.Join(configDir, config.Name+".conf")
-			if err := os.Rename(oldPath, newPath); err != nil {
+			if config.Managed {
+				// 설정 파일이 폴더 안에 있으므로 폴더째 옮김
+				if err := moveVMDir(configDir, config, vmName); err != nil {
+					dialog.ShowError(err, win)
+					return
+				}
+			} else {
+				oldPath := filepath.Join(configDir, vmName+".conf")
+				newPath := filepath.Join(configDir, config.Name+".conf")
+				if err := os.Rename(oldPath, newPath); err != nil {
+					dialog.ShowError(err, win)
+					return
+				}
+			}
+			vmName = config.Name
+		} else if vmName == "" && config.Managed {
+			if err := createVMDir(configDir, config.Name); err != nil {
 				dialog.ShowError(err, win)
 				return
 			}
 		}
 
 		// 최종 저장 시, 없는 디스크 파일은 qemu-img create
-		disks := strings.Split(config.Disk, ";")
-		for _, diskInfo := range disks {
-			dType, dPath, dCap := parseDiskInfo(diskInfo)
-			if dPath == "" {
-				continue
-			}
+		for _, d := range parseDiskConfigs(config.Disk) {
+			dPath := d.Path
 			if _, err := os.Stat(dPath); os.IsNotExist(err) {
-				capacityVal, err := strconv.ParseInt(dCap, 10, 64)
+				capacityVal, err := strconv.ParseInt(d.Capacity, 10, 64)
 				if err != nil || capacityVal < 1 {
 					capacityVal = 10240
 				}
-				formatMap := map[string]string{
-					"QCOW2": "qcow2",
-					"RAW":   "raw",
-					"VHD":   "vpc",
-					"VMDK":  "vmdk",
-				}
-				format := formatMap[dType]
-				if format == "" {
-					format = "qcow2"
+				format := qemuImgFormat(d.Type)
+
+				baseArgs := []string{"create", "-f", format}
+				// LUKS 암호화: 암호는 임시 파일로 secret 객체에 전달
+				secretPath := diskSecretPath(dPath)
+				if d.Encrypted {
+					if err := writeSecretFile(secretPath, passphrases[dPath]); err != nil {
+						dialog.ShowError(err, win)
+						return
+					}
+					baseArgs = append(baseArgs,
+						"--object", "secret,id=sec0,format=raw,file="+escapeOptionValue(secretPath),
+						"-o", "encrypt.format=luks,encrypt.key-secret=sec0")
 				}
+				baseArgs = append(baseArgs, dPath, fmt.Sprintf("%dM", capacityVal))
 
-				cmdArgs := []string{"create", "-f", format, dPath, fmt.Sprintf("%dM", capacityVal)}
+				cmdArgs := baseArgs
 				if fullAllocCheck.Checked && format == "qcow2" {
 					cmdArgs = append(cmdArgs, "-o", "preallocation=full")
 				}
 				cmd := exec.Command("qemu-img", cmdArgs...)
 				if err2 := cmd.Run(); err2 != nil {
 					// fallback
-					fallbackArgs := []string{"create", "-f", format, dPath, fmt.Sprintf("%dM", capacityVal)}
-					fallbackCmd := exec.Command("qemu-img", fallbackArgs...)
+					fallbackCmd := exec.Command("qemu-img", baseArgs...)
 					if err3 := fallbackCmd.Run(); err3 != nil {
+						os.Remove(secretPath)
 						dialog.ShowError(fmt.Errorf("preallocation=full 실패 후 fallback도 실패: %v", err3), win)
 						return
 					} else {
 						dialog.ShowInformation("경고", "preallocation=full이 실패하여 기본 방식으로 생성했습니다.", win)
 					}
 				}
+				if d.Encrypted {
+					os.Remove(secretPath)
+					if storePass[dPath] {
+						if err := storeDiskPassphrase(dPath, passphrases[dPath]); err != nil {
+							dialog.ShowError(fmt.Errorf("암호를 자격 증명 관리자에 저장하지 못했습니다: %v", err), win)
+						}
+					}
+				}
 			}
 		}
 
-		configContent := "name=" + config.Name + "\n" +
-			"cpuModel=" + config.CPUModel + "\n" +
-			"cpuCores=" + config.CPUCores + "\n" +
-			"cpuSockets=" + config.CPUSockets + "\n" +
-			"cpuThreads=" + config.CPUThreads + "\n" +
-			"cpuFeatures=" + config.CPUFeatures + "\n" +
-			"cpuAccel=" + config.CPUAccel + "\n" +
-			"cpuAccelerator=" + config.CPUAccelerator + "\n" +
-			"ram=" + config.RAM + "\n" +
-			"disk=" + config.Disk + "\n" +
-			"gpu=" + config.GPU + "\n" +
-			"network=" + config.Network + "\n" +
-			"hw=" + config.HW + "\n"
-
-		if err := ioutil.WriteFile(filepath.Join(configDir, config.Name+".conf"), []byte(configContent), 0644); err != nil {
+		if config.UUID == "" {
+			config.UUID = newVMUUID()
+		}
+		if config.MAC == "" {
+			config.MAC = newVMMAC()
+		}
+		if err := ensureNVRAM(configDir, config); err != nil {
+			dialog.ShowError(fmt.Errorf("UEFI 변수 저장소를 만들지 못했습니다: %v", err), win)
+			return
+		}
+		switch {
+		case ciPasswordEntry.Text != "":
+			hash, err := sha512Crypt(ciPasswordEntry.Text)
+			if err != nil {
+				dialog.ShowError(err, win)
+				return
+			}
+			config.CloudInit.Password = hash
+		case ciPasswordClear:
+			config.CloudInit.Password = ""
+		}
+		if err := ensureCloudInitSeed(configDir, config); err != nil {
+			dialog.ShowError(fmt.Errorf("cloud-init seed ISO를 만들지 못했습니다: %v", err), win)
+			return
+		}
+		switch {
+		case uaPasswordEntry.Text != "":
+			config.Unattend.Password = encodeUnattendPassword(uaPasswordEntry.Text)
+		case uaPasswordClear:
+			config.Unattend.Password = ""
+		}
+		if err := ensureUnattendMedia(configDir, config); err != nil {
+			dialog.ShowError(fmt.Errorf("자동 설치 응답 파일 ISO를 만들지 못했습니다: %v", err), win)
+			return
+		}
+
+		if err := saveVMConfig(configDir, *config); err != nil {
 			dialog.ShowError(err, win)
 		} else {
-			dialog.ShowInformation("저장", "설정이 저장되었습니다.", parent)
+			message := "설정이 저장되었습니다."
+			if config.TPM != "" {
+				message += "\n\nTPM에는 swtpm이 필요합니다. 가상머신을 시작할 때 함께 실행됩니다."
+			}
+			if config.Unattend.Enabled && config.Firmware == "uefi" {
+				message += "\n\nUEFI에서는 설치 CD로 처음 부팅할 때 \"아무 키나 누르십시오\"가 나오면 키를 한 번 눌러야 합니다."
+			}
+			dialog.ShowInformation("저장", message, parent)
 			win.Close()
 			if onSave != nil {
 				onSave()
 			}
 		}
+	}
+
+	saveBtn := widget.NewButton("저장", func() {
+		updateConfigFromEntries()
+		if config.Name == "" {
+			dialog.ShowError(errEmptyName(), win)
+			return
+		}
+		if config.Name != vmName && vmConfigExists(configDir, config.Name) {
+			dialog.ShowError(fmt.Errorf("%s 가상머신이 이미 있습니다.", config.Name), win)
+			return
+		}
+		if err := validateDisks(parseDiskConfigs(config.Disk), vmCDROMs(*config), vmMachine(*config)); err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+
+		if err := validateKernelBoot(*config); err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		if err := validateFirmware(*config); err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		if err := validateExtraArgs(*config); err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		if _, err := strconv.Atoi(config.BootMenuTimeout); config.BootMenuTimeout != "" && err != nil {
+			dialog.ShowError(fmt.Errorf("부팅 메뉴 대기 시간이 올바르지 않습니다: %s", config.BootMenuTimeout), win)
+			return
+		}
+		for _, c := range parseCDROMConfigs(config.CDROM) {
+			if _, err := os.Stat(c.Path); c.Path != "" && err != nil {
+				dialog.ShowError(fmt.Errorf("ISO 파일을 찾을 수 없습니다: %s", c.Path), win)
+				return
+			}
+		}
+
+		// 새로 만들 암호화 디스크의 암호를 차례로 입력받은 뒤 저장
+		var newEncrypted []string
+		for _, d := range parseDiskConfigs(config.Disk) {
+			if _, err := os.Stat(d.Path); d.Encrypted && os.IsNotExist(err) {
+				newEncrypted = append(newEncrypted, d.Path)
+			}
+		}
+		passphrases := make(map[string]string)
+		storePass := make(map[string]bool)
+		var askNext func(i int)
+		askNext = func(i int) {
+			if i == len(newEncrypted) {
+				saveConfig(passphrases, storePass)
+				return
+			}
+			askDiskPassphrase(newEncrypted[i], win, func(passphrase string, store bool) {
+				passphrases[newEncrypted[i]] = passphrase
+				storePass[newEncrypted[i]] = store
+				askNext(i + 1)
+			})
+		}
+		askNext(0)
 	})
 	cancelBtn := widget.NewButton("취소", func() {
 		win.Close()
 	})
 	bottomBar := container.NewHBox(saveBtn, cancelBtn)
 
+	// 입력이 바뀔 때마다 갱신되는 명령줄 미리보기
+	// (편집 중인 config는 건드리지 않도록 복사본으로 만듦)
+	preview := newCommandPreview(win, func() (string, []string, error) {
+		c := *config
+		fillConfig(&c)
+		return buildQEMUArgs(c)
+	})
+	// 행 추가로 새로 생긴 위젯도 잡도록 바뀔 때마다 다시 훑음
+	watched := make(map[fyne.CanvasObject]bool)
+	onInput = func() {
+		for _, panel := range []fyne.CanvasObject{basicPanel, cpuPanel, ramPanel, diskPanel, cdromPanel, bootPanel, kernelPanel, provisionPanel, gpuPanel, networkPanel, extraArgsPanel} {
+			watchInputs(panel, onInput, watched)
+		}
+		preview.schedule()
+	}
+	onInput()
+
 	split := container.NewHSplit(leftPanel, rightPanel)
 	split.SetOffset(0.2)
-	content := container.NewBorder(nil, bottomBar, nil, nil, split)
+	content := container.NewBorder(nil, container.NewVBox(preview.box, bottomBar), nil, nil, split)
 
 	win.SetContent(content)
 	win.CenterOnScreen()
 	win.Show()
 }
 
-// 줄바꿈(\n, \r\n) 지원
-func splitLines(s string) []string {
-	var lines []string
-	tmp := ""
-	for _, r := range s {
-		if r == '\n' {
-			lines = append(lines, tmp)
-			tmp = ""
-		} else if r != '\r' {
-			tmp += string(r)
-		}
+func errEmptyName() error {
+	return &emptyNameError{}
+}
+
+type emptyNameError struct{}
+
+func (e *emptyNameError) Error() string {
+	return "가상머신 이름을 입력하십시오."
+}
+
+// 디스크 설정 탭의 한 행
+type diskRow struct {
+	box                *fyne.Container
+	typeSelect         *widget.Select
+	pathEntry          *widget.Entry
+	capacityEntry      *widget.Entry
+	busSelect          *widget.Select
+	cacheSelect        *widget.Select
+	aioSelect          *widget.Select
+	discardCheck       *widget.Check
+	detectZeroesSelect *widget.Select
+	encryptCheck       *widget.Check
+	throttle           map[string]string
+}
+
+func (r *diskRow) diskConfig() diskConfig {
+	return diskConfig{
+		Type:         r.typeSelect.Selected,
+		Path:         r.pathEntry.Text,
+		Capacity:     r.capacityEntry.Text,
+		Bus:          r.busSelect.Selected,
+		Cache:        r.cacheSelect.Selected,
+		AIO:          r.aioSelect.Selected,
+		Discard:      r.discardCheck.Checked,
+		DetectZeroes: r.detectZeroesSelect.Selected,
+		Encrypted:    r.encryptCheck.Checked,
+		Throttle:     r.throttle,
+	}
+}
+
+// "iops-read-max" → "IOPS 읽기 버스트"
+func throttleLimitLabel(key string) string {
+	names := map[string]string{"total": "전체", "read": "읽기", "write": "쓰기"}
+	parts := strings.Split(key, "-")
+	label := "IOPS"
+	if parts[0] == "bps" {
+		label = "대역폭(B/s)"
 	}
-	if tmp != "" {
-		lines = append(lines, tmp)
+	label += " " + names[parts[1]]
+	if len(parts) > 2 {
+		label += " 버스트"
 	}
-	return lines
+	return label
 }
 
-// "key=value" 분할
-func splitKeyValue(s string) []string {
-	for i, ch := range s {
-		if ch == '=' {
-			return []string{s[:i], s[i+1:]}
-		}
+// showThrottleForm은 I/O 제한 입력 창을 띄우고, 올바른 값이면 onDone에 넘깁니다.
+func showThrottleForm(current map[string]string, win fyne.Window, onDone func(throttle map[string]string)) {
+	entries := make(map[string]*widget.Entry)
+	var items []*widget.FormItem
+	for _, key := range throttleLimitKeys {
+		entry := widget.NewEntry()
+		entry.SetPlaceHolder("제한 없음")
+		entry.SetText(current[key])
+		entries[key] = entry
+		items = append(items, widget.NewFormItem(throttleLimitLabel(key), entry))
 	}
-	return []string{}
+	dialog.ShowForm("디스크 I/O 제한", "확인", "취소", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		throttle := make(map[string]string)
+		for key, entry := range entries {
+			if text := strings.TrimSpace(entry.Text); text != "" {
+				throttle[key] = text
+			}
+		}
+		if err := validateThrottle(throttle); err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		onDone(throttle)
+	}, win)
 }
 
-func errEmptyName() error {
-	return &emptyNameError{}
+func throttleButtonText(throttle map[string]string) string {
+	if len(throttle) == 0 {
+		return "I/O 제한"
+	}
+	return fmt.Sprintf("I/O 제한 (%d)", len(throttle))
 }
 
-type emptyNameError struct{}
+// CD/DVD 탭의 한 행
+type cdromRow struct {
+	box       *fyne.Container
+	busSelect *widget.Select
+	pathEntry *widget.Entry
+}
 
-func (e *emptyNameError) Error() string {
-	return "가상머신 이름을 입력하십시오."
+// 디스크 행의 사용량/기반 이미지 표시 갱신
+func setDiskInfoLabel(label *widget.Label, path string) {
+	var lines []string
+	if path != "" {
+		if usage, err := getDiskUsage(path); err == nil {
+			lines = append(lines, diskUsageText(usage))
+		}
+	}
+	if text := backingChainText(path); text != "" {
+		lines = append(lines, text)
+	}
+	if len(lines) > 0 {
+		label.SetText(strings.Join(lines, "\n"))
+		label.Show()
+	} else {
+		label.SetText("")
+		label.Hide()
+	}
 }
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// diskConfig는 VMConfig.Disk의 디스크 한 개입니다.
+// 저장 형식: "QCOW2:E:\QEMU\disk.qcow2:10240|bus=virtio-blk,cache=none,aio=native,discard=unmap"
+// ('|'는 Windows 경로에 쓸 수 없으므로 구분자로 사용)
+type diskConfig struct {
+	Type         string // QCOW2, RAW, VHD, VMDK
+	Path         string
+	Capacity     string // MB
+	Bus          string // 비어 있으면 머신 종류에 따라 자동
+	Cache        string // 비어 있으면 writeback
+	AIO          string // 비어 있으면 threads
+	Discard      bool   // discard=unmap
+	DetectZeroes string // 비어 있으면 off
+	Encrypted    bool   // LUKS 암호화 QCOW2 (encrypt=luks)
+
+	// I/O 제한 ("iops-total", "bps-read-max" 등 throttleLimitKeys 이름 → 값)
+	Throttle map[string]string
+}
+
+// QEMU throttle-group의 limits.* 이름. -max는 버스트 한도입니다.
+// iops는 초당 요청 수, bps는 초당 바이트 수입니다.
+var throttleLimitKeys = []string{
+	"iops-total", "iops-read", "iops-write",
+	"iops-total-max", "iops-read-max", "iops-write-max",
+	"bps-total", "bps-read", "bps-write",
+	"bps-total-max", "bps-read-max", "bps-write-max",
+}
+
+var (
+	diskBusOptions          = []string{"virtio-blk", "virtio-scsi", "nvme", "ide", "ahci"}
+	diskCacheOptions        = []string{"writeback", "none", "writethrough", "directsync", "unsafe"}
+	diskAIOOptions          = []string{"threads", "native", "io_uring"}
+	diskDetectZeroesOptions = []string{"off", "on", "unmap"}
+)
+
+func parseDiskConfig(diskInfo string) diskConfig {
+	base, opts, _ := strings.Cut(diskInfo, "|")
+	dType, dPath, dCap := parseDiskInfo(base)
+	d := diskConfig{Type: dType, Path: dPath, Capacity: dCap}
+	parsed := parseGPUString(opts)
+	d.Bus = parsed["bus"]
+	d.Cache = parsed["cache"]
+	d.AIO = parsed["aio"]
+	d.Discard = parsed["discard"] == "unmap"
+	d.DetectZeroes = parsed["detect-zeroes"]
+	d.Encrypted = parsed["encrypt"] == "luks"
+	for _, key := range throttleLimitKeys {
+		if value := parsed[key]; value != "" {
+			if d.Throttle == nil {
+				d.Throttle = make(map[string]string)
+			}
+			d.Throttle[key] = value
+		}
+	}
+	return d
+}
+
+// parseDiskConfigs는 ';'로 구분된 디스크 목록을 읽습니다. 경로가 없는 항목은 건너뜁니다.
+func parseDiskConfigs(disk string) []diskConfig {
+	var disks []diskConfig
+	if disk == "" {
+		return disks
+	}
+	for _, diskInfo := range strings.Split(disk, ";") {
+		if d := parseDiskConfig(diskInfo); d.Path != "" {
+			disks = append(disks, d)
+		}
+	}
+	return disks
+}
+
+func (d diskConfig) String() string {
+	s := d.Type + ":" + d.Path + ":" + d.Capacity
+	var opts []string
+	if d.Bus != "" {
+		opts = append(opts, "bus="+d.Bus)
+	}
+	if d.Cache != "" {
+		opts = append(opts, "cache="+d.Cache)
+	}
+	if d.AIO != "" {
+		opts = append(opts, "aio="+d.AIO)
+	}
+	if d.Discard {
+		opts = append(opts, "discard=unmap")
+	}
+	if d.DetectZeroes != "" {
+		opts = append(opts, "detect-zeroes="+d.DetectZeroes)
+	}
+	if d.Encrypted {
+		opts = append(opts, "encrypt=luks")
+	}
+	for _, key := range throttleLimitKeys {
+		if value := d.Throttle[key]; value != "" {
+			opts = append(opts, key+"="+value)
+		}
+	}
+	if len(opts) > 0 {
+		s += "|" + strings.Join(opts, ",")
+	}
+	return s
+}
+
+func formatDiskConfigs(disks []diskConfig) string {
+	var parts []string
+	for _, d := range disks {
+		parts = append(parts, d.String())
+	}
+	return strings.Join(parts, ";")
+}
+
+// 머신 종류별 기본 디스크 버스
+func defaultDiskBus(machine string) string {
+	switch machine {
+	case "pc", "malta":
+		return "ide"
+	case "q35":
+		return "ahci"
+	}
+	return "virtio-blk"
+}
+
+func (d diskConfig) bus(machine string) string {
+	if d.Bus != "" {
+		return d.Bus
+	}
+	return defaultDiskBus(machine)
+}
+
+// 머신 종류에서 사용할 수 있는 디스크 버스
+func machineDiskBuses(machine string) []string {
+	switch machine {
+	case "pc", "q35":
+		return []string{"virtio-blk", "virtio-scsi", "nvme", "ide", "ahci"}
+	case "malta":
+		return []string{"virtio-blk", "virtio-scsi", "nvme", "ide"}
+	case "virt":
+		return []string{"virtio-blk", "virtio-scsi", "nvme"}
+	}
+	return nil
+}
+
+// validateDiskConfig는 디스크 옵션 조합과 머신 종류에 맞는 버스인지 확인합니다.
+func validateDiskConfig(d diskConfig, machine string) error {
+	name := d.Path
+	bus := d.bus(machine)
+	supported := false
+	for _, b := range machineDiskBuses(machine) {
+		if b == bus {
+			supported = true
+			break
+		}
+	}
+	if !supported {
+		return fmt.Errorf("%s: %s 머신에서는 %s 버스를 사용할 수 없습니다.", name, machine, bus)
+	}
+	if d.AIO == "native" && d.Cache != "none" && d.Cache != "directsync" {
+		return fmt.Errorf("%s: aio=native는 cache=none 또는 directsync에서만 사용할 수 있습니다.", name)
+	}
+	if d.AIO == "io_uring" && runtime.GOOS != "linux" {
+		return fmt.Errorf("%s: aio=io_uring은 Linux 호스트에서만 사용할 수 있습니다.", name)
+	}
+	if d.DetectZeroes == "unmap" && !d.Discard {
+		return fmt.Errorf("%s: detect-zeroes=unmap은 discard=unmap과 함께 사용해야 합니다.", name)
+	}
+	if d.Encrypted && d.Type != "QCOW2" {
+		return fmt.Errorf("%s: 암호화는 QCOW2 디스크에서만 사용할 수 있습니다.", name)
+	}
+	if err := validateThrottle(d.Throttle); err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	return nil
+}
+
+// validateThrottle은 QEMU가 거부하는 I/O 제한 조합을 미리 확인합니다.
+func validateThrottle(throttle map[string]string) error {
+	values := make(map[string]int64)
+	for _, key := range throttleLimitKeys {
+		text := throttle[key]
+		if text == "" {
+			continue
+		}
+		value, err := strconv.ParseInt(text, 10, 64)
+		if err != nil || value < 0 {
+			return fmt.Errorf("%s 값이 올바르지 않습니다: %s", key, text)
+		}
+		if value > 0 {
+			values[key] = value
+		}
+	}
+	for _, kind := range []string{"iops", "bps"} {
+		// 전체 한도와 읽기/쓰기 한도는 함께 쓸 수 없음
+		if values[kind+"-total"] > 0 && (values[kind+"-read"] > 0 || values[kind+"-write"] > 0) {
+			return fmt.Errorf("%s-total과 %s-read/%s-write는 함께 설정할 수 없습니다.", kind, kind, kind)
+		}
+		for _, op := range []string{"total", "read", "write"} {
+			key := kind + "-" + op
+			burst := values[key+"-max"]
+			if burst == 0 {
+				continue
+			}
+			if values[key] == 0 {
+				return fmt.Errorf("%s-max는 %s와 함께 설정해야 합니다.", key, key)
+			}
+			if burst < values[key] {
+				return fmt.Errorf("%s-max는 %s보다 작을 수 없습니다.", key, key)
+			}
+		}
+	}
+	return nil
+}
+
+// validateDisks는 디스크와 CD/DVD 드라이브를 확인하고 컨트롤러 포트 수를 넘지 않는지 검사합니다.
+func validateDisks(disks []diskConfig, cdroms []cdromConfig, machine string) error {
+	count := make(map[string]int)
+	for _, d := range disks {
+		if err := validateDiskConfig(d, machine); err != nil {
+			return err
+		}
+		count[d.bus(machine)]++
+	}
+	for _, c := range cdroms {
+		bus := c.bus(machine)
+		supported := false
+		for _, b := range machineDiskBuses(machine) {
+			if b == bus {
+				supported = true
+				break
+			}
+		}
+		if !supported {
+			return fmt.Errorf("CD/DVD: %s 머신에서는 %s 버스를 사용할 수 없습니다.", machine, bus)
+		}
+		count[bus]++
+	}
+	// q35의 IDE 장치는 내장 AHCI 컨트롤러의 포트(ide.0~ide.5)를 AHCI와 함께 씀 (storageArgs 참고)
+	if machine == "q35" {
+		if count["ide"]+count["ahci"] > 6 {
+			return errors.New("q35 머신의 IDE/AHCI 장치는 CD/DVD를 포함해 최대 6개까지 연결할 수 있습니다.")
+		}
+		return nil
+	}
+	if count["ide"] > 4 {
+		return errors.New("IDE 장치는 최대 4개까지 연결할 수 있습니다.")
+	}
+	if count["ahci"] > 6 {
+		return errors.New("AHCI 장치는 최대 6개까지 연결할 수 있습니다.")
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiskConfigsRoundTrip(t *testing.T) {
+	disks := []diskConfig{
+		{Type: "QCOW2", Path: `E:\QEMU\disk.qcow2`, Capacity: "10240"},
+		{Type: "RAW", Path: `D:\vm data\raw.img`, Capacity: "2048", Bus: "virtio-scsi", Cache: "none", AIO: "native",
+			Discard: true, DetectZeroes: "unmap"},
+		{Type: "QCOW2", Path: `C:\vm\secret.qcow2`, Bus: "nvme", Encrypted: true,
+			Throttle: map[string]string{"iops-total": "1000", "iops-total-max": "2000", "bps-write": "10485760"}},
+		{Type: "VHD", Path: "/srv/vm/disk.vhd", Capacity: "512"},
+	}
+	text := formatDiskConfigs(disks)
+	if got := parseDiskConfigs(text); !reflect.DeepEqual(got, disks) {
+		t.Errorf("parseDiskConfigs(%q) =\n%+v\nwant\n%+v", text, got, disks)
+	}
+	if again := formatDiskConfigs(parseDiskConfigs(text)); again != text {
+		t.Errorf("다시 저장하면 %q, want %q", again, text)
+	}
+}
+
+func TestParseDiskConfigs(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []diskConfig
+	}{
+		{"", nil},
+		// 옵션이 없던 이전 형식
+		{`QCOW2:E:\QEMU\disk.qcow2:10240`, []diskConfig{{Type: "QCOW2", Path: `E:\QEMU\disk.qcow2`, Capacity: "10240"}}},
+		{`RAW:E:\a.img:;QCOW2:E:\b.qcow2:100|bus=ide`, []diskConfig{
+			{Type: "RAW", Path: `E:\a.img`},
+			{Type: "QCOW2", Path: `E:\b.qcow2`, Capacity: "100", Bus: "ide"},
+		}},
+		// 경로가 없는 항목은 건너뜀, 모르는 옵션은 무시
+		{`QCOW2;QCOW2::10;RAW:E:\c.img:1|discard=ignore,foo=bar,iops-read=50`, []diskConfig{
+			{Type: "RAW", Path: `E:\c.img`, Capacity: "1", Throttle: map[string]string{"iops-read": "50"}},
+		}},
+	}
+	for _, tt := range tests {
+		if got := parseDiskConfigs(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseDiskConfigs(%q) =\n%+v\nwant\n%+v", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// qemu-img check를 지원하는 디스크 종류 (RAW, VHD는 검사 기능이 없음)
+func diskCheckSupported(diskType string) bool {
+	return diskType == "QCOW2" || diskType == "VMDK"
+}
+
+// 검사 결과 요약 문자열
+func diskCheckSummary(result *diskCheckResult) string {
+	if result.CheckErrors == 0 && result.Leaks == 0 && result.Corruptions == 0 {
+		text := "이상 없음"
+		if result.LeaksFixed > 0 || result.CorruptionsFixed > 0 {
+			text += fmt.Sprintf(" (복구됨: 누수 %d, 손상 %d)", result.LeaksFixed, result.CorruptionsFixed)
+		}
+		return text
+	}
+	return fmt.Sprintf("누수 클러스터 %d, 손상 클러스터 %d, 검사 오류 %d", result.Leaks, result.Corruptions, result.CheckErrors)
+}
+
+// backupDiskPath는 아직 없는 백업 파일 경로를 고릅니다. "<경로>.bak"이 이미 있으면
+// 이전 백업을 덮어쓰지 않도록 시각을 붙입니다 ("<경로>.20240102-150405.bak", 겹치면 "-2" 등).
+func backupDiskPath(path string, now time.Time) string {
+	backupPath := path + ".bak"
+	stamp := now.Format("20060102-150405")
+	for n := 1; ; n++ {
+		if _, err := os.Lstat(backupPath); err != nil {
+			return backupPath
+		}
+		backupPath = path + "." + stamp + ".bak"
+		if n > 1 {
+			backupPath = fmt.Sprintf("%s.%s-%d.bak", path, stamp, n)
+		}
+	}
+}
+
+// backupDiskFile은 디스크 파일을 backupPath로 복사하며 진행률(0~1)을 report로 알립니다.
+// backupPath가 이미 있으면 덮어쓰지 않고 실패합니다.
+func backupDiskFile(ctx context.Context, path, backupPath string, report func(float64)) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	var total, copied int64
+	if info, err := src.Stat(); err == nil {
+		total = info.Size()
+	}
+	dst, err := os.OpenFile(backupPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, &progressReader{ctx: ctx, r: src, onRead: func(n int) {
+		copied += int64(n)
+		if total > 0 {
+			report(float64(copied) / float64(total))
+		}
+	}}); err != nil {
+		dst.Close()
+		os.Remove(backupPath)
+		return err
+	}
+	return dst.Close()
+}
+
+// checkDisk는 디스크 하나를 검사합니다. 암호화 디스크는 암호를 secret 객체로 넘겨 엽니다.
+func checkDisk(d diskConfig, passphrases map[string]string, repair string) (*diskCheckResult, error) {
+	opts, image, cleanup, err := diskImageArgs(d, passphrases)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	return checkDiskImage(opts, image, repair)
+}
+
+// autoCheckDisks는 가상머신이 비정상 종료된 뒤 검사를 지원하는 디스크를 작업 패널에서 검사하고,
+// 누수나 손상이 있으면 검사 창을 열지 묻습니다. passphrases는 실행할 때 입력받은 암호입니다.
+func autoCheckDisks(config VMConfig, passphrases map[string]string, parent fyne.Window, jobs *jobPanel) {
+	var problems []string
+	jobs.start(config.Name+" 디스크 자동 검사 중", func(ctx context.Context, report func(float64)) error {
+		disks := parseDiskConfigs(config.Disk)
+		for i, d := range disks {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if !diskCheckSupported(d.Type) {
+				continue
+			}
+			result, err := checkDisk(d, passphrases, "")
+			if err != nil {
+				problems = append(problems, filepath.Base(d.Path)+": 검사 실패: "+err.Error())
+			} else if result.Leaks > 0 || result.Corruptions > 0 || result.CheckErrors > 0 {
+				problems = append(problems, filepath.Base(d.Path)+": "+diskCheckSummary(result))
+			}
+			report(float64(i+1) / float64(len(disks)))
+		}
+		return nil
+	}, func(err error) {
+		if err != nil || len(problems) == 0 {
+			return
+		}
+		msg := config.Name + " 가상머신이 비정상 종료된 뒤 디스크에서 문제가 발견되었습니다.\n" +
+			strings.Join(problems, "\n") + "\n디스크 검사 창을 여시겠습니까?"
+		dialog.ShowConfirm("디스크 자동 검사", msg, func(ok bool) {
+			if ok {
+				ShowDiskCheckWindow(config, jobs)
+			}
+		}, parent)
+	})
+}
+
+// ShowDiskCheckWindow는 VM의 모든 디스크를 검사하고 복구 버튼을 제공합니다.
+// 검사와 복구는 VM이 꺼져 있을 때만 해야 하며, 작업 패널에서 진행됩니다.
+func ShowDiskCheckWindow(config VMConfig, jobs *jobPanel) {
+	a := fyne.CurrentApp()
+	win := a.NewWindow(config.Name + " 디스크 검사")
+	win.Resize(fyne.NewSize(550, 300))
+
+	rows := container.NewVBox()
+	addRows := func(passphrases map[string]string) {
+		for _, d := range parseDiskConfigs(config.Disk) {
+			d := d
+			nameLabel := widget.NewLabel(filepath.Base(d.Path) + " (" + d.Type + ")")
+			statusLabel := widget.NewLabel("")
+			statusLabel.Wrapping = fyne.TextWrapWord
+
+			if !diskCheckSupported(d.Type) {
+				statusLabel.SetText("이 디스크 종류는 검사를 지원하지 않습니다.")
+				rows.Add(container.NewVBox(nameLabel, statusLabel, widget.NewSeparator()))
+				continue
+			}
+
+			checkBtn := widget.NewButton("다시 검사", nil)
+			leaksBtn := widget.NewButton("누수 복구 (-r leaks)", nil)
+			allBtn := widget.NewButton("전체 복구 (-r all)", nil)
+			buttons := []*widget.Button{checkBtn, leaksBtn, allBtn}
+			setBusy := func(busy bool) {
+				for _, b := range buttons {
+					if busy {
+						b.Disable()
+					} else {
+						b.Enable()
+					}
+				}
+			}
+			runCheck := func(repair string) {
+				setBusy(true)
+				statusLabel.SetText("검사 중...")
+				var result *diskCheckResult
+				jobs.start(filepath.Base(d.Path)+" 검사 중", func(ctx context.Context, report func(float64)) error {
+					var err error
+					result, err = checkDisk(d, passphrases, repair)
+					return err
+				}, func(err error) {
+					checkBtn.Enable()
+					if err != nil {
+						statusLabel.SetText("검사 실패: " + err.Error())
+						return
+					}
+					statusLabel.SetText(diskCheckSummary(result))
+					if result.Leaks > 0 {
+						leaksBtn.Enable()
+					}
+					if result.Leaks > 0 || result.Corruptions > 0 {
+						allBtn.Enable()
+					}
+				})
+			}
+			// 복구 전에 백업 여부를 묻습니다. 백업도 작업 패널에서 복사합니다.
+			confirmRepair := func(repair string) {
+				backupPath := backupDiskPath(d.Path, time.Now())
+				dialog.ShowConfirm("디스크 복구", "복구하기 전에 디스크 파일을 백업하시겠습니까?\n("+backupPath+")", func(backup bool) {
+					if !backup {
+						runCheck(repair)
+						return
+					}
+					setBusy(true)
+					jobs.start(filepath.Base(d.Path)+" 백업 중", func(ctx context.Context, report func(float64)) error {
+						return backupDiskFile(ctx, d.Path, backupPath, report)
+					}, func(err error) {
+						if err != nil {
+							setBusy(false)
+							dialog.ShowError(fmt.Errorf("백업 실패: %v", err), win)
+							return
+						}
+						runCheck(repair)
+					})
+				}, win)
+			}
+			checkBtn.OnTapped = func() { runCheck("") }
+			leaksBtn.OnTapped = func() { confirmRepair("leaks") }
+			allBtn.OnTapped = func() { confirmRepair("all") }
+			runCheck("")
+			rows.Add(container.NewVBox(
+				nameLabel,
+				statusLabel,
+				container.NewHBox(checkBtn, leaksBtn, allBtn),
+				widget.NewSeparator(),
+			))
+		}
+		if len(rows.Objects) == 0 {
+			rows.Add(widget.NewLabel("검사할 디스크가 없습니다."))
+		}
+	}
+
+	closeBtn := widget.NewButton("닫기", func() {
+		win.Close()
+	})
+	win.SetContent(container.NewBorder(nil, container.NewHBox(closeBtn), nil, nil, container.NewVScroll(rows)))
+	win.CenterOnScreen()
+	win.Show()
+	if vmRunning(config.Name) {
+		rows.Add(widget.NewLabel(config.Name + " 가상머신이 실행 중입니다. 종료한 뒤 검사하십시오."))
+		return
+	}
+	// 자격 증명 관리자에 없는 디스크 암호는 검사 전에 입력받음
+	askMissingPassphrases(parseDiskConfigs(config.Disk), win, addRows)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBackupDiskPath(t *testing.T) {
+	now := time.Date(2024, 1, 2, 15, 4, 5, 0, time.Local)
+	tests := []struct {
+		existing []string // 이미 있는 백업 파일 (디스크 경로 뒤에 붙는 부분)
+		want     string
+	}{
+		{nil, ".bak"},
+		{[]string{".bak"}, ".20240102-150405.bak"},
+		{[]string{".bak", ".20240102-150405.bak"}, ".20240102-150405-2.bak"},
+		{[]string{".bak", ".20240102-150405.bak", ".20240102-150405-2.bak"}, ".20240102-150405-3.bak"},
+		// 다른 시각의 백업은 겹치지 않음
+		{[]string{".bak", ".20231231-000000.bak"}, ".20240102-150405.bak"},
+	}
+	for _, tt := range tests {
+		disk := filepath.Join(t.TempDir(), "os.qcow2")
+		for _, suffix := range tt.existing {
+			writeTestFile(t, disk+suffix, "old")
+		}
+		if got := backupDiskPath(disk, now); got != disk+tt.want {
+			t.Errorf("%v가 있을 때 backupDiskPath = %q, want %q", tt.existing, filepath.Base(got), filepath.Base(disk+tt.want))
+		}
+	}
+}
+
+func TestBackupDiskFile(t *testing.T) {
+	dir := t.TempDir()
+	disk := filepath.Join(dir, "os.qcow2")
+	writeTestFile(t, disk, "disk data")
+
+	var last float64
+	backup := filepath.Join(dir, "os.qcow2.bak")
+	if err := backupDiskFile(context.Background(), disk, backup, func(p float64) { last = p }); err != nil {
+		t.Fatal(err)
+	}
+	if readTestFile(t, backup) != "disk data" || last != 1 {
+		t.Errorf("백업 내용 %q, 진행률 %v", readTestFile(t, backup), last)
+	}
+
+	// 있는 백업은 덮어쓰지 않음
+	writeTestFile(t, disk, "repaired")
+	if err := backupDiskFile(context.Background(), disk, backup, func(float64) {}); err == nil {
+		t.Error("백업 파일이 이미 있는데 오류가 없습니다")
+	}
+	if readTestFile(t, backup) != "disk data" {
+		t.Error("이전 백업을 덮어썼습니다")
+	}
+
+	// 취소하면 만들던 백업을 지움
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	canceled := filepath.Join(dir, "canceled.bak")
+	if err := backupDiskFile(ctx, disk, canceled, func(float64) {}); err == nil {
+		t.Error("취소했는데 오류가 없습니다")
+	}
+	if _, err := os.Stat(canceled); !os.IsNotExist(err) {
+		t.Errorf("취소한 백업이 남아 있습니다: %v", err)
+	}
+}
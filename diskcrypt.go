@@ -0,0 +1,243 @@
+package main
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unsafe"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"golang.org/x/sys/windows"
+)
+
+// Windows 자격 증명 관리자 (CREDENTIALW)
+type credential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        windows.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+const (
+	credTypeGeneric         = 1
+	credPersistLocalMachine = 2
+)
+
+var (
+	advapi32       = windows.NewLazySystemDLL("advapi32.dll")
+	procCredWriteW = advapi32.NewProc("CredWriteW")
+	procCredReadW  = advapi32.NewProc("CredReadW")
+	procCredDelete = advapi32.NewProc("CredDeleteW")
+	procCredFree   = advapi32.NewProc("CredFree")
+)
+
+// 디스크별 자격 증명 이름 (경로는 대소문자 구분 없이 정규화)
+func diskCredentialTarget(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return "goqemu:disk:" + strings.ToLower(filepath.Clean(path))
+}
+
+// storeDiskPassphrase는 디스크 암호를 자격 증명 관리자에 저장합니다.
+func storeDiskPassphrase(path, passphrase string) error {
+	target, err := windows.UTF16PtrFromString(diskCredentialTarget(path))
+	if err != nil {
+		return err
+	}
+	blob := []byte(passphrase)
+	cred := credential{
+		Type:               credTypeGeneric,
+		TargetName:         target,
+		CredentialBlobSize: uint32(len(blob)),
+		Persist:            credPersistLocalMachine,
+	}
+	if len(blob) > 0 {
+		cred.CredentialBlob = &blob[0]
+	}
+	ret, _, err := procCredWriteW.Call(uintptr(unsafe.Pointer(&cred)), 0)
+	if ret == 0 {
+		return err
+	}
+	return nil
+}
+
+// loadDiskPassphrase는 저장된 디스크 암호를 읽습니다. 없으면 ok가 false입니다.
+func loadDiskPassphrase(path string) (passphrase string, ok bool) {
+	target, err := windows.UTF16PtrFromString(diskCredentialTarget(path))
+	if err != nil {
+		return "", false
+	}
+	var cred *credential
+	ret, _, _ := procCredReadW.Call(uintptr(unsafe.Pointer(target)), credTypeGeneric, 0, uintptr(unsafe.Pointer(&cred)))
+	if ret == 0 {
+		return "", false
+	}
+	defer procCredFree.Call(uintptr(unsafe.Pointer(cred)))
+	blob := unsafe.Slice(cred.CredentialBlob, cred.CredentialBlobSize)
+	return string(blob), true
+}
+
+func deleteDiskPassphrase(path string) {
+	target, err := windows.UTF16PtrFromString(diskCredentialTarget(path))
+	if err != nil {
+		return
+	}
+	procCredDelete.Call(uintptr(unsafe.Pointer(target)), credTypeGeneric, 0)
+}
+
+// QEMU secret 객체가 읽을 암호 파일 경로 (실행 직전에 만들고 QEMU가 읽으면 지움)
+func diskSecretPath(path string) string {
+	sum := sha256.Sum256([]byte(diskCredentialTarget(path)))
+	return filepath.Join(os.TempDir(), fmt.Sprintf("goqemu-%x.secret", sum[:8]))
+}
+
+// removeStaleDiskSecrets는 앱이 비정상 종료되어 임시 폴더에 남은 암호 파일을 지웁니다.
+// 앱을 시작할 때 호출하며, 이때는 암호 파일을 읽을 QEMU나 qemu-img가 없습니다.
+func removeStaleDiskSecrets() {
+	paths, _ := filepath.Glob(filepath.Join(os.TempDir(), "goqemu-*.secret"))
+	for _, path := range paths {
+		os.Remove(path)
+	}
+}
+
+func writeSecretFile(secretPath, passphrase string) error {
+	return os.WriteFile(secretPath, []byte(passphrase), 0600)
+}
+
+// writeDiskSecrets는 VM 실행 전에 암호화 디스크의 암호 파일을 만듭니다.
+// 자격 증명 관리자에 없는 암호는 passphrases에서 찾습니다(실행 시 입력받은 암호).
+// 반환된 cleanup은 QEMU가 암호를 읽은 뒤 호출해 파일을 지웁니다 (startVM 참고).
+func writeDiskSecrets(config VMConfig, passphrases map[string]string) (cleanup func(), err error) {
+	var written []string
+	cleanup = func() {
+		for _, path := range written {
+			os.Remove(path)
+		}
+	}
+	for _, d := range parseDiskConfigs(config.Disk) {
+		if !d.Encrypted {
+			continue
+		}
+		passphrase, ok := diskPassphrase(d.Path, passphrases)
+		if !ok {
+			cleanup()
+			return nil, fmt.Errorf("%s 디스크의 암호가 없습니다.", filepath.Base(d.Path))
+		}
+		secretPath := diskSecretPath(d.Path)
+		if err := writeSecretFile(secretPath, passphrase); err != nil {
+			cleanup()
+			return nil, err
+		}
+		written = append(written, secretPath)
+	}
+	return cleanup, nil
+}
+
+// diskPassphrase는 자격 증명 관리자에서, 없으면 passphrases(입력받은 암호)에서 디스크 암호를 찾습니다.
+func diskPassphrase(path string, passphrases map[string]string) (string, bool) {
+	if passphrase, ok := loadDiskPassphrase(path); ok {
+		return passphrase, true
+	}
+	passphrase, ok := passphrases[path]
+	return passphrase, ok
+}
+
+// diskImageArgs는 qemu-img로 디스크를 열 때의 옵션과 이미지 인자를 만듭니다.
+// 암호화 디스크는 암호를 임시 파일의 secret 객체(sec0)로 넘기고 --image-opts로 엽니다.
+// 반환된 cleanup은 qemu-img가 끝난 뒤 호출합니다.
+func diskImageArgs(d diskConfig, passphrases map[string]string) (opts []string, image string, cleanup func(), err error) {
+	format := qemuImgFormat(d.Type)
+	if !d.Encrypted {
+		return []string{"-f", format}, d.Path, func() {}, nil
+	}
+	passphrase, ok := diskPassphrase(d.Path, passphrases)
+	if !ok {
+		return nil, "", nil, fmt.Errorf("%s 디스크의 암호가 없습니다.", filepath.Base(d.Path))
+	}
+	f, err := os.CreateTemp("", "goqemu-*.secret")
+	if err != nil {
+		return nil, "", nil, err
+	}
+	secretPath := f.Name()
+	f.Close()
+	if err := writeSecretFile(secretPath, passphrase); err != nil {
+		os.Remove(secretPath)
+		return nil, "", nil, err
+	}
+	opts = []string{"--object", "secret,id=sec0,format=raw,file=" + escapeOptionValue(secretPath), "--image-opts"}
+	image = "driver=" + format + ",file.driver=file,file.filename=" + escapeOptionValue(d.Path) + ",encrypt.key-secret=sec0"
+	return opts, image, func() { os.Remove(secretPath) }, nil
+}
+
+// askMissingPassphrases는 자격 증명 관리자에 암호가 없는 암호화 디스크의 암호를 차례로 입력받아
+// 디스크 경로 → 암호로 onDone에 넘깁니다. 취소하면 onDone을 호출하지 않습니다.
+func askMissingPassphrases(disks []diskConfig, win fyne.Window, onDone func(passphrases map[string]string)) {
+	var missing []string
+	for _, d := range disks {
+		if _, ok := loadDiskPassphrase(d.Path); d.Encrypted && !ok {
+			missing = append(missing, d.Path)
+		}
+	}
+	passphrases := make(map[string]string)
+	var ask func(i int)
+	ask = func(i int) {
+		if i == len(missing) {
+			onDone(passphrases)
+			return
+		}
+		passEntry := widget.NewPasswordEntry()
+		dialog.ShowForm(filepath.Base(missing[i])+" 암호", "확인", "취소",
+			[]*widget.FormItem{widget.NewFormItem("암호", passEntry)},
+			func(ok bool) {
+				if !ok {
+					return
+				}
+				passphrases[missing[i]] = passEntry.Text
+				ask(i + 1)
+			}, win)
+	}
+	ask(0)
+}
+
+// askDiskPassphrase는 새 암호화 디스크의 암호를 두 번 입력받습니다.
+// store가 true이면 자격 증명 관리자에 보관하고, 아니면 실행할 때마다 입력받습니다.
+func askDiskPassphrase(path string, win fyne.Window, onDone func(passphrase string, store bool)) {
+	passEntry := widget.NewPasswordEntry()
+	confirmEntry := widget.NewPasswordEntry()
+	storeCheck := widget.NewCheck("Windows 자격 증명 관리자에 저장", nil)
+	storeCheck.SetChecked(true)
+	dialog.ShowForm(filepath.Base(path)+" 암호 설정", "확인", "취소",
+		[]*widget.FormItem{
+			widget.NewFormItem("암호", passEntry),
+			widget.NewFormItem("암호 확인", confirmEntry),
+			widget.NewFormItem("", storeCheck),
+		},
+		func(ok bool) {
+			if !ok {
+				return
+			}
+			if passEntry.Text == "" {
+				dialog.ShowError(errors.New("암호를 입력하십시오."), win)
+				return
+			}
+			if passEntry.Text != confirmEntry.Text {
+				dialog.ShowError(errors.New("암호가 일치하지 않습니다."), win)
+				return
+			}
+			onDone(passEntry.Text, storeCheck.Checked)
+		}, win)
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// 테스트의 디스크 경로는 임시 폴더에 있어 자격 증명 관리자에 암호가 없으므로 passphrases로 넘깁니다.
+
+func TestDiskImageArgs(t *testing.T) {
+	dir := t.TempDir()
+	plain := filepath.Join(dir, "plain.vmdk")
+	secret := filepath.Join(dir, "se,cret.qcow2")
+	passphrases := map[string]string{secret: "암호 1"}
+
+	tests := []struct {
+		d         diskConfig
+		wantOpts  []string // 암호화 디스크는 secret 파일 경로 앞부분까지
+		wantImage string
+		wantErr   bool
+	}{
+		{diskConfig{Type: "VMDK", Path: plain}, []string{"-f", "vmdk"}, plain, false},
+		{diskConfig{Type: "QCOW2", Path: secret, Encrypted: true},
+			[]string{"--object", "secret,id=sec0,format=raw,file=", "--image-opts"},
+			"driver=qcow2,file.driver=file,file.filename=" + strings.ReplaceAll(secret, ",", ",,") + ",encrypt.key-secret=sec0", false},
+		{diskConfig{Type: "QCOW2", Path: filepath.Join(dir, "other.qcow2"), Encrypted: true}, nil, "", true},
+	}
+	for _, tt := range tests {
+		opts, image, cleanup, err := diskImageArgs(tt.d, passphrases)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: 암호가 없는데 오류가 없습니다", tt.d.Path)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if image != tt.wantImage || len(opts) != len(tt.wantOpts) {
+			t.Errorf("%s: opts = %q, image = %q", tt.d.Path, opts, image)
+			cleanup()
+			continue
+		}
+		for i, want := range tt.wantOpts {
+			if !strings.HasPrefix(opts[i], want) {
+				t.Errorf("%s: opts[%d] = %q, want %q…", tt.d.Path, i, opts[i], want)
+			}
+		}
+		if tt.d.Encrypted {
+			secretPath := strings.TrimPrefix(opts[1], tt.wantOpts[1])
+			if got := readTestFile(t, secretPath); got != passphrases[tt.d.Path] {
+				t.Errorf("암호 파일 내용 %q", got)
+			}
+			cleanup()
+			if _, err := os.Stat(secretPath); !os.IsNotExist(err) {
+				t.Errorf("cleanup 뒤에 암호 파일이 남아 있습니다: %v", err)
+			}
+		}
+	}
+}
+
+func TestWriteDiskSecrets(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.qcow2"), filepath.Join(dir, "b.qcow2")
+	config := VMConfig{Name: "secret", Disk: formatDiskConfigs([]diskConfig{
+		{Type: "QCOW2", Path: a, Encrypted: true},
+		{Type: "RAW", Path: filepath.Join(dir, "plain.img")},
+		{Type: "QCOW2", Path: b, Encrypted: true},
+	})}
+
+	// 암호가 하나라도 없으면 만든 파일을 지우고 실패
+	if _, err := writeDiskSecrets(config, map[string]string{a: "first"}); err == nil {
+		t.Error("b의 암호가 없는데 오류가 없습니다")
+	}
+	if _, err := os.Stat(diskSecretPath(a)); !os.IsNotExist(err) {
+		t.Errorf("실패한 뒤 a의 암호 파일이 남아 있습니다: %v", err)
+	}
+
+	cleanup, err := writeDiskSecrets(config, map[string]string{a: "first", b: "second"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if readTestFile(t, diskSecretPath(a)) != "first" || readTestFile(t, diskSecretPath(b)) != "second" {
+		t.Error("암호 파일 내용이 다릅니다")
+	}
+	// QEMU는 같은 경로의 secret 객체로 암호를 읽음
+	args, err := storageArgs(parseDiskConfigs(config.Disk), nil, "q35", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	joined := strings.Join(args, "\n")
+	for node, path := range map[string]string{"disk0": a, "disk2": b} {
+		want := "secret,id=" + node + "-secret,format=raw,file=" + escapeOptionValue(diskSecretPath(path))
+		if !strings.Contains(joined, want) || !strings.Contains(joined, ",node-name="+node+",file="+node+"-file") {
+			t.Errorf("%s의 secret 객체가 없습니다:\n%s", node, joined)
+		}
+		if !strings.Contains(joined, "encrypt.key-secret="+node+"-secret") {
+			t.Errorf("%s 형식 노드에 encrypt.key-secret이 없습니다:\n%s", node, joined)
+		}
+	}
+	if strings.Contains(joined, "disk1-secret") {
+		t.Errorf("암호화하지 않은 disk1에 secret 객체가 있습니다:\n%s", joined)
+	}
+
+	cleanup()
+	for _, path := range []string{a, b} {
+		if _, err := os.Stat(diskSecretPath(path)); !os.IsNotExist(err) {
+			t.Errorf("cleanup 뒤에 %s의 암호 파일이 남아 있습니다: %v", filepath.Base(path), err)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/windows"
+)
+
+// 디스크 사용량 (MB 단위)
+type diskUsage struct {
+	VirtualMB int64 // 게스트가 보는 용량
+	ActualMB  int64 // 호스트에 실제 할당된 용량
+	ChainMB   int64 // 기반 이미지를 포함한 실제 할당량 합계
+}
+
+func (u *diskUsage) add(other diskUsage) {
+	u.VirtualMB += other.VirtualMB
+	u.ActualMB += other.ActualMB
+	u.ChainMB += other.ChainMB
+}
+
+// qemu-img info 결과 캐시 (경로 → 최상위 이미지의 수정 시각, 크기와 함께 저장)
+var backingChainCache = struct {
+	sync.Mutex
+	entries map[string]backingChainEntry
+}{entries: make(map[string]backingChainEntry)}
+
+type backingChainEntry struct {
+	modTime time.Time
+	size    int64
+	chain   []diskImageInfo
+}
+
+// cachedBackingChain은 이미지 파일이 바뀌지 않았으면 이전 getBackingChain 결과를 돌려줍니다.
+// 목록을 새로 고칠 때마다 모든 디스크에 qemu-img를 다시 실행하지 않기 위한 것입니다.
+func cachedBackingChain(path string) ([]diskImageInfo, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	key := strings.ToLower(filepath.Clean(path))
+	backingChainCache.Lock()
+	entry, ok := backingChainCache.entries[key]
+	backingChainCache.Unlock()
+	if ok && entry.modTime.Equal(stat.ModTime()) && entry.size == stat.Size() {
+		return entry.chain, nil
+	}
+	chain, err := getBackingChain(path)
+	if err != nil {
+		return nil, err
+	}
+	backingChainCache.Lock()
+	backingChainCache.entries[key] = backingChainEntry{modTime: stat.ModTime(), size: stat.Size(), chain: chain}
+	backingChainCache.Unlock()
+	return chain, nil
+}
+
+// backingImageUsers는 다른 가상머신(exclude 제외)이 기반 이미지로 쓰는 파일의 목록입니다.
+// 키는 소문자로 바꾼 절대 경로, 값은 그 파일을 쓰는 가상머신 이름입니다.
+func backingImageUsers(configDir, exclude string) map[string]string {
+	users := make(map[string]string)
+	for _, config := range loadVMConfigs(configDir) {
+		if config.Name == exclude {
+			continue
+		}
+		for _, d := range parseDiskConfigs(config.Disk) {
+			chain, err := cachedBackingChain(d.Path)
+			if err != nil || len(chain) < 2 {
+				continue
+			}
+			for _, info := range chain[1:] {
+				absPath, err := filepath.Abs(info.Filename)
+				if err != nil {
+					absPath = info.Filename
+				}
+				users[strings.ToLower(filepath.Clean(absPath))] = config.Name
+			}
+		}
+	}
+	return users
+}
+
+// getDiskUsage는 qemu-img info --backing-chain으로 디스크 사용량을 조회합니다.
+// 희소(sparse) RAW 파일도 actual-size로 실제 할당량을 구합니다.
+func getDiskUsage(path string) (diskUsage, error) {
+	chain, err := cachedBackingChain(path)
+	if err != nil {
+		return diskUsage{}, err
+	}
+	if len(chain) == 0 {
+		return diskUsage{}, fmt.Errorf("%s: 이미지 정보가 없습니다.", path)
+	}
+	usage := diskUsage{
+		VirtualMB: chain[0].VirtualSize / (1024 * 1024),
+		ActualMB:  chain[0].ActualSize / (1024 * 1024),
+	}
+	for _, info := range chain {
+		usage.ChainMB += info.ActualSize / (1024 * 1024)
+	}
+	return usage, nil
+}
+
+// vmDiskUsage는 VM의 모든 디스크 사용량을 합산합니다.
+func vmDiskUsage(config VMConfig) diskUsage {
+	var total diskUsage
+	for _, diskInfo := range strings.Split(config.Disk, ";") {
+		_, dPath, _ := parseDiskInfo(diskInfo)
+		if dPath == "" {
+			continue
+		}
+		if usage, err := getDiskUsage(dPath); err == nil {
+			total.add(usage)
+		}
+	}
+	return total
+}
+
+func vmDiskUsages(configs []VMConfig) []diskUsage {
+	usages := make([]diskUsage, len(configs))
+	for i, config := range configs {
+		usages[i] = vmDiskUsage(config)
+	}
+	return usages
+}
+
+// "10240MB" 대신 읽기 쉬운 단위로 표시
+func formatSizeMB(mb int64) string {
+	if mb >= 1024 {
+		return fmt.Sprintf("%.1fGB", float64(mb)/1024)
+	}
+	return fmt.Sprintf("%dMB", mb)
+}
+
+// "가상 40.0GB / 실제 3.2GB (체인 합계 12.5GB)"
+func diskUsageText(usage diskUsage) string {
+	text := "가상 " + formatSizeMB(usage.VirtualMB) + " / 실제 " + formatSizeMB(usage.ActualMB)
+	if usage.ChainMB > usage.ActualMB {
+		text += " (체인 합계 " + formatSizeMB(usage.ChainMB) + ")"
+	}
+	return text
+}
+
+// 호스트 볼륨별 저장소 현황
+type volumeUsage struct {
+	Volume   string
+	ActualMB int64 // 이 볼륨에 있는 VM 디스크의 실제 할당량
+	GrowthMB int64 // 씬 프로비저닝 디스크가 가상 용량까지 커질 때 추가로 필요한 용량
+	FreeMB   int64
+	TotalMB  int64
+}
+
+// 볼륨 여유 공간이 부족한지 (10% 미만이거나 디스크가 모두 커지면 넘치는 경우)
+func (v volumeUsage) nearlyFull() bool {
+	if v.TotalMB == 0 {
+		return false
+	}
+	return v.FreeMB*10 < v.TotalMB || v.GrowthMB > v.FreeMB
+}
+
+func getVolumeSpaceMB(volume string) (free, total int64, err error) {
+	root, err := windows.UTF16PtrFromString(volume + `\`)
+	if err != nil {
+		return 0, 0, err
+	}
+	var freeAvail, totalBytes, totalFree uint64
+	if err := windows.GetDiskFreeSpaceEx(root, &freeAvail, &totalBytes, &totalFree); err != nil {
+		return 0, 0, err
+	}
+	return int64(freeAvail / (1024 * 1024)), int64(totalBytes / (1024 * 1024)), nil
+}
+
+// storageSummary는 모든 VM의 디스크를 호스트 볼륨별로 집계합니다.
+// 같은 기반 이미지를 공유하는 디스크가 있어도 파일마다 한 번만 셉니다.
+func storageSummary(configs []VMConfig) []volumeUsage {
+	volumes := make(map[string]*volumeUsage)
+	counted := make(map[string]bool)
+	for _, config := range configs {
+		for _, diskInfo := range strings.Split(config.Disk, ";") {
+			_, dPath, _ := parseDiskInfo(diskInfo)
+			if dPath == "" {
+				continue
+			}
+			chain, err := cachedBackingChain(dPath)
+			if err != nil {
+				continue
+			}
+			for i, info := range chain {
+				absPath, err := filepath.Abs(info.Filename)
+				if err != nil {
+					absPath = info.Filename
+				}
+				key := strings.ToLower(absPath)
+				if counted[key] {
+					continue
+				}
+				counted[key] = true
+
+				volume := filepath.VolumeName(absPath)
+				v, ok := volumes[volume]
+				if !ok {
+					v = &volumeUsage{Volume: volume}
+					v.FreeMB, v.TotalMB, _ = getVolumeSpaceMB(volume)
+					volumes[volume] = v
+				}
+				v.ActualMB += info.ActualSize / (1024 * 1024)
+				// 기반 이미지는 더 이상 커지지 않으므로 최상위 이미지만 증가량에 포함
+				if i == 0 && info.VirtualSize > info.ActualSize {
+					v.GrowthMB += (info.VirtualSize - info.ActualSize) / (1024 * 1024)
+				}
+			}
+		}
+	}
+
+	var result []volumeUsage
+	for _, v := range volumes {
+		result = append(result, *v)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Volume < result[j].Volume })
+	return result
+}
+
+// 메인 창 좌측 패널에 표시할 저장소 요약
+func storageSummaryText(configs []VMConfig) string {
+	var lines []string
+	for _, v := range storageSummary(configs) {
+		line := fmt.Sprintf("%s 디스크 %s, 여유 %s / %s", v.Volume, formatSizeMB(v.ActualMB), formatSizeMB(v.FreeMB), formatSizeMB(v.TotalMB))
+		if v.GrowthMB > 0 {
+			line += "\n  최대 증가 " + formatSizeMB(v.GrowthMB)
+		}
+		if v.nearlyFull() {
+			line += "\n  경고 : 여유 공간이 부족합니다!"
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return "저장소\n" + strings.Join(lines, "\n")
+}
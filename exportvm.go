@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	sqdialog "github.com/sqweek/dialog"
+)
+
+// 내보내기 결과 알림 (내보내지 못한 설정이 있으면 함께 표시)
+func showExportResult(path string, notes []string, parent fyne.Window) {
+	message := path + " 파일로 내보냈습니다."
+	if len(notes) > 0 {
+		message += "\n\n" + strings.Join(notes, "\n")
+	}
+	dialog.ShowInformation("내보내기", message, parent)
+}
+
+// ShowExportWindow는 내보낼 형식을 고르는 창을 띄웁니다.
+func ShowExportWindow(config VMConfig, parent fyne.Window, jobs *jobPanel) {
+	win := fyne.CurrentApp().NewWindow(config.Name + " 내보내기")
+	libvirtBtn := widget.NewButton("libvirt 도메인 XML", func() {
+		path, err := sqdialog.File().Title("libvirt XML 저장").Filter("libvirt 도메인 XML", "xml").SetStartFile(config.Name + ".xml").Save()
+		if err != nil {
+			return
+		}
+		if filepath.Ext(path) == "" {
+			path += ".xml"
+		}
+		notes, err := writeLibvirtFile(path, config)
+		if err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		win.Close()
+		showExportResult(path, notes, parent)
+	})
+	ovaBtn := widget.NewButton("OVA (OVF + VMDK)", func() {
+		path, err := sqdialog.File().Title("OVA 저장").Filter("OVA", "ova").SetStartFile(config.Name + ".ova").Save()
+		if err != nil {
+			return
+		}
+		if filepath.Ext(path) == "" {
+			path += ".ova"
+		}
+		win.Close()
+		var notes []string
+		jobs.start(config.Name+" → "+filepath.Base(path)+" 내보내는 중", func(ctx context.Context, report func(float64)) error {
+			var err error
+			notes, err = exportOVA(ctx, config, path, report)
+			return err
+		}, func(err error) {
+			if err != nil {
+				dialog.ShowError(err, parent)
+				return
+			}
+			showExportResult(path, notes, parent)
+		})
+	})
+	bundleBtn := widget.NewButton("가상머신 묶음 (.vmbundle)", func() {
+		path, err := sqdialog.File().Title("묶음 저장").Filter("가상머신 묶음", "vmbundle").SetStartFile(config.Name + ".vmbundle").Save()
+		if err != nil {
+			return
+		}
+		if filepath.Ext(path) == "" {
+			path += ".vmbundle"
+		}
+		win.Close()
+		var notes []string
+		jobs.start(config.Name+" → "+filepath.Base(path)+" 내보내는 중", func(ctx context.Context, report func(float64)) error {
+			var err error
+			notes, err = exportBundle(ctx, config, path, report)
+			return err
+		}, func(err error) {
+			if err != nil {
+				dialog.ShowError(err, parent)
+				return
+			}
+			showExportResult(path, notes, parent)
+		})
+	})
+	closeBtn := widget.NewButton("닫기", func() { win.Close() })
+	win.SetContent(container.NewVBox(
+		widget.NewLabel("내보낼 형식을 선택하세요."),
+		bundleBtn, libvirtBtn, ovaBtn, closeBtn,
+	))
+	win.Resize(fyne.NewSize(300, 180))
+	win.CenterOnScreen()
+	win.Show()
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// QEMU와 함께 설치되는 EDK2 UEFI 펌웨어 (코드, 변수 저장소 원본)
+var uefiFirmware = map[string]struct{ code, vars string }{
+	"qemu-system-x86_64":  {"edk2-x86_64-code.fd", "edk2-i386-vars.fd"},
+	"qemu-system-aarch64": {"edk2-aarch64-code.fd", "edk2-arm-vars.fd"},
+}
+
+// 머신별 TPM 장치 (swtpm 에뮬레이터에 연결)
+func tpmDeviceModel(binary string) string {
+	switch binary {
+	case "qemu-system-x86_64":
+		return "tpm-crb"
+	case "qemu-system-aarch64":
+		return "tpm-tis-device"
+	}
+	return ""
+}
+
+// qemuShareFile은 QEMU 설치 폴더의 share 폴더에서 파일을 찾습니다.
+func qemuShareFile(binary, name string) (string, error) {
+	exe, err := exec.LookPath(binary)
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Dir(exe)
+	for _, share := range []string{filepath.Join(dir, "share"), filepath.Join(dir, "..", "share", "qemu")} {
+		path := filepath.Join(share, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("QEMU 펌웨어 파일 %s를 찾을 수 없습니다.", name)
+}
+
+// validateFirmware는 UEFI, TPM 설정이 CPU와 추가 인자에 맞는지 확인합니다.
+func validateFirmware(config VMConfig) error {
+	binary, _ := qemuCPU(config.CPUModel)
+	if config.Firmware == "uefi" {
+		if _, ok := uefiFirmware[binary]; !ok {
+			return errors.New("이 CPU 모델에서는 UEFI 펌웨어를 사용할 수 없습니다.")
+		}
+		if strings.Contains(config.ExtraArgs, "if=pflash") {
+			return errors.New("UEFI를 켜면 추가 인자에 pflash 드라이브를 넣을 수 없습니다.")
+		}
+	}
+	if config.TPM != "" && tpmDeviceModel(binary) == "" {
+		return errors.New("이 CPU 모델에서는 TPM을 사용할 수 없습니다.")
+	}
+	return nil
+}
+
+// firmwareArgs는 UEFI pflash 드라이브와 swtpm TPM 인자를 만듭니다.
+// 펌웨어 코드를 찾지 못하면 파일 이름만 넘겨 QEMU가 오류를 알리게 합니다.
+func firmwareArgs(config VMConfig) []string {
+	binary, _ := qemuCPU(config.CPUModel)
+	var args []string
+	if fw, ok := uefiFirmware[binary]; ok && config.Firmware == "uefi" {
+		code, err := qemuShareFile(binary, fw.code)
+		if err != nil {
+			code = fw.code
+		}
+		args = append(args, "-drive", "if=pflash,format=raw,unit=0,readonly=on,file="+escapeOptionValue(code))
+		if config.NVRAM != "" {
+			args = append(args, "-drive", "if=pflash,format=raw,unit=1,file="+escapeOptionValue(config.NVRAM))
+		}
+	}
+	if device := tpmDeviceModel(binary); device != "" && config.TPM != "" {
+		args = append(args,
+			"-chardev", "socket,id=chrtpm,path="+escapeOptionValue(config.TPM),
+			"-tpmdev", "emulator,id=tpm0,chardev=chrtpm",
+			"-device", device+",tpmdev=tpm0")
+	}
+	return args
+}
+
+// defaultTPMSocket은 swtpm 제어 소켓의 기본 경로입니다 (관리형이면 가상머신 폴더의 tpm).
+func defaultTPMSocket(configDir string, config VMConfig) string {
+	if config.Managed {
+		return filepath.Join(vmStorageDir(configDir, config.Name), "tpm", "swtpm-sock")
+	}
+	return filepath.Join(configDir, "tpm", config.Name, "swtpm-sock")
+}
+
+// swtpmArgs는 소켓이 있는 폴더를 상태 폴더로 쓰는 swtpm 인자입니다.
+// --terminate로 QEMU의 연결이 끊기면 swtpm도 끝납니다.
+func swtpmArgs(socket string) []string {
+	return []string{"socket", "--tpm2",
+		"--tpmstate", "dir=" + filepath.Dir(socket),
+		"--ctrl", "type=unixio,path=" + socket,
+		"--terminate"}
+}
+
+// swtpm이 제어 소켓을 열 때까지 기다리는 시간
+const swtpmStartTimeout = 5 * time.Second
+
+// startSWTPM은 TPM을 쓰는 가상머신을 시작하기 전에 swtpm을 실행하고 제어 소켓이 열릴 때까지 기다립니다.
+// 이미 swtpm이 소켓을 열어 두었으면(직접 실행한 경우) 그대로 씁니다.
+// 반환된 stop은 QEMU가 끝난 뒤 호출하며, swtpm이 아직 남아 있으면 끝냅니다.
+func startSWTPM(socket string) (stop func(), err error) {
+	if conn, err := net.DialTimeout("unix", socket, time.Second); err == nil {
+		conn.Close()
+		return func() {}, nil
+	}
+	binary, err := exec.LookPath("swtpm")
+	if err != nil {
+		return nil, errors.New("TPM을 쓰려면 swtpm이 필요합니다. swtpm을 설치하고 PATH에 추가하십시오.")
+	}
+	if err := os.MkdirAll(filepath.Dir(socket), os.ModePerm); err != nil {
+		return nil, err
+	}
+	os.Remove(socket)
+	cmd := exec.Command(binary, swtpmArgs(socket)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	done := make(chan struct{})
+	var waitErr error
+	go func() {
+		waitErr = cmd.Wait()
+		close(done)
+	}()
+	stop = func() {
+		cmd.Process.Kill()
+		<-done
+	}
+
+	deadline := time.Now().Add(swtpmStartTimeout)
+	for {
+		if _, err := os.Lstat(socket); err == nil {
+			return stop, nil
+		}
+		select {
+		case <-done:
+			msg := strings.TrimSpace(stderr.String())
+			if msg == "" && waitErr != nil {
+				msg = waitErr.Error()
+			}
+			return nil, fmt.Errorf("swtpm을 시작하지 못했습니다: %s", msg)
+		case <-time.After(100 * time.Millisecond):
+		}
+		if time.Now().After(deadline) {
+			stop()
+			return nil, fmt.Errorf("swtpm 제어 소켓(%s)이 열리지 않았습니다.", socket)
+		}
+	}
+}
+
+// tpmStateFiles는 swtpm 상태 폴더(소켓이 있는 폴더)의 상태 파일 목록입니다.
+// tpm2-00.permall 등 "tpm"으로 시작하는 일반 파일만 고르며 소켓과 잠금 파일은 뺍니다.
+func tpmStateFiles(socket string) ([]string, error) {
+	dir := filepath.Dir(socket)
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, e := range entries {
+		path := filepath.Join(dir, e.Name())
+		if !e.Type().IsRegular() || !strings.HasPrefix(e.Name(), "tpm") || e.Name() == filepath.Base(socket) {
+			continue
+		}
+		files = append(files, path)
+	}
+	return files, nil
+}
+
+// defaultNVRAMPath는 UEFI 변수 저장소의 기본 경로입니다 (관리형이면 가상머신 폴더의 nvram).
+func defaultNVRAMPath(configDir string, config VMConfig) string {
+	if config.Managed {
+		return filepath.Join(vmStorageDir(configDir, config.Name), "nvram", "VARS.fd")
+	}
+	return filepath.Join(configDir, "nvram", config.Name+"_VARS.fd")
+}
+
+// ensureNVRAM은 UEFI 가상머신의 변수 저장소가 없으면 EDK2 원본을 복사해 만듭니다.
+func ensureNVRAM(configDir string, config *VMConfig) error {
+	if config.Firmware != "uefi" {
+		return nil
+	}
+	if config.NVRAM == "" {
+		config.NVRAM = defaultNVRAMPath(configDir, *config)
+	}
+	if _, err := os.Stat(config.NVRAM); err == nil {
+		return nil
+	}
+	binary, _ := qemuCPU(config.CPUModel)
+	fw, ok := uefiFirmware[binary]
+	if !ok {
+		return errors.New("이 CPU 모델에서는 UEFI 펌웨어를 사용할 수 없습니다.")
+	}
+	src, err := qemuShareFile(binary, fw.vars)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(config.NVRAM), os.ModePerm); err != nil {
+		return err
+	}
+	return copyFile(src, config.NVRAM)
+}
+
+// cloneFirmware는 복제본에 원본 UEFI 변수 저장소의 사본과 새 TPM 소켓 경로를 줍니다.
+// TPM 상태는 가상머신마다 달라야 하므로 복사하지 않습니다.
+func cloneFirmware(configDir string, clone *VMConfig) error {
+	if clone.TPM != "" {
+		clone.TPM = defaultTPMSocket(configDir, *clone)
+	}
+	if clone.Firmware != "uefi" {
+		return nil
+	}
+	src := clone.NVRAM
+	clone.NVRAM = defaultNVRAMPath(configDir, *clone)
+	if _, err := os.Stat(src); src == "" || err != nil {
+		return ensureNVRAM(configDir, clone)
+	}
+	if err := os.MkdirAll(filepath.Dir(clone.NVRAM), os.ModePerm); err != nil {
+		return err
+	}
+	return copyFile(src, clone.NVRAM)
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 다른 하이퍼바이저(VirtualBox, VMware)의 디스크 이미지 한 층
+type foreignLayer struct {
+	path   string
+	format string // qemu-img 포맷 이름 (vdi, vmdk, vpc ...)
+}
+
+// 스냅샷 체인은 기반 이미지부터 현재 상태 순서
+type foreignDisk struct {
+	chain []foreignLayer
+	bus   string
+}
+
+// .vbox, .vmx에서 읽은 가상머신 (config에는 디스크를 뺀 설정)
+type foreignVM struct {
+	config VMConfig
+	disks  []foreignDisk
+	notes  []string
+}
+
+// importForeignVM은 vm의 디스크를 destDir에 변환해 설정을 완성합니다.
+// 스냅샷 체인은 현재 상태를 diskType 이미지 하나로 병합하거나, keepChain이면 층마다
+// QCOW2 오버레이(convert -o backing_file)로 만들어 유지합니다.
+func importForeignVM(ctx context.Context, vm foreignVM, name, destDir, diskType string, keepChain bool, report func(float64)) (VMConfig, []string, error) {
+	config, notes := vm.config, vm.notes
+	config.Name = name
+	steps := 0
+	for _, d := range vm.disks {
+		for _, layer := range d.chain {
+			if _, err := os.Stat(layer.path); err != nil {
+				return VMConfig{}, nil, fmt.Errorf("디스크 이미지를 찾을 수 없습니다: %w", err)
+			}
+		}
+		if keepChain {
+			steps += len(d.chain)
+		} else {
+			steps++
+		}
+	}
+
+	_, statErr := os.Stat(destDir)
+	createdDir := os.IsNotExist(statErr)
+	if err := os.MkdirAll(destDir, os.ModePerm); err != nil {
+		return VMConfig{}, nil, err
+	}
+	var created []string
+	cleanup := func() {
+		for i := len(created) - 1; i >= 0; i-- {
+			os.Remove(created[i])
+		}
+		if createdDir {
+			os.Remove(destDir)
+		}
+	}
+
+	step := 0
+	convert := func(src foreignLayer, dst, dstType string, options ...string) error {
+		if _, err := os.Stat(dst); err == nil {
+			return fmt.Errorf("%s 파일이 이미 있습니다.", dst)
+		}
+		created = append(created, dst)
+		err := convertDiskImage(ctx, src.path, src.format, dst, qemuImgFormat(dstType), func(percent float64) {
+			report((float64(step) + percent/100) / float64(steps))
+		}, options...)
+		step++
+		return err
+	}
+
+	var disks []diskConfig
+	for i, d := range vm.disks {
+		base := filepath.Join(destDir, fmt.Sprintf("%s-disk%d", name, i+1))
+		newType, newPath := diskType, base+diskFileExt(diskType)
+		if keepChain && len(d.chain) > 1 {
+			// 기반 이미지는 -base, 중간 층은 -snapN, 현재 상태가 <name>-diskN.qcow2
+			newType = "QCOW2"
+			backing := ""
+			for j, layer := range d.chain {
+				var dst string
+				switch {
+				case j == len(d.chain)-1:
+					dst = base + ".qcow2"
+				case j == 0:
+					dst = base + "-base.qcow2"
+				default:
+					dst = fmt.Sprintf("%s-snap%d.qcow2", base, j)
+				}
+				var options []string
+				if backing != "" {
+					options = []string{"backing_file=" + escapeOptionValue(backing), "backing_fmt=qcow2"}
+				}
+				if err := convert(layer, dst, newType, options...); err != nil {
+					cleanup()
+					return VMConfig{}, nil, err
+				}
+				backing = dst
+			}
+			newPath = backing
+		} else if err := convert(d.chain[len(d.chain)-1], newPath, newType); err != nil {
+			cleanup()
+			return VMConfig{}, nil, err
+		}
+		disks = append(disks, diskConfig{Type: newType, Path: newPath, Bus: d.bus, Capacity: importDiskCapacity(newPath)})
+	}
+	config.Disk = formatDiskConfigs(disks)
+	if config.Machine == "" {
+		config.Machine = importedMachine(config)
+	}
+	if len(disks) == 0 && createdDir {
+		os.Remove(destDir)
+	}
+	return config, notes, nil
+}
+
+// 가져온 장치에 SATA가 있으면 AHCI가 내장된 q35, 아니면 pc
+func importedMachine(config VMConfig) string {
+	if strings.Contains(config.Disk, "bus=ahci") || strings.Contains(config.CDROM, "ahci:") {
+		return "q35"
+	}
+	return "pc"
+}
@@ -0,0 +1,434 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// QEMU 게스트 에이전트(qemu-ga)는 virtio-serial 포트 org.qemu.guest_agent.0으로 연결되고,
+// 호스트 쪽은 QEMU가 만드는 소켓(설정의 GuestAgent 경로)으로 QMP와 같은 JSON 명령을 주고받습니다.
+const guestAgentPortName = "org.qemu.guest_agent.0"
+
+// 응답이 없을 때 기다리는 기본 시간 (ctx에 기한이 없을 때)
+const guestAgentTimeout = 5 * time.Second
+
+// defaultGuestAgentSocket은 게스트 에이전트 소켓의 기본 경로입니다 (관리형이면 가상머신 폴더).
+func defaultGuestAgentSocket(configDir string, config VMConfig) string {
+	if config.Managed {
+		return filepath.Join(vmStorageDir(configDir, config.Name), "qga.sock")
+	}
+	return filepath.Join(configDir, "qga", config.Name+".sock")
+}
+
+// guestAgentArgs는 에이전트 채널(소켓 chardev와 virtio-serial 포트) 인자를 만듭니다.
+// QEMU가 소켓을 열고 기다리므로 게스트 에이전트가 없어도 가상머신은 그대로 시작됩니다.
+// PCI 버스가 없는 머신에는 virtio-serial을 붙일 수 없으므로 채널을 만들지 않습니다.
+func guestAgentArgs(config VMConfig) []string {
+	if config.GuestAgent == "" || !machineHasPCI(vmMachine(config)) {
+		return nil
+	}
+	return []string{
+		"-chardev", "socket,id=qga0,path=" + escapeOptionValue(config.GuestAgent) + ",server=on,wait=off",
+		"-device", "virtio-serial,id=qga-serial0",
+		"-device", "virtserialport,bus=qga-serial0.0,chardev=qga0,name=" + guestAgentPortName,
+	}
+}
+
+// guestAgentError는 에이전트가 돌려준 오류입니다.
+type guestAgentError struct {
+	Class string `json:"class"`
+	Desc  string `json:"desc"`
+}
+
+func (e *guestAgentError) Error() string {
+	return fmt.Sprintf("게스트 에이전트 오류 (%s): %s", e.Class, e.Desc)
+}
+
+// guestAgent는 게스트 에이전트 소켓에 연결된 클라이언트입니다. 명령은 한 번에 하나씩 보냅니다.
+type guestAgent struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// dialGuestAgent는 소켓에 연결하고 guest-sync-delimited로 이전 연결에서 남은 응답을 버립니다.
+func dialGuestAgent(ctx context.Context, socket string) (*guestAgent, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", socket)
+	if err != nil {
+		return nil, err
+	}
+	ga := &guestAgent{conn: conn, r: bufio.NewReader(conn)}
+	if err := ga.sync(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return ga, nil
+}
+
+func (ga *guestAgent) Close() error {
+	return ga.conn.Close()
+}
+
+func (ga *guestAgent) setDeadline(ctx context.Context) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(guestAgentTimeout)
+	}
+	ga.conn.SetDeadline(deadline)
+}
+
+// sync는 0xFF로 에이전트의 입력 파서를 초기화하고, 무작위 id를 보내 0xFF 뒤에 같은 id가 돌아올 때까지 읽습니다.
+func (ga *guestAgent) sync(ctx context.Context) error {
+	ga.setDeadline(ctx)
+	id := rand.Int63n(1 << 31)
+	req, _ := json.Marshal(map[string]any{"execute": "guest-sync-delimited", "arguments": map[string]any{"id": id}})
+	if _, err := ga.conn.Write(append([]byte{0xFF}, req...)); err != nil {
+		return err
+	}
+	for {
+		if _, err := ga.r.ReadBytes(0xFF); err != nil {
+			return err
+		}
+		line, err := ga.r.ReadBytes('\n')
+		if err != nil {
+			return err
+		}
+		var resp struct {
+			Return int64 `json:"return"`
+		}
+		if json.Unmarshal(line, &resp) == nil && resp.Return == id {
+			return nil
+		}
+	}
+}
+
+// call은 명령을 보내고 응답의 return 값을 result에 읽습니다. result가 nil이면 값을 버립니다.
+func (ga *guestAgent) call(ctx context.Context, command string, args any, result any) error {
+	ga.setDeadline(ctx)
+	req := map[string]any{"execute": command}
+	if args != nil {
+		req["arguments"] = args
+	}
+	data, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	if _, err := ga.conn.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	for {
+		line, err := ga.r.ReadBytes('\n')
+		if err != nil {
+			return err
+		}
+		line = bytes.TrimLeft(line, "\xff")
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var resp struct {
+			Return json.RawMessage  `json:"return"`
+			Error  *guestAgentError `json:"error"`
+		}
+		if err := json.Unmarshal(line, &resp); err != nil {
+			return fmt.Errorf("게스트 에이전트 응답을 읽을 수 없습니다: %v", err)
+		}
+		if resp.Error != nil {
+			return resp.Error
+		}
+		if result == nil || len(resp.Return) == 0 {
+			return nil
+		}
+		return json.Unmarshal(resp.Return, result)
+	}
+}
+
+// Ping은 에이전트가 응답하는지 확인합니다.
+func (ga *guestAgent) Ping(ctx context.Context) error {
+	return ga.call(ctx, "guest-ping", nil, nil)
+}
+
+type guestAgentInfo struct {
+	Version  string `json:"version"`
+	Commands []struct {
+		Name    string `json:"name"`
+		Enabled bool   `json:"enabled"`
+	} `json:"supported_commands"`
+}
+
+// Info는 에이전트 버전과 지원하는 명령 목록입니다.
+func (ga *guestAgent) Info(ctx context.Context) (guestAgentInfo, error) {
+	var info guestAgentInfo
+	err := ga.call(ctx, "guest-info", nil, &info)
+	return info, err
+}
+
+type guestOSInfo struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	PrettyName    string `json:"pretty-name"`
+	Version       string `json:"version"`
+	VersionID     string `json:"version-id"`
+	KernelRelease string `json:"kernel-release"`
+	KernelVersion string `json:"kernel-version"`
+	Machine       string `json:"machine"`
+}
+
+// OSInfo는 게스트 운영체제 정보입니다.
+func (ga *guestAgent) OSInfo(ctx context.Context) (guestOSInfo, error) {
+	var info guestOSInfo
+	err := ga.call(ctx, "guest-get-osinfo", nil, &info)
+	return info, err
+}
+
+type guestIPAddress struct {
+	Type    string `json:"ip-address-type"` // ipv4, ipv6
+	Address string `json:"ip-address"`
+	Prefix  int    `json:"prefix"`
+}
+
+type guestNetworkInterface struct {
+	Name        string           `json:"name"`
+	MAC         string           `json:"hardware-address"`
+	IPAddresses []guestIPAddress `json:"ip-addresses"`
+}
+
+// NetworkInterfaces는 게스트의 네트워크 인터페이스와 주소 목록입니다.
+func (ga *guestAgent) NetworkInterfaces(ctx context.Context) ([]guestNetworkInterface, error) {
+	var ifaces []guestNetworkInterface
+	err := ga.call(ctx, "guest-network-get-interfaces", nil, &ifaces)
+	return ifaces, err
+}
+
+// FSFreezeStatus는 파일 시스템 동결 상태("thawed" 또는 "frozen")입니다.
+func (ga *guestAgent) FSFreezeStatus(ctx context.Context) (string, error) {
+	var status string
+	err := ga.call(ctx, "guest-fsfreeze-status", nil, &status)
+	return status, err
+}
+
+// FSFreeze는 게스트 파일 시스템을 동결하고 동결한 개수를 반환합니다 (스냅샷 전에 사용).
+func (ga *guestAgent) FSFreeze(ctx context.Context) (int, error) {
+	var n int
+	err := ga.call(ctx, "guest-fsfreeze-freeze", nil, &n)
+	return n, err
+}
+
+// FSThaw는 동결한 파일 시스템을 풀고 푼 개수를 반환합니다.
+func (ga *guestAgent) FSThaw(ctx context.Context) (int, error) {
+	var n int
+	err := ga.call(ctx, "guest-fsfreeze-thaw", nil, &n)
+	return n, err
+}
+
+// guestExecStatus는 guest-exec로 실행한 프로세스의 상태입니다. 출력은 디코딩된 값입니다.
+type guestExecStatus struct {
+	Exited    bool
+	ExitCode  int
+	Signal    int
+	Stdout    []byte
+	Stderr    []byte
+	Truncated bool
+}
+
+// Exec는 게스트에서 프로그램을 실행하고 pid를 반환합니다. captureOutput이면 출력을 모아 ExecStatus로 받습니다.
+func (ga *guestAgent) Exec(ctx context.Context, path string, args []string, input []byte, captureOutput bool) (int, error) {
+	req := map[string]any{"path": path, "capture-output": captureOutput}
+	if len(args) > 0 {
+		req["arg"] = args
+	}
+	if input != nil {
+		req["input-data"] = base64.StdEncoding.EncodeToString(input)
+	}
+	var resp struct {
+		PID int `json:"pid"`
+	}
+	err := ga.call(ctx, "guest-exec", req, &resp)
+	return resp.PID, err
+}
+
+// ExecStatus는 Exec로 실행한 프로세스의 상태와 출력을 읽습니다.
+func (ga *guestAgent) ExecStatus(ctx context.Context, pid int) (guestExecStatus, error) {
+	var resp struct {
+		Exited       bool   `json:"exited"`
+		ExitCode     int    `json:"exitcode"`
+		Signal       int    `json:"signal"`
+		OutData      string `json:"out-data"`
+		ErrData      string `json:"err-data"`
+		OutTruncated bool   `json:"out-truncated"`
+		ErrTruncated bool   `json:"err-truncated"`
+	}
+	if err := ga.call(ctx, "guest-exec-status", map[string]any{"pid": pid}, &resp); err != nil {
+		return guestExecStatus{}, err
+	}
+	status := guestExecStatus{Exited: resp.Exited, ExitCode: resp.ExitCode, Signal: resp.Signal,
+		Truncated: resp.OutTruncated || resp.ErrTruncated}
+	var err error
+	if status.Stdout, err = base64.StdEncoding.DecodeString(resp.OutData); err != nil {
+		return status, err
+	}
+	status.Stderr, err = base64.StdEncoding.DecodeString(resp.ErrData)
+	return status, err
+}
+
+// Run은 Exec로 실행하고 끝날 때까지 기다려 상태를 반환합니다.
+func (ga *guestAgent) Run(ctx context.Context, path string, args ...string) (guestExecStatus, error) {
+	pid, err := ga.Exec(ctx, path, args, nil, true)
+	if err != nil {
+		return guestExecStatus{}, err
+	}
+	for {
+		status, err := ga.ExecStatus(ctx, pid)
+		if err != nil || status.Exited {
+			return status, err
+		}
+		select {
+		case <-ctx.Done():
+			return status, ctx.Err()
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+}
+
+// FileOpen은 게스트 파일을 열고 핸들을 반환합니다. mode는 fopen 형식("r", "w", "a+" 등)입니다.
+func (ga *guestAgent) FileOpen(ctx context.Context, path, mode string) (int64, error) {
+	var handle int64
+	err := ga.call(ctx, "guest-file-open", map[string]any{"path": path, "mode": mode}, &handle)
+	return handle, err
+}
+
+// FileRead는 최대 count바이트를 읽습니다. 파일 끝이면 io.EOF를 반환합니다.
+func (ga *guestAgent) FileRead(ctx context.Context, handle int64, count int) ([]byte, error) {
+	var resp struct {
+		Data string `json:"buf-b64"`
+		EOF  bool   `json:"eof"`
+	}
+	if err := ga.call(ctx, "guest-file-read", map[string]any{"handle": handle, "count": count}, &resp); err != nil {
+		return nil, err
+	}
+	data, err := base64.StdEncoding.DecodeString(resp.Data)
+	if err != nil {
+		return nil, err
+	}
+	if resp.EOF && len(data) == 0 {
+		return nil, io.EOF
+	}
+	return data, nil
+}
+
+// FileWrite는 data를 쓰고 쓴 바이트 수를 반환합니다.
+func (ga *guestAgent) FileWrite(ctx context.Context, handle int64, data []byte) (int, error) {
+	var resp struct {
+		Count int `json:"count"`
+	}
+	err := ga.call(ctx, "guest-file-write", map[string]any{"handle": handle, "buf-b64": base64.StdEncoding.EncodeToString(data)}, &resp)
+	return resp.Count, err
+}
+
+// FileSeek는 위치를 옮기고 새 위치를 반환합니다. whence는 io.SeekStart 등입니다.
+func (ga *guestAgent) FileSeek(ctx context.Context, handle, offset int64, whence int) (int64, error) {
+	var resp struct {
+		Position int64 `json:"position"`
+	}
+	whenceName := map[int]string{io.SeekStart: "set", io.SeekCurrent: "cur", io.SeekEnd: "end"}[whence]
+	err := ga.call(ctx, "guest-file-seek", map[string]any{"handle": handle, "offset": offset, "whence": whenceName}, &resp)
+	return resp.Position, err
+}
+
+func (ga *guestAgent) FileFlush(ctx context.Context, handle int64) error {
+	return ga.call(ctx, "guest-file-flush", map[string]any{"handle": handle}, nil)
+}
+
+func (ga *guestAgent) FileClose(ctx context.Context, handle int64) error {
+	return ga.call(ctx, "guest-file-close", map[string]any{"handle": handle}, nil)
+}
+
+// ReadFile은 게스트 파일 전체를 읽습니다.
+func (ga *guestAgent) ReadFile(ctx context.Context, path string) ([]byte, error) {
+	handle, err := ga.FileOpen(ctx, path, "r")
+	if err != nil {
+		return nil, err
+	}
+	defer ga.FileClose(ctx, handle)
+	var data []byte
+	for {
+		chunk, err := ga.FileRead(ctx, handle, 48*1024)
+		if err == io.EOF {
+			return data, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, chunk...)
+	}
+}
+
+// WriteFile은 게스트 파일을 data로 덮어씁니다.
+func (ga *guestAgent) WriteFile(ctx context.Context, path string, data []byte) error {
+	handle, err := ga.FileOpen(ctx, path, "w")
+	if err != nil {
+		return err
+	}
+	for len(data) > 0 {
+		chunk := data[:min(len(data), 48*1024)]
+		n, err := ga.FileWrite(ctx, handle, chunk)
+		if err == nil && n == 0 {
+			err = errors.New("게스트 파일에 쓰지 못했습니다.")
+		}
+		if err != nil {
+			ga.FileClose(ctx, handle)
+			return err
+		}
+		data = data[n:]
+	}
+	return ga.FileClose(ctx, handle)
+}
+
+// guestSummary는 관리 창에 표시할 게스트 운영체제와 IP 주소입니다 (루프백과 링크 로컬 주소는 뺌).
+func guestSummary(ctx context.Context, socket string) (string, error) {
+	ga, err := dialGuestAgent(ctx, socket)
+	if err != nil {
+		return "", err
+	}
+	defer ga.Close()
+	if err := ga.Ping(ctx); err != nil {
+		return "", err
+	}
+	text := "알 수 없는 운영체제"
+	if info, err := ga.OSInfo(ctx); err == nil {
+		switch {
+		case info.PrettyName != "":
+			text = info.PrettyName
+		case info.Name != "":
+			text = strings.TrimSpace(info.Name + " " + info.Version)
+		}
+	}
+	ifaces, err := ga.NetworkInterfaces(ctx)
+	if err != nil {
+		return text, nil
+	}
+	var addrs []string
+	for _, iface := range ifaces {
+		for _, a := range iface.IPAddresses {
+			ip := net.ParseIP(a.Address)
+			if ip == nil || ip.IsLoopback() || ip.IsLinkLocalUnicast() {
+				continue
+			}
+			addrs = append(addrs, a.Address)
+		}
+	}
+	if len(addrs) > 0 {
+		text += "\nIP: " + strings.Join(addrs, ", ")
+	}
+	return text, nil
+}
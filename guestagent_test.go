@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// skipFF는 qemu-ga처럼 입력의 0xFF 바이트(파서 초기화)를 건너뜁니다.
+type skipFF struct{ r io.Reader }
+
+func (s skipFF) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	return copy(p, bytes.ReplaceAll(p[:n], []byte{0xFF}, nil)), err
+}
+
+// fakeGuestAgent는 명령마다 handle이 돌려준 바이트를 그대로 쓰는 가짜 qemu-ga입니다.
+// guest-sync-delimited는 줄바꿈 없이 오므로 JSON 값 단위로 읽습니다.
+func fakeGuestAgent(t *testing.T, handle func(cmd map[string]any) string) string {
+	t.Helper()
+	socket := filepath.Join(t.TempDir(), "qga.sock")
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skip("unix 소켓을 쓸 수 없습니다:", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				dec := json.NewDecoder(skipFF{conn})
+				for {
+					var cmd map[string]any
+					if err := dec.Decode(&cmd); err != nil {
+						return
+					}
+					conn.Write([]byte(handle(cmd)))
+				}
+			}()
+		}
+	}()
+	return socket
+}
+
+// syncReply는 guest-sync-delimited 응답입니다. 이전 연결에서 남은 응답과
+// 다른 id의 sync 응답을 먼저 보내 클라이언트가 버리는지 확인합니다.
+func syncReply(cmd map[string]any) string {
+	id := int64(cmd["arguments"].(map[string]any)["id"].(float64))
+	return `{"return": {"version": "stale"}}` + "\n" +
+		"\xff" + fmt.Sprintf(`{"return": %d}`, id+1) + "\n" +
+		"\xff" + fmt.Sprintf(`{"return": %d}`, id) + "\n"
+}
+
+func TestGuestAgent(t *testing.T) {
+	socket := fakeGuestAgent(t, func(cmd map[string]any) string {
+		switch cmd["execute"] {
+		case "guest-sync-delimited":
+			return syncReply(cmd)
+		case "guest-ping":
+			return `{"return": {}}` + "\n"
+		case "guest-info":
+			return `{"return": {"version": "8.2.0", "supported_commands": [` +
+				`{"name": "guest-ping", "enabled": true}, {"name": "guest-exec", "enabled": false}]}}` + "\n"
+		case "guest-get-osinfo":
+			return `{"return": {"id": "debian", "name": "Debian GNU/Linux", "pretty-name": "Debian GNU/Linux 12 (bookworm)"}}` + "\n"
+		case "guest-network-get-interfaces":
+			return `{"return": [` +
+				`{"name": "lo", "hardware-address": "00:00:00:00:00:00", "ip-addresses": [{"ip-address-type": "ipv4", "ip-address": "127.0.0.1", "prefix": 8}]},` +
+				`{"name": "eth0", "hardware-address": "52:54:00:12:34:56", "ip-addresses": [` +
+				`{"ip-address-type": "ipv4", "ip-address": "10.0.2.15", "prefix": 24},` +
+				`{"ip-address-type": "ipv6", "ip-address": "fe80::5054:ff:fe12:3456", "prefix": 64}]}]}` + "\n"
+		}
+		return `{"error": {"class": "CommandNotFound", "desc": "Command ` + cmd["execute"].(string) + ` has not been found"}}` + "\n"
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ga, err := dialGuestAgent(ctx, socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ga.Close()
+
+	info, err := ga.Info(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Version != "8.2.0" || len(info.Commands) != 2 || !info.Commands[0].Enabled || info.Commands[1].Enabled {
+		t.Errorf("Info = %+v", info)
+	}
+
+	ifaces, err := ga.NetworkInterfaces(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ifaces) != 2 || ifaces[1].MAC != "52:54:00:12:34:56" || len(ifaces[1].IPAddresses) != 2 ||
+		ifaces[1].IPAddresses[0].Address != "10.0.2.15" || ifaces[1].IPAddresses[0].Prefix != 24 {
+		t.Errorf("NetworkInterfaces = %+v", ifaces)
+	}
+
+	_, err = ga.FSFreezeStatus(ctx)
+	if e, ok := err.(*guestAgentError); !ok || e.Class != "CommandNotFound" {
+		t.Errorf("FSFreezeStatus err = %v, want CommandNotFound", err)
+	}
+
+	// 루프백과 링크 로컬 주소는 빼고 표시
+	text, err := guestSummary(ctx, socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Debian GNU/Linux 12 (bookworm)\nIP: 10.0.2.15"; text != want {
+		t.Errorf("guestSummary = %q, want %q", text, want)
+	}
+}
+
+func TestGuestAgentTimeout(t *testing.T) {
+	// 에이전트가 설치되지 않은 게스트: QEMU는 소켓을 열지만 아무 응답도 없음
+	silent := fakeGuestAgent(t, func(cmd map[string]any) string { return "" })
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := dialGuestAgent(ctx, silent); err == nil {
+		t.Fatal("응답이 없는데 오류가 없습니다")
+	}
+	if d := time.Since(start); d > 2*time.Second {
+		t.Errorf("기한을 넘겨 %v 동안 기다렸습니다", d)
+	}
+
+	// sync 뒤에 명령에 응답하지 않는 경우
+	hung := fakeGuestAgent(t, func(cmd map[string]any) string {
+		if cmd["execute"] == "guest-sync-delimited" {
+			return syncReply(cmd)
+		}
+		return ""
+	})
+	ga, err := dialGuestAgent(context.Background(), hung)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ga.Close()
+	ctx, cancel = context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if err := ga.Ping(ctx); err == nil {
+		t.Error("응답이 없는데 오류가 없습니다")
+	}
+}
+
+func TestGuestAgentArgsNeedsPCI(t *testing.T) {
+	config := VMConfig{Machine: "q35", GuestAgent: "/run/qga.sock"}
+	args := guestAgentArgs(config)
+	if len(args) != 6 || args[3] != "virtio-serial,id=qga-serial0" {
+		t.Errorf("q35: %q", args)
+	}
+	config.Machine = "microbit"
+	if args := guestAgentArgs(config); args != nil {
+		t.Errorf("microbit: %q", args)
+	}
+}
@@ -0,0 +1,831 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	sqdialog "github.com/sqweek/dialog"
+)
+
+// 값을 받지 않는 QEMU 옵션 (나머지 옵션은 값 하나를 받음)
+var qemuFlagOptions = map[string]bool{
+	"-enable-kvm": true, "-nographic": true, "-no-reboot": true, "-no-shutdown": true,
+	"-snapshot": true, "-S": true, "-s": true, "-usb": true, "-full-screen": true,
+	"-daemonize": true, "-nodefaults": true, "-no-user-config": true, "-no-hpet": true,
+	"-no-acpi": true, "-no-fd-bootchk": true, "-win2k-hack": true, "-alt-grab": true,
+	"-ctrl-grab": true, "-enable-fips": true, "-only-migratable": true, "-no-quit": true,
+}
+
+// 명령줄의 옵션 하나 (name이 비어 있으면 옵션 없이 쓴 디스크 이미지)
+type qemuOption struct {
+	name  string
+	value string
+	flag  bool
+	raw   []string // 원래 인자 (추가 인자로 돌려보낼 때 사용)
+}
+
+// parseQEMUOptions는 인자를 옵션 단위로 묶습니다. "--m"은 "-m"으로 봅니다.
+func parseQEMUOptions(args []string) []qemuOption {
+	var opts []qemuOption
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") {
+			opts = append(opts, qemuOption{value: arg, raw: []string{arg}})
+			continue
+		}
+		name := arg
+		if strings.HasPrefix(name, "--") {
+			name = name[1:]
+		}
+		if qemuFlagOptions[name] || i+1 == len(args) {
+			opts = append(opts, qemuOption{name: name, flag: true, raw: []string{arg}})
+			continue
+		}
+		opts = append(opts, qemuOption{name: name, value: args[i+1], raw: args[i : i+2]})
+		i++
+	}
+	return opts
+}
+
+// splitOptionList는 "a,b=c,,d"를 ["a", "b=c,d"]로 나눕니다 (",,"는 쉼표 자체).
+func splitOptionList(s string) []string {
+	var items []string
+	var cur strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == ',' {
+			if i+1 < len(s) && s[i+1] == ',' {
+				cur.WriteByte(',')
+				i++
+				continue
+			}
+			items = append(items, cur.String())
+			cur.Reset()
+			continue
+		}
+		cur.WriteByte(s[i])
+	}
+	return append(items, cur.String())
+}
+
+// optionProps는 옵션 값을 첫 항목(이름 없는 값)과 key=value 목록으로 나눕니다.
+func optionProps(value string) (first string, props map[string]string) {
+	props = make(map[string]string)
+	for i, item := range splitOptionList(value) {
+		k, v, ok := strings.Cut(item, "=")
+		if !ok {
+			if i == 0 {
+				first = item
+			} else {
+				props[item] = "on"
+			}
+			continue
+		}
+		props[k] = v
+	}
+	return first, props
+}
+
+// 허용된 키 외의 속성이 있는지 확인
+func onlyProps(props map[string]string, allowed ...string) bool {
+	for k := range props {
+		found := false
+		for _, a := range allowed {
+			if k == a {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// 목록에서 대소문자를 무시하고 같은 항목
+func findOption(options []string, value string) (string, bool) {
+	for _, o := range options {
+		if strings.EqualFold(o, value) {
+			return o, true
+		}
+	}
+	return "", false
+}
+
+// QEMU CPU 모델 이름에 해당하는 CPU 모델 선택 항목
+func cpuModelOption(model string) (string, bool) {
+	for _, option := range cpuModelOptions {
+		if _, name := qemuCPU(option); strings.EqualFold(name, model) {
+			return option, true
+		}
+	}
+	return "", false
+}
+
+// "2048", "2G", "512M" → "2048MB", "2GB", "512MB" (단위가 없으면 MB)
+func importMemorySize(size string) (string, bool) {
+	unit := "M"
+	if n := len(size); n > 0 && strings.ContainsRune("kKmMgGtT", rune(size[n-1])) {
+		unit = strings.ToUpper(size[n-1:])
+		size = size[:n-1]
+	}
+	n, err := strconv.ParseInt(size, 10, 64)
+	if err != nil || n <= 0 {
+		return "", false
+	}
+	switch unit {
+	case "K":
+		if n%1024 != 0 {
+			return "", false
+		}
+		return strconv.FormatInt(n/1024, 10) + "MB", true
+	case "G":
+		return strconv.FormatInt(n, 10) + "GB", true
+	case "T":
+		return strconv.FormatInt(n*1024, 10) + "GB", true
+	}
+	return strconv.FormatInt(n, 10) + "MB", true
+}
+
+// qemu-img 포맷 이름(없으면 확장자)으로 디스크 종류를 고릅니다.
+func importDiskType(format, path string) (string, bool) {
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".qcow2":
+			format = "qcow2"
+		case ".img", ".raw":
+			format = "raw"
+		case ".vhd":
+			format = "vpc"
+		case ".vmdk":
+			format = "vmdk"
+		}
+	}
+	for _, diskType := range []string{"QCOW2", "RAW", "VHD", "VMDK"} {
+		if qemuImgFormat(diskType) == format {
+			return diskType, true
+		}
+	}
+	return "", false
+}
+
+// 이미지가 있으면 가상 크기(MB)를 용량으로 사용
+func importDiskCapacity(path string) string {
+	if info, err := getDiskImageInfo(path); err == nil && info.VirtualSize > 0 {
+		return strconv.FormatInt(info.VirtualSize/(1024*1024), 10)
+	}
+	return ""
+}
+
+// blockdev 캐시 옵션과 장치의 write-cache로 캐시 모드를 되찾음
+func importCacheMode(direct, noFlush, writeCache bool) string {
+	for _, mode := range diskCacheOptions {
+		if d, n, w := blockdevCacheOptions(mode); d == direct && n == noFlush && w == writeCache {
+			if mode == "writeback" {
+				return ""
+			}
+			return mode
+		}
+	}
+	return ""
+}
+
+// -drive 옵션의 캐시·aio·discard·detect-zeroes를 디스크 설정에 옮김
+func applyDriveProps(d *diskConfig, props map[string]string) {
+	if cache, ok := findOption(diskCacheOptions, props["cache"]); ok && cache != "writeback" {
+		d.Cache = cache
+	}
+	if aio, ok := findOption(diskAIOOptions, props["aio"]); ok && aio != "threads" {
+		d.AIO = aio
+	}
+	d.Discard = props["discard"] == "unmap" || props["discard"] == "on"
+	if dz, ok := findOption(diskDetectZeroesOptions, props["detect-zeroes"]); ok && dz != "off" {
+		d.DetectZeroes = dz
+	}
+}
+
+// 버전이 붙은 머신 이름(pc-q35-8.0 등)도 machineOptions 항목으로 바꿈
+func importMachineType(name string) (string, bool) {
+	switch {
+	case strings.HasPrefix(name, "pc-q35"):
+		name = "q35"
+	case strings.HasPrefix(name, "pc-"):
+		name = "pc"
+	case strings.HasPrefix(name, "virt-"):
+		name = "virt"
+	}
+	return findOption(machineOptions, name)
+}
+
+// -drive if= 값 → 디스크 버스 (IDE는 머신 종류에 따라 자동: pc는 IDE, q35는 AHCI)
+var driveInterfaceBus = map[string]string{
+	"": "", "ide": "", "virtio": "virtio-blk", "scsi": "virtio-scsi",
+}
+
+// -device 드라이버 → 디스크 버스 (CD/DVD 여부 포함)
+func deviceDiskBus(driver, bus, machine string) (diskBus string, cdrom, ok bool) {
+	switch driver {
+	case "virtio-blk-pci", "virtio-blk", "virtio-blk-device":
+		return "virtio-blk", false, true
+	case "scsi-hd", "scsi-cd":
+		return "virtio-scsi", driver == "scsi-cd", true
+	case "nvme":
+		return "nvme", false, true
+	case "ide-hd", "ide-cd":
+		// q35의 ide.N은 내장 AHCI 포트
+		if strings.Contains(bus, "ahci") || (machine == "q35" && strings.HasPrefix(bus, "ide.")) {
+			return "ahci", driver == "ide-cd", true
+		}
+		return "ide", driver == "ide-cd", true
+	}
+	return "", false, false
+}
+
+// importQEMUArgs는 qemu-system 명령줄을 VM 설정으로 바꿉니다.
+// 설정으로 옮길 수 없는 옵션은 ExtraArgs에 그대로 남기고, 잃어버리는 정보는 notes에 적습니다.
+func importQEMUArgs(binary string, args []string) (VMConfig, []string) {
+	var config VMConfig
+	var notes []string
+	opts := parseQEMUOptions(args)
+	used := make([]bool, len(opts))
+
+	// 머신 종류는 디스크 버스 판단에 필요하므로 먼저 읽음
+	machine := ""
+	accel := ""
+	for i, o := range opts {
+		if o.name != "-machine" && o.name != "-M" {
+			continue
+		}
+		first, props := optionProps(o.value)
+		if t, ok := props["type"]; ok && first == "" {
+			first = t
+		}
+		delete(props, "type")
+		if m, ok := importMachineType(first); ok {
+			machine = m
+		} else {
+			notes = append(notes, "지원하지 않는 머신 종류 "+first+" 대신 기본 머신을 사용합니다.")
+		}
+		if a, ok := props["accel"]; ok {
+			accel = a
+			delete(props, "accel")
+		}
+		for k, v := range props {
+			notes = append(notes, fmt.Sprintf("-machine의 %s=%s 속성은 가져오지 않았습니다.", k, v))
+		}
+		used[i] = true
+	}
+	x86 := binary == "qemu-system-x86_64" || binary == "qemu-system-i386"
+	if machine == "" && x86 {
+		// QEMU의 x86 기본 머신은 pc
+		machine = "pc"
+	}
+	config.Machine = machine
+
+	var disks []diskConfig
+	var cdroms []cdromConfig
+	bootIndex := make(map[string]int) // 장치 ID(disk0, cd0, net0) → bootindex
+	gpu := make(map[string]string)
+	gpuDevice := false
+	netImported, netSeen := false, false
+
+	addDisk := func(d diskConfig, cdrom bool, props map[string]string) {
+		id := ""
+		if cdrom {
+			id = fmt.Sprintf("cd%d", len(cdroms))
+			cdroms = append(cdroms, cdromConfig{Bus: d.Bus, Path: d.Path})
+		} else {
+			id = fmt.Sprintf("disk%d", len(disks))
+			d.Capacity = importDiskCapacity(d.Path)
+			disks = append(disks, d)
+		}
+		if n, err := strconv.Atoi(props["bootindex"]); err == nil {
+			bootIndex[id] = n
+		}
+	}
+
+	// -drive if=none, -blockdev, -netdev, throttle-group, secret은 -device에서 참조할 때 가져옴
+	drives := make(map[string]int)
+	blockdevs := make(map[string]int)
+	netdevs := make(map[string]int)
+	objects := make(map[string]int)
+
+	for i, o := range opts {
+		first, props := optionProps(o.value)
+		switch o.name {
+		case "-name":
+			name := first
+			if g, ok := props["guest"]; ok && name == "" {
+				name = g
+			}
+			config.Name = name
+			used[i] = true
+		case "-uuid":
+			config.UUID = o.value
+			used[i] = true
+		case "-m":
+			size := first
+			if s, ok := props["size"]; ok && size == "" {
+				size = s
+				delete(props, "size")
+			}
+			if ram, ok := importMemorySize(size); ok && len(props) == 0 {
+				config.RAM = ram
+				used[i] = true
+			}
+		case "-smp":
+			if !onlyProps(props, "cpus", "sockets", "cores", "threads") {
+				break
+			}
+			if first == "" {
+				first = props["cpus"]
+			}
+			config.CPUSockets = props["sockets"]
+			config.CPUCores = props["cores"]
+			config.CPUThreads = props["threads"]
+			if config.CPUCores == "" && config.CPUSockets == "" && config.CPUThreads == "" {
+				config.CPUCores = first
+			}
+			used[i] = true
+		case "-cpu":
+			option, ok := cpuModelOption(first)
+			if !ok {
+				break
+			}
+			config.CPUModel = option
+			features := splitOptionList(o.value)[1:]
+			config.CPUFeatures = strings.Join(features, ",")
+			used[i] = true
+		case "-enable-kvm":
+			accel = "kvm"
+			used[i] = true
+		case "-accel":
+			if _, ok := findOption(acceleratorOptions, first); ok && len(props) == 0 {
+				accel = first
+				used[i] = true
+			}
+		case "-kernel":
+			config.Kernel = o.value
+			used[i] = true
+		case "-initrd":
+			config.Initrd = o.value
+			used[i] = true
+		case "-append":
+			config.KernelAppend = o.value
+			used[i] = true
+		case "-dtb":
+			config.DTB = o.value
+			used[i] = true
+		case "-vga":
+			if vga, ok := findOption(gpuFrontendOptions, o.value); ok {
+				gpu["vga"] = vga
+				used[i] = true
+			}
+		case "-display":
+			if display, ok := findOption(gpuDisplayOptions, first); ok && display != "none" && onlyProps(props, "gl") {
+				gpu["display"] = display
+				if props["gl"] == "on" {
+					gpu["gl"] = "on"
+				}
+				used[i] = true
+			}
+		case "-boot":
+			// 부팅 메뉴만 설정으로 옮기고, 부팅 순서 지정(order=, once=)은 그대로 둠
+			if first == "" && onlyProps(props, "menu", "splash-time") && props["menu"] == "on" {
+				config.BootMenu = "true"
+				config.BootMenuTimeout = props["splash-time"]
+				used[i] = true
+			}
+		case "", "-hda", "-hdb", "-hdc", "-hdd":
+			if diskType, ok := importDiskType("", o.value); ok {
+				addDisk(diskConfig{Type: diskType, Path: o.value}, false, nil)
+				used[i] = true
+			}
+		case "-cdrom":
+			addDisk(diskConfig{Path: o.value}, true, nil)
+			used[i] = true
+		case "-drive":
+			if props["if"] == "none" && props["id"] != "" {
+				drives[props["id"]] = i
+				break
+			}
+			bus, ok := driveInterfaceBus[props["if"]]
+			if !ok || props["file"] == "" || !onlyProps(props, "file", "format", "if", "media", "cache", "aio", "discard", "detect-zeroes", "index") {
+				break
+			}
+			if props["media"] == "cdrom" {
+				addDisk(diskConfig{Path: props["file"], Bus: bus}, true, nil)
+				used[i] = true
+				break
+			}
+			diskType, ok := importDiskType(props["format"], props["file"])
+			if !ok {
+				break
+			}
+			d := diskConfig{Type: diskType, Path: props["file"], Bus: bus}
+			applyDriveProps(&d, props)
+			addDisk(d, false, nil)
+			used[i] = true
+		case "-blockdev":
+			if node := props["node-name"]; node != "" && !strings.HasPrefix(o.value, "{") {
+				blockdevs[node] = i
+			}
+		case "-netdev":
+			netSeen = true
+			if id := props["id"]; id != "" {
+				netdevs[id] = i
+			}
+		case "-nic":
+			netSeen = true
+			if first == "none" {
+				config.Network = "none"
+				used[i] = true
+				break
+			}
+			if netImported || (props["model"] != "" && props["model"] != nicModel(vmMachine(config))) {
+				break
+			}
+			config.MAC = props["mac"]
+			config.Network = first
+			for _, item := range splitOptionList(o.value)[1:] {
+				if k, _, _ := strings.Cut(item, "="); k != "model" && k != "mac" {
+					config.Network += "," + item
+				}
+			}
+			netImported = true
+			used[i] = true
+		case "-net":
+			netSeen = true
+		case "-object":
+			if id := props["id"]; id != "" {
+				objects[id] = i
+			}
+		}
+	}
+
+	// blockdev 체인(포맷 노드 → 파일 노드, throttle 필터 포함)을 디스크 하나로 되돌림
+	importBlockdev := func(node string, devProps map[string]string) (diskConfig, []int, bool) {
+		var consumed []int
+		var d diskConfig
+		i, ok := blockdevs[node]
+		if !ok {
+			return d, nil, false
+		}
+		_, props := optionProps(opts[i].value)
+		if props["driver"] == "throttle" {
+			gi, ok := objects[props["throttle-group"]]
+			if !ok {
+				return d, nil, false
+			}
+			_, group := optionProps(opts[gi].value)
+			d.Throttle = make(map[string]string)
+			for _, key := range throttleLimitKeys {
+				if v := group["limits."+key]; v != "" {
+					d.Throttle[key] = v
+				}
+			}
+			consumed = append(consumed, i, gi)
+			if i, ok = blockdevs[props["file"]]; !ok {
+				return d, nil, false
+			}
+			_, props = optionProps(opts[i].value)
+		}
+		diskType, ok := importDiskType(props["driver"], "")
+		if !ok || props["driver"] == "" {
+			return d, nil, false
+		}
+		fi, ok := blockdevs[props["file"]]
+		if !ok {
+			return d, nil, false
+		}
+		_, fileProps := optionProps(opts[fi].value)
+		if fileProps["driver"] != "file" || fileProps["filename"] == "" {
+			return d, nil, false
+		}
+		consumed = append(consumed, i, fi)
+		if secret := props["encrypt.key-secret"]; secret != "" {
+			si, ok := objects[secret]
+			if !ok {
+				return d, nil, false
+			}
+			d.Encrypted = true
+			consumed = append(consumed, si)
+		}
+		d.Type = diskType
+		d.Path = fileProps["filename"]
+		d.Cache = importCacheMode(props["cache.direct"] == "on", props["cache.no-flush"] == "on", devProps["write-cache"] != "off")
+		if aio, ok := findOption(diskAIOOptions, fileProps["aio"]); ok && aio != "threads" {
+			d.AIO = aio
+		}
+		d.Discard = props["discard"] == "unmap"
+		if dz, ok := findOption(diskDetectZeroesOptions, props["detect-zeroes"]); ok && dz != "off" {
+			d.DetectZeroes = dz
+		}
+		return d, consumed, true
+	}
+
+	controllers := make(map[string]int) // 컨트롤러 드라이버 → 옵션 위치
+	for i, o := range opts {
+		if o.name != "-device" {
+			continue
+		}
+		driver, props := optionProps(o.value)
+		switch {
+		case driver == "virtio-scsi-pci" || driver == "ahci" || driver == "ich9-ahci":
+			controllers[driver] = i
+		case gpuDeviceOptionFound(driver) && onlyProps(props, "hostmem", "id"):
+			gpu["device"] = driver
+			if hostmem := props["hostmem"]; hostmem != "" {
+				gpu["hostmem"] = hostmem
+			}
+			gpuDevice = true
+			used[i] = true
+		case props["netdev"] != "":
+			ni, ok := netdevs[props["netdev"]]
+			if !ok || netImported || driver != nicModel(vmMachine(config)) {
+				break
+			}
+			netType, _ := optionProps(opts[ni].value)
+			config.Network = netType
+			for _, item := range splitOptionList(opts[ni].value)[1:] {
+				if k, _, _ := strings.Cut(item, "="); k != "id" {
+					config.Network += "," + item
+				}
+			}
+			config.MAC = props["mac"]
+			if n, err := strconv.Atoi(props["bootindex"]); err == nil {
+				bootIndex["net0"] = n
+			}
+			netImported = true
+			used[i], used[ni] = true, true
+		default:
+			bus, cdrom, ok := deviceDiskBus(driver, props["bus"], machine)
+			if !ok {
+				break
+			}
+			ref := props["drive"]
+			if ref == "" {
+				if cdrom {
+					// 매체 없는 빈 드라이브
+					addDisk(diskConfig{Bus: bus}, true, props)
+					used[i] = true
+				}
+				break
+			}
+			if di, ok := drives[ref]; ok {
+				_, dp := optionProps(opts[di].value)
+				if cdrom || dp["media"] == "cdrom" {
+					addDisk(diskConfig{Path: dp["file"], Bus: bus}, true, props)
+				} else {
+					diskType, ok := importDiskType(dp["format"], dp["file"])
+					if !ok {
+						break
+					}
+					d := diskConfig{Type: diskType, Path: dp["file"], Bus: bus}
+					applyDriveProps(&d, dp)
+					addDisk(d, false, props)
+				}
+				used[i], used[di] = true, true
+				break
+			}
+			if cdrom {
+				// CD/DVD는 raw 포맷 노드 → 파일 노드
+				bi, ok := blockdevs[ref]
+				if !ok {
+					break
+				}
+				_, bp := optionProps(opts[bi].value)
+				fi, ok := blockdevs[bp["file"]]
+				if !ok || bp["driver"] != "raw" {
+					break
+				}
+				_, fp := optionProps(opts[fi].value)
+				addDisk(diskConfig{Path: fp["filename"], Bus: bus}, true, props)
+				used[i], used[bi], used[fi] = true, true, true
+				break
+			}
+			d, consumed, ok := importBlockdev(ref, props)
+			if !ok {
+				break
+			}
+			d.Bus = bus
+			addDisk(d, false, props)
+			used[i] = true
+			for _, c := range consumed {
+				used[c] = true
+			}
+		}
+	}
+
+	// 가져온 디스크가 쓰는 컨트롤러는 실행할 때 다시 만들어지므로 뺌
+	for driver, i := range controllers {
+		want := "ahci"
+		if driver == "virtio-scsi-pci" {
+			want = "virtio-scsi"
+		}
+		for _, d := range disks {
+			if d.Bus == want {
+				used[i] = true
+			}
+		}
+		for _, c := range cdroms {
+			if c.Bus == want {
+				used[i] = true
+			}
+		}
+	}
+	// GPU 장치를 가져오면 실행할 때 -vga none이 다시 붙음
+	if gpuDevice {
+		for i, o := range opts {
+			if o.name == "-vga" && o.value == "none" {
+				used[i] = true
+			}
+		}
+	}
+	// 네트워크 옵션이 있는데 하나도 가져오지 못했으면 기본 NIC를 만들지 않음
+	if netSeen && !netImported && config.Network == "" {
+		config.Network = "none"
+	}
+
+	if accel != "" {
+		if a, ok := findOption(acceleratorOptions, accel); ok {
+			config.CPUAccel = "true"
+			config.CPUAccelerator = a
+		}
+	}
+	if config.CPUModel == "" && !x86 {
+		notes = append(notes, binary+"용 CPU 모델을 알 수 없어 x86_64 가상머신으로 가져왔습니다.")
+	}
+
+	config.Disk = formatDiskConfigs(disks)
+	config.CDROM = formatCDROMConfigs(cdroms)
+
+	var gpuPairs []string
+	for _, key := range []string{"vga", "display", "device", "gl", "hostmem"} {
+		if v := gpu[key]; v != "" {
+			gpuPairs = append(gpuPairs, key+"="+v)
+		}
+	}
+	config.GPU = strings.Join(gpuPairs, ",")
+
+	// bootindex 순서대로 부팅 순서를 정함
+	if len(bootIndex) > 0 {
+		var ids []string
+		for id := range bootIndex {
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(a, b int) bool { return bootIndex[ids[a]] < bootIndex[ids[b]] })
+		config.BootOrder = strings.Join(syncBootOrder(ids, bootDevices(config)), ",")
+	}
+
+	var extra []string
+	var unknown []string
+	for i, o := range opts {
+		if used[i] {
+			continue
+		}
+		extra = append(extra, o.raw...)
+		unknown = append(unknown, o.raw[0])
+	}
+	config.ExtraArgs = joinArgs(extra)
+	if len(unknown) > 0 {
+		notes = append(notes, "설정으로 옮기지 못한 옵션을 추가 인자에 넣었습니다: "+strings.Join(unknown, " "))
+	}
+	return config, notes
+}
+
+func gpuDeviceOptionFound(driver string) bool {
+	_, ok := findOption(gpuDeviceOptions, driver)
+	return ok
+}
+
+// qemu-system 실행 파일 이름이면 ".exe"와 경로를 뗀 이름을 돌려줌
+func qemuBinaryName(token string) (string, bool) {
+	base := strings.ToLower(filepath.Base(strings.ReplaceAll(token, `\`, "/")))
+	base = strings.TrimSuffix(base, ".exe")
+	return base, strings.HasPrefix(base, "qemu-system-")
+}
+
+// extractQEMUCommand는 붙여 넣은 명령줄이나 .sh/.bat/.cmd 스크립트에서 qemu-system 명령을 찾습니다.
+// 줄 끝의 \, ^, `는 다음 줄로 이어지며, batch가 참이면 %%를 %로 바꿉니다.
+func extractQEMUCommand(text string, batch bool) (string, []string, error) {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	var lines []string
+	var cur string
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimRight(line, " \t")
+		if n := len(trimmed); n > 0 && strings.ContainsRune("\\^`", rune(trimmed[n-1])) {
+			cur += trimmed[:n-1] + " "
+			continue
+		}
+		lines = append(lines, cur+line)
+		cur = ""
+	}
+	if cur != "" {
+		lines = append(lines, cur)
+	}
+
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		lower := strings.ToLower(trimmed)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "::") ||
+			strings.HasPrefix(lower, "rem ") || strings.HasPrefix(lower, "@echo") {
+			continue
+		}
+		if batch {
+			trimmed = strings.ReplaceAll(trimmed, "%%", "%")
+		}
+		tokens, err := splitArgs(trimmed)
+		if err != nil {
+			continue
+		}
+		for i, token := range tokens {
+			binary, ok := qemuBinaryName(token)
+			if !ok {
+				continue
+			}
+			args := tokens[i+1:]
+			// 리다이렉션, 파이프, 명령 연결 이후는 버림
+			for j, arg := range args {
+				if arg == "|" || arg == "||" || arg == "&" || arg == "&&" || arg == ";" ||
+					strings.HasPrefix(arg, ">") || strings.HasPrefix(arg, "<") || strings.HasPrefix(arg, "2>") {
+					args = args[:j]
+					break
+				}
+			}
+			return binary, args, nil
+		}
+	}
+	return "", nil, errors.New("qemu-system 명령을 찾을 수 없습니다.")
+}
+
+// ShowImportCommandWindow는 QEMU 명령줄이나 스크립트를 새 가상머신 설정으로 가져오는 창을 띄웁니다.
+func ShowImportCommandWindow(configDir string, parent fyne.Window, onDone func()) {
+	win := fyne.CurrentApp().NewWindow("QEMU 명령줄 가져오기")
+	win.Resize(fyne.NewSize(640, 420))
+
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder("가상머신 이름 (비우면 -name 값 사용)")
+	textEntry := widget.NewMultiLineEntry()
+	textEntry.SetPlaceHolder("qemu-system-... 명령줄을 붙여 넣거나 스크립트 파일을 여세요.")
+	textEntry.Wrapping = fyne.TextWrapBreak
+	batch := false
+
+	openBtn := widget.NewButton("스크립트 열기", func() {
+		path, err := sqdialog.File().Title("QEMU 스크립트 선택").Filter("스크립트", "sh", "bat", "cmd").Load()
+		if err != nil {
+			return
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		textEntry.SetText(string(data))
+		ext := strings.ToLower(filepath.Ext(path))
+		batch = ext == ".bat" || ext == ".cmd"
+		if nameEntry.Text == "" {
+			nameEntry.SetText(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)))
+		}
+	})
+
+	importBtn := widget.NewButton("가져오기", func() {
+		binary, args, err := extractQEMUCommand(textEntry.Text, batch)
+		if err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		config, notes := importQEMUArgs(binary, args)
+		if name := strings.TrimSpace(nameEntry.Text); name != "" {
+			config.Name = name
+		}
+		if err := saveImportedConfig(configDir, config, notes, parent); err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		onDone()
+		win.Close()
+	})
+	cancelBtn := widget.NewButton("취소", func() { win.Close() })
+
+	win.SetContent(container.NewBorder(
+		container.NewVBox(
+			widget.NewForm(widget.NewFormItem("이름", nameEntry)),
+			openBtn,
+		),
+		container.NewHBox(importBtn, cancelBtn),
+		nil, nil,
+		textEntry,
+	))
+	win.CenterOnScreen()
+	win.Show()
+}
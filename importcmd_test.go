@@ -0,0 +1,110 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// 설정으로 만든 명령줄을 다시 가져오면 같은 설정이 되어야 함
+func TestImportQEMUArgsRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	kernel, initrd := filepath.Join(dir, "vmlinuz"), filepath.Join(dir, "initrd.img")
+	writeTestFile(t, kernel, "kernel")
+	writeTestFile(t, initrd, "initrd")
+	config := VMConfig{
+		Name: "rt", UUID: "6f1b3c2e-1d2a-4b7e-9a55-0c8d4e2f7a10", RAM: "4GB", Machine: "q35",
+		CPUModel: "Intel: Haswell", CPUFeatures: "+avx2,-hle", CPUSockets: "1", CPUCores: "4", CPUThreads: "2",
+		CPUAccel: "true", CPUAccelerator: "KVM",
+		Disk: formatDiskConfigs([]diskConfig{
+			{Type: "QCOW2", Path: `C:\vm\rt\os.qcow2`, Bus: "virtio-blk", Cache: "none", AIO: "native", Discard: true,
+				Throttle: map[string]string{"iops-total": "500", "bps-read": "1048576", "bps-read-max": "2097152"}},
+			{Type: "RAW", Path: `D:\data,1.img`, Bus: "virtio-scsi", Discard: true, DetectZeroes: "unmap"},
+			{Type: "QCOW2", Path: `C:\vm\rt\secret.qcow2`, Bus: "ahci", Cache: "writethrough", Encrypted: true},
+		}),
+		CDROM:     formatCDROMConfigs([]cdromConfig{{Bus: "ahci", Path: `E:\ISO\install.iso`}, {Bus: "ahci"}}),
+		BootOrder: "cd0,disk0,disk1,disk2,cd1,net0", BootMenu: "true", BootMenuTimeout: "3000",
+		GPU:     "display=gtk,device=virtio-vga,gl=on",
+		Network: "user,hostfwd=tcp::2222-:22", MAC: "52:54:00:12:34:56",
+		Kernel: kernel, Initrd: initrd, KernelAppend: "console=ttyS0 root=/dev/vda1",
+		ExtraArgs: joinArgs([]string{"-device", "usb-tablet", "-rtc", "base=localtime"}),
+	}
+	binary, args, err := buildQEMUArgs(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, notes := importQEMUArgs(binary, args)
+	if got != config {
+		t.Errorf("importQEMUArgs(%q) =\n%+v\nwant\n%+v", args, got, config)
+	}
+	// 추가 인자로 돌려보낸 옵션만 안내
+	if len(notes) != 1 || !strings.Contains(notes[0], "-device -rtc") {
+		t.Errorf("notes = %q", notes)
+	}
+}
+
+func TestImportQEMUArgs(t *testing.T) {
+	binary, args, err := extractQEMUCommand(""+
+		"@echo off\r\n"+
+		"rem 예전 실행 스크립트\r\n"+
+		`"C:\Program Files\qemu\qemu-system-x86_64.exe" -enable-kvm -m 2G -smp 2 -cpu EPYC,+svm ^`+"\r\n"+
+		`  -hda win.qcow2 -cdrom "D:\iso\Win 11.iso" -drive file=data.img,if=virtio,cache=unsafe,discard=on ^`+"\r\n"+
+		`  -nic user,model=e1000,mac=52:54:00:aa:bb:cc -vga std -boot menu=on -usbdevice tablet -snapshot > log%%1.txt`+"\r\n",
+		true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if binary != "qemu-system-x86_64" {
+		t.Errorf("binary = %q", binary)
+	}
+	got, notes := importQEMUArgs(binary, args)
+	want := VMConfig{
+		RAM: "2GB", Machine: "pc", CPUModel: "AMD: EPYC", CPUFeatures: "+svm", CPUCores: "2",
+		CPUAccel: "true", CPUAccelerator: "KVM",
+		Disk: formatDiskConfigs([]diskConfig{
+			{Type: "QCOW2", Path: "win.qcow2"},
+			{Type: "RAW", Path: "data.img", Bus: "virtio-blk", Cache: "unsafe", Discard: true},
+		}),
+		CDROM:    formatCDROMConfigs([]cdromConfig{{Path: `D:\iso\Win 11.iso`}}),
+		BootMenu: "true", GPU: "vga=std",
+		Network: "user", MAC: "52:54:00:aa:bb:cc",
+		ExtraArgs: "-usbdevice tablet -snapshot",
+	}
+	if got != want {
+		t.Errorf("importQEMUArgs(%q) =\n%+v\nwant\n%+v", args, got, want)
+	}
+	if len(notes) != 1 {
+		t.Errorf("notes = %q", notes)
+	}
+
+	// 모르는 머신과 가져올 수 없는 네트워크
+	got, notes = importQEMUArgs("qemu-system-aarch64", []string{"-M", "raspi3b", "-netdev", "tap,id=n0", "-device", "rtl8139,netdev=n0"})
+	if got.Machine != "" || got.Network != "none" || got.CPUModel != "" || len(notes) != 3 {
+		t.Errorf("config = %+v, notes = %q", got, notes)
+	}
+}
+
+func TestImportMemorySize(t *testing.T) {
+	for in, want := range map[string]string{"2048": "2048MB", "512M": "512MB", "4g": "4GB", "1T": "1024GB", "1048576k": "1024MB", "100k": "", "0": "", "": ""} {
+		got, ok := importMemorySize(in)
+		if got != want || ok != (want != "") {
+			t.Errorf("importMemorySize(%q) = %q, %v; want %q", in, got, ok, want)
+		}
+	}
+}
+
+func TestParseQEMUOptions(t *testing.T) {
+	got := parseQEMUOptions([]string{"disk.img", "--m", "1G", "-nographic", "-name", "x", "-S", "-kernel"})
+	want := []qemuOption{
+		{value: "disk.img", raw: []string{"disk.img"}},
+		{name: "-m", value: "1G", raw: []string{"--m", "1G"}},
+		{name: "-nographic", flag: true, raw: []string{"-nographic"}},
+		{name: "-name", value: "x", raw: []string{"-name", "x"}},
+		{name: "-S", flag: true, raw: []string{"-S"}},
+		{name: "-kernel", flag: true, raw: []string{"-kernel"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseQEMUOptions =\n%+v\nwant\n%+v", got, want)
+	}
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	sqdialog "github.com/sqweek/dialog"
+)
+
+// saveImportedConfig는 가져온 설정에 UUID와 MAC을 채워 새 .conf로 저장하고,
+// 가져오지 못한 항목(notes)을 알려 줍니다.
+func saveImportedConfig(configDir string, config VMConfig, notes []string, parent fyne.Window) error {
+	if err := checkCloneName(configDir, config.Name); err != nil {
+		return err
+	}
+	if config.UUID == "" {
+		config.UUID = newVMUUID()
+	}
+	if config.MAC == "" {
+		config.MAC = newVMMAC()
+	}
+	if err := validateExtraArgs(config); err != nil {
+		notes = append(notes, err.Error())
+	}
+	// 게스트 에이전트 채널만 켜 두고 경로를 정하지 않은 설정 (libvirt 가져오기)
+	if config.GuestAgent == "true" {
+		config.GuestAgent = defaultGuestAgentSocket(configDir, config)
+	}
+	if err := ensureNVRAM(configDir, &config); err != nil {
+		notes = append(notes, "UEFI 변수 저장소를 만들지 못했습니다: "+err.Error())
+	}
+	if err := ensureCloudInitSeed(configDir, &config); err != nil {
+		notes = append(notes, "cloud-init seed ISO를 만들지 못했습니다: "+err.Error())
+	}
+	if err := ensureUnattendMedia(configDir, &config); err != nil {
+		notes = append(notes, "자동 설치 응답 파일 ISO를 만들지 못했습니다: "+err.Error())
+	}
+	if err := saveVMConfig(configDir, config); err != nil {
+		return err
+	}
+	message := config.Name + " 가상머신을 가져왔습니다."
+	if len(notes) > 0 {
+		message += "\n\n" + strings.Join(notes, "\n")
+	}
+	dialog.ShowInformation("가져오기", message, parent)
+	return nil
+}
+
+// showFileImportWindow는 파일 하나를 골라 parse로 설정을 읽어 저장하는 가져오기 창을 띄웁니다.
+// 이름을 비워 두면 파일에 있는 이름을 사용합니다.
+func showFileImportWindow(title, filterName string, exts []string, configDir string, parent fyne.Window, onDone func(),
+	parse func(path string) (VMConfig, []string, error)) {
+	win := fyne.CurrentApp().NewWindow(title)
+	win.Resize(fyne.NewSize(500, 150))
+
+	pathEntry := widget.NewEntry()
+	pathEntry.SetPlaceHolder("가져올 파일")
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder("가상머신 이름 (비우면 파일의 이름 사용)")
+	browseBtn := widget.NewButton("찾아보기", func() {
+		path, err := sqdialog.File().Title(title).Filter(filterName, exts...).Load()
+		if err != nil {
+			return
+		}
+		pathEntry.SetText(path)
+	})
+
+	importBtn := widget.NewButton("가져오기", func() {
+		path := strings.TrimSpace(pathEntry.Text)
+		if _, err := os.Stat(path); err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		config, notes, err := parse(path)
+		if err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		if name := strings.TrimSpace(nameEntry.Text); name != "" {
+			config.Name = name
+		}
+		if config.Name == "" {
+			config.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		}
+		if err := saveImportedConfig(configDir, config, notes, parent); err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		onDone()
+		win.Close()
+	})
+	cancelBtn := widget.NewButton("취소", func() { win.Close() })
+
+	win.SetContent(container.NewVBox(
+		widget.NewForm(
+			widget.NewFormItem("파일", container.NewBorder(nil, nil, nil, browseBtn, pathEntry)),
+			widget.NewFormItem("이름", nameEntry),
+		),
+		container.NewHBox(importBtn, cancelBtn),
+	))
+	win.CenterOnScreen()
+	win.Show()
+}
+
+// diskImportFunc는 path의 가상머신을 읽어 디스크를 destDir에 diskType 형식으로 변환하고 설정을 돌려줍니다.
+// keepChain이면 스냅샷 체인을 병합하지 않고 QCOW2 오버레이로 유지합니다.
+type diskImportFunc func(ctx context.Context, path, name, destDir, diskType string, keepChain bool, report func(float64)) (VMConfig, []string, error)
+
+// 가져오기 창에서 추가로 고를 항목
+type diskImportFields struct {
+	format bool // 디스크 형식 (고르지 않으면 diskType은 "")
+	chain  bool // 스냅샷 체인 처리 방식
+}
+
+// showDiskImportWindow는 디스크를 복사하거나 변환하는 가져오기 창을 엽니다. 작업은 메인 창의 작업 패널에서 진행됩니다.
+func showDiskImportWindow(title, filterName string, exts []string, fields diskImportFields, configDir string, parent fyne.Window, jobs *jobPanel, onDone func(), run diskImportFunc) {
+	win := fyne.CurrentApp().NewWindow(title)
+	win.Resize(fyne.NewSize(500, 200))
+
+	pathEntry := widget.NewEntry()
+	pathEntry.SetPlaceHolder("가져올 파일")
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder("새 가상머신 이름")
+	destEntry := widget.NewEntry()
+	destEntry.SetPlaceHolder("디스크를 저장할 폴더")
+	browseBtn := widget.NewButton("찾아보기", func() {
+		path, err := sqdialog.File().Title(title).Filter(filterName, exts...).Load()
+		if err != nil {
+			return
+		}
+		pathEntry.SetText(path)
+		nameEntry.SetText(strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)))
+	})
+	nameEntry.OnChanged = func(name string) {
+		destEntry.SetText(vmStorageDir(configDir, strings.TrimSpace(name)))
+	}
+	destBtn := widget.NewButton("경로선택", func() {
+		dir, err := sqdialog.Directory().Title("디스크 폴더 선택").Browse()
+		if err != nil || dir == "" {
+			return
+		}
+		destEntry.SetText(filepath.Join(dir, strings.TrimSpace(nameEntry.Text)))
+	})
+	formatSelect := widget.NewSelect([]string{"QCOW2", "RAW", "VHD", "VMDK"}, nil)
+	formatSelect.SetSelected("QCOW2")
+	chainRadio := widget.NewRadioGroup([]string{"하나로 병합", "QCOW2 오버레이로 유지"}, nil)
+	chainRadio.Horizontal = true
+	chainRadio.SetSelected("하나로 병합")
+
+	importBtn := widget.NewButton("가져오기", func() {
+		path := strings.TrimSpace(pathEntry.Text)
+		if _, err := os.Stat(path); err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		name := strings.TrimSpace(nameEntry.Text)
+		if err := checkCloneName(configDir, name); err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		destDir := strings.TrimSpace(destEntry.Text)
+		if destDir == "" {
+			dialog.ShowError(errors.New("디스크를 저장할 폴더를 선택하십시오."), win)
+			return
+		}
+		diskType := ""
+		if fields.format {
+			diskType = formatSelect.Selected
+		}
+		keepChain := fields.chain && chainRadio.Selected != "하나로 병합"
+		win.Close()
+		var config VMConfig
+		var notes []string
+		jobs.start(filepath.Base(path)+" 가져오는 중", func(ctx context.Context, report func(float64)) error {
+			var err error
+			config, notes, err = run(ctx, path, name, destDir, diskType, keepChain, report)
+			return err
+		}, func(err error) {
+			// 기본 대상 폴더에 풀었으면 관리형 가상머신으로
+			if err == nil && sameDiskPath(destDir, vmStorageDir(configDir, name)) {
+				config.Managed = true
+				err = makeVMSubdirs(destDir)
+			}
+			if err == nil {
+				err = saveImportedConfig(configDir, config, notes, parent)
+			}
+			if err != nil {
+				dialog.ShowError(err, parent)
+				return
+			}
+			onDone()
+		})
+	})
+	cancelBtn := widget.NewButton("취소", func() { win.Close() })
+
+	form := widget.NewForm(
+		widget.NewFormItem("파일", container.NewBorder(nil, nil, nil, browseBtn, pathEntry)),
+		widget.NewFormItem("이름", nameEntry),
+		widget.NewFormItem("대상 폴더", container.NewBorder(nil, nil, nil, destBtn, destEntry)),
+	)
+	if fields.format {
+		form.Append("디스크 형식", formatSelect)
+	}
+	if fields.chain {
+		form.Append("스냅샷", chainRadio)
+	}
+	win.SetContent(container.NewVBox(form, container.NewHBox(importBtn, cancelBtn)))
+	win.CenterOnScreen()
+	win.Show()
+}
+
+// ShowImportWindow는 가져올 형식을 고르는 창을 띄웁니다.
+func ShowImportWindow(configDir string, parent fyne.Window, jobs *jobPanel, onDone func()) {
+	win := fyne.CurrentApp().NewWindow("가상머신 가져오기")
+	cmdBtn := widget.NewButton("QEMU 명령줄 / 스크립트", func() {
+		ShowImportCommandWindow(configDir, parent, onDone)
+		win.Close()
+	})
+	libvirtBtn := widget.NewButton("libvirt 도메인 XML", func() {
+		showFileImportWindow("libvirt XML 가져오기", "libvirt 도메인 XML", []string{"xml"}, configDir, parent, onDone, importLibvirtFile)
+		win.Close()
+	})
+	ovfBtn := widget.NewButton("OVF / OVA", func() {
+		showDiskImportWindow("OVF/OVA 가져오기", "OVF/OVA", []string{"ova", "ovf"}, diskImportFields{format: true}, configDir, parent, jobs, onDone,
+			func(ctx context.Context, path, name, destDir, diskType string, _ bool, report func(float64)) (VMConfig, []string, error) {
+				return importOVF(ctx, configDir, path, name, destDir, diskType, report)
+			})
+		win.Close()
+	})
+	bundleBtn := widget.NewButton("가상머신 묶음 (.vmbundle)", func() {
+		showDiskImportWindow("묶음 가져오기", "가상머신 묶음", []string{"vmbundle"}, diskImportFields{}, configDir, parent, jobs, onDone,
+			func(ctx context.Context, path, name, destDir, _ string, _ bool, report func(float64)) (VMConfig, []string, error) {
+				return importBundle(ctx, path, name, destDir, report)
+			})
+		win.Close()
+	})
+	// VirtualBox와 VMware는 설정을 읽은 뒤 디스크를 변환
+	foreign := func(parse func(path string) (foreignVM, error)) diskImportFunc {
+		return func(ctx context.Context, path, name, destDir, diskType string, keepChain bool, report func(float64)) (VMConfig, []string, error) {
+			vm, err := parse(path)
+			if err != nil {
+				return VMConfig{}, nil, err
+			}
+			return importForeignVM(ctx, vm, name, destDir, diskType, keepChain, report)
+		}
+	}
+	vboxBtn := widget.NewButton("VirtualBox (.vbox)", func() {
+		showDiskImportWindow("VirtualBox 가져오기", "VirtualBox 가상머신", []string{"vbox"}, diskImportFields{format: true, chain: true}, configDir, parent, jobs, onDone, foreign(parseVBoxFile))
+		win.Close()
+	})
+	vmxBtn := widget.NewButton("VMware (.vmx)", func() {
+		showDiskImportWindow("VMware 가져오기", "VMware 가상머신", []string{"vmx"}, diskImportFields{format: true, chain: true}, configDir, parent, jobs, onDone, foreign(parseVMXFile))
+		win.Close()
+	})
+	closeBtn := widget.NewButton("닫기", func() { win.Close() })
+	win.SetContent(container.NewVBox(
+		widget.NewLabel("가져올 형식을 선택하세요."),
+		bundleBtn, cmdBtn, libvirtBtn, ovfBtn, vboxBtn, vmxBtn, closeBtn,
+	))
+	win.Resize(fyne.NewSize(300, 280))
+	win.CenterOnScreen()
+	win.Show()
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+// 작은 데이터 CD(cloud-init seed 등)를 만드는 ISO9660 작성기입니다.
+// 루트 폴더 하나에 파일만 두며, 긴 소문자 파일 이름은 Joliet 보조 볼륨 기술자로 기록합니다.
+// 날짜는 모두 "지정 안 함"(0)으로 써서 같은 내용이면 같은 이미지가 나옵니다.
+//
+//	섹터 0-15   시스템 영역
+//	16, 17, 18  기본 볼륨 기술자, Joliet 보조 볼륨 기술자, 종료 기술자
+//	19-22       경로 테이블 (기본 L/M, Joliet L/M)
+//	23, 24      루트 폴더 (기본, Joliet)
+//	25-         파일 데이터
+const isoSectorSize = 2048
+
+const (
+	isoPVDSector        = 16
+	isoJolietSector     = 17
+	isoTermSector       = 18
+	isoPathTableSector  = 19
+	isoRootSector       = 23
+	isoJolietRootSector = 24
+	isoDataSector       = 25
+)
+
+type isoFile struct {
+	name string
+	data []byte
+}
+
+func isoBoth16(b []byte, v uint16) {
+	binary.LittleEndian.PutUint16(b, v)
+	binary.BigEndian.PutUint16(b[2:], v)
+}
+
+func isoBoth32(b []byte, v uint32) {
+	binary.LittleEndian.PutUint32(b, v)
+	binary.BigEndian.PutUint32(b[4:], v)
+}
+
+func isoSectors(size int) uint32 {
+	return uint32((size + isoSectorSize - 1) / isoSectorSize)
+}
+
+// 기본 볼륨의 파일 이름: 대문자, 숫자, '_'만 쓰고 "이름.확장자;1" 형식
+func isoPrimaryName(name string) []byte {
+	base, ext, _ := strings.Cut(strings.ToUpper(name), ".")
+	clean := func(s string, max int) string {
+		s = strings.Map(func(r rune) rune {
+			if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
+				return r
+			}
+			return '_'
+		}, s)
+		if len(s) > max {
+			s = s[:max]
+		}
+		return s
+	}
+	return []byte(clean(base, 24) + "." + clean(ext, 5) + ";1")
+}
+
+// Joliet 이름은 UCS-2 빅 엔디언
+func isoUCS2(s string) []byte {
+	units := utf16.Encode([]rune(s))
+	b := make([]byte, len(units)*2)
+	for i, u := range units {
+		binary.BigEndian.PutUint16(b[i*2:], u)
+	}
+	return b
+}
+
+// 폴더 레코드 (날짜는 0)
+func isoDirRecord(extent, size uint32, dir bool, name []byte) []byte {
+	n := 33 + len(name)
+	if n%2 == 1 {
+		n++
+	}
+	r := make([]byte, n)
+	r[0] = byte(n)
+	isoBoth32(r[2:], extent)
+	isoBoth32(r[10:], size)
+	if dir {
+		r[25] = 2
+	}
+	isoBoth16(r[28:], 1)
+	r[32] = byte(len(name))
+	copy(r[33:], name)
+	return r
+}
+
+// 문자열 필드를 공백으로 채움 (Joliet은 UCS-2 공백)
+func isoPadField(b []byte, value []byte, joliet bool) {
+	for i := 0; i < len(b); i++ {
+		if joliet && i%2 == 0 {
+			b[i] = 0
+		} else {
+			b[i] = ' '
+		}
+	}
+	copy(b, value)
+}
+
+func isoVolumeDescriptor(d []byte, joliet bool, volumeID string, total uint32, root []byte, lPath, mPath uint32) {
+	d[0] = 1
+	if joliet {
+		d[0] = 2
+	}
+	copy(d[1:], "CD001")
+	d[6] = 1
+	id := []byte(strings.ToUpper(volumeID))
+	if joliet {
+		id = isoUCS2(volumeID)
+	}
+	isoPadField(d[8:40], nil, joliet)
+	isoPadField(d[40:72], id, joliet)
+	isoBoth32(d[80:], total)
+	if joliet {
+		copy(d[88:], "%/E") // UCS-2 Level 3
+	}
+	isoBoth16(d[120:], 1)
+	isoBoth16(d[124:], 1)
+	isoBoth16(d[128:], isoSectorSize)
+	isoBoth32(d[132:], 10)
+	binary.LittleEndian.PutUint32(d[140:], lPath)
+	binary.BigEndian.PutUint32(d[148:], mPath)
+	copy(d[156:190], root)
+	isoPadField(d[190:813], nil, joliet)
+	// 생성, 수정, 만료, 유효 날짜: "지정 안 함"
+	for off := 813; off < 881; off += 17 {
+		copy(d[off:], "0000000000000000")
+		d[off+16] = 0
+	}
+	d[881] = 1
+}
+
+// 루트 폴더 하나만 있는 경로 테이블 항목 (10바이트)
+func isoPathTable(b []byte, root uint32, bigEndian bool) {
+	b[0] = 1
+	if bigEndian {
+		binary.BigEndian.PutUint32(b[2:], root)
+		binary.BigEndian.PutUint16(b[6:], 1)
+	} else {
+		binary.LittleEndian.PutUint32(b[2:], root)
+		binary.LittleEndian.PutUint16(b[6:], 1)
+	}
+}
+
+// buildISO9660은 files를 루트 폴더에 담은 ISO 이미지를 만듭니다.
+func buildISO9660(volumeID string, files []isoFile) ([]byte, error) {
+	next := uint32(isoDataSector)
+	extents := make([]uint32, len(files))
+	for i, f := range files {
+		extents[i] = next
+		next += isoSectors(len(f.data))
+	}
+	total := next
+	img := make([]byte, int(total)*isoSectorSize)
+	sector := func(n uint32) []byte {
+		return img[int(n)*isoSectorSize : int(n+1)*isoSectorSize]
+	}
+
+	// 루트 폴더: ".", ".." 다음에 이름 순서로
+	writeRoot := func(n uint32, joliet bool) error {
+		type entry struct {
+			name []byte
+			i    int
+		}
+		var entries []entry
+		for i, f := range files {
+			name := isoPrimaryName(f.name)
+			if joliet {
+				name = isoUCS2(f.name)
+			}
+			entries = append(entries, entry{name, i})
+		}
+		sort.Slice(entries, func(a, b int) bool { return string(entries[a].name) < string(entries[b].name) })
+		var dir []byte
+		dir = append(dir, isoDirRecord(n, isoSectorSize, true, []byte{0})...)
+		dir = append(dir, isoDirRecord(n, isoSectorSize, true, []byte{1})...)
+		for _, e := range entries {
+			dir = append(dir, isoDirRecord(extents[e.i], uint32(len(files[e.i].data)), false, e.name)...)
+		}
+		if len(dir) > isoSectorSize {
+			return errors.New("ISO 루트 폴더에 파일이 너무 많습니다.")
+		}
+		copy(sector(n), dir)
+		return nil
+	}
+	if err := writeRoot(isoRootSector, false); err != nil {
+		return nil, err
+	}
+	if err := writeRoot(isoJolietRootSector, true); err != nil {
+		return nil, err
+	}
+
+	isoVolumeDescriptor(sector(isoPVDSector), false, volumeID, total,
+		isoDirRecord(isoRootSector, isoSectorSize, true, []byte{0}), isoPathTableSector, isoPathTableSector+1)
+	isoVolumeDescriptor(sector(isoJolietSector), true, volumeID, total,
+		isoDirRecord(isoJolietRootSector, isoSectorSize, true, []byte{0}), isoPathTableSector+2, isoPathTableSector+3)
+	term := sector(isoTermSector)
+	term[0] = 255
+	copy(term[1:], "CD001")
+	term[6] = 1
+
+	isoPathTable(sector(isoPathTableSector), isoRootSector, false)
+	isoPathTable(sector(isoPathTableSector+1), isoRootSector, true)
+	isoPathTable(sector(isoPathTableSector+2), isoJolietRootSector, false)
+	isoPathTable(sector(isoPathTableSector+3), isoJolietRootSector, true)
+
+	for i, f := range files {
+		copy(img[int(extents[i])*isoSectorSize:], f.data)
+	}
+	return img, nil
+}
+
+// writeISO9660은 ISO 이미지를 path에 씁니다. 내용이 같으면 파일을 건드리지 않고 false를 반환합니다.
+func writeISO9660(path, volumeID string, files []isoFile) (bool, error) {
+	img, err := buildISO9660(volumeID, files)
+	if err != nil {
+		return false, err
+	}
+	if old, err := os.ReadFile(path); err == nil && string(old) == string(img) {
+		return false, nil
+	}
+	return true, os.WriteFile(path, img, 0644)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+// readISORoot는 볼륨 기술자의 루트 폴더를 읽어 이름 → 내용 맵을 돌려줍니다.
+func readISORoot(t *testing.T, img []byte, descriptor int) map[string]string {
+	t.Helper()
+	d := img[descriptor*isoSectorSize:]
+	joliet := d[0] == 2
+	root := binary.LittleEndian.Uint32(d[156+2:])
+	if binary.BigEndian.Uint32(d[156+6:]) != root {
+		t.Fatalf("기술자 %d: 루트 위치의 두 바이트 순서 값이 다릅니다", descriptor)
+	}
+	dir := img[int(root)*isoSectorSize : int(root+1)*isoSectorSize]
+	files := make(map[string]string)
+	for off := 0; off < len(dir) && dir[off] != 0; off += int(dir[off]) {
+		r := dir[off:]
+		name := r[33 : 33+int(r[32])]
+		if r[25]&2 != 0 {
+			continue // ".", ".."
+		}
+		extent := binary.LittleEndian.Uint32(r[2:])
+		size := binary.LittleEndian.Uint32(r[10:])
+		if binary.BigEndian.Uint32(r[6:]) != extent || binary.BigEndian.Uint32(r[14:]) != size {
+			t.Fatalf("%q: 두 바이트 순서 값이 다릅니다", name)
+		}
+		key := string(name)
+		if joliet {
+			units := make([]uint16, len(name)/2)
+			for i := range units {
+				units[i] = binary.BigEndian.Uint16(name[i*2:])
+			}
+			key = string(utf16.Decode(units))
+		}
+		files[key] = string(img[int(extent)*isoSectorSize : int(extent)*isoSectorSize+int(size)])
+	}
+	return files
+}
+
+func TestBuildISO9660(t *testing.T) {
+	big := strings.Repeat("x", 3*isoSectorSize+1)
+	files := []isoFile{
+		{name: "user-data", data: []byte("#cloud-config\n")},
+		{name: "meta-data", data: []byte("instance-id: iid-1\n")},
+		{name: "network-config", data: []byte(big)},
+		{name: "empty", data: nil},
+	}
+	img, err := buildISO9660("cidata", files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 데이터: user-data, meta-data 한 섹터씩, network-config 네 섹터, 빈 파일은 0섹터
+	if len(img) != (isoDataSector+6)*isoSectorSize {
+		t.Errorf("크기 %d", len(img))
+	}
+	for sector, typ := range map[int]byte{isoPVDSector: 1, isoJolietSector: 2, isoTermSector: 255} {
+		if d := img[sector*isoSectorSize:]; d[0] != typ || string(d[1:6]) != "CD001" {
+			t.Errorf("섹터 %d: 종류 %d, %q", sector, d[0], d[1:6])
+		}
+	}
+	// cloud-init은 볼륨 레이블 "cidata"로 seed를 찾음 (기본 볼륨은 대문자)
+	if label := strings.TrimSpace(string(img[isoPVDSector*isoSectorSize+40 : isoPVDSector*isoSectorSize+72])); label != "CIDATA" {
+		t.Errorf("레이블 %q", label)
+	}
+	if total := binary.LittleEndian.Uint32(img[isoPVDSector*isoSectorSize+80:]); int(total)*isoSectorSize != len(img) {
+		t.Errorf("볼륨 크기 %d섹터", total)
+	}
+
+	joliet := readISORoot(t, img, isoJolietSector)
+	primary := readISORoot(t, img, isoPVDSector)
+	for _, f := range files {
+		if joliet[f.name] != string(f.data) {
+			t.Errorf("Joliet %s: %d바이트", f.name, len(joliet[f.name]))
+		}
+	}
+	if len(primary) != len(files) || primary["USER_DATA.;1"] != "#cloud-config\n" || primary["NETWORK_CONFIG.;1"] != big {
+		t.Errorf("기본 볼륨: %q", primary)
+	}
+
+	// 날짜를 쓰지 않으므로 같은 내용이면 같은 이미지
+	again, _ := buildISO9660("cidata", files)
+	if !bytes.Equal(img, again) {
+		t.Error("같은 내용인데 이미지가 다릅니다")
+	}
+
+	path := filepath.Join(t.TempDir(), "seed.iso")
+	if changed, err := writeISO9660(path, "cidata", files); err != nil || !changed {
+		t.Errorf("처음 쓰기: %v, %v", changed, err)
+	}
+	if changed, err := writeISO9660(path, "cidata", files); err != nil || changed {
+		t.Errorf("같은 내용으로 다시 쓰기: %v, %v", changed, err)
+	}
+
+	var many []isoFile
+	for i := 0; i < 100; i++ {
+		many = append(many, isoFile{name: strings.Repeat("f", 20) + string(rune('a'+i%26)) + strings.Repeat("g", i/26)})
+	}
+	if _, err := buildISO9660("x", many); err == nil {
+		t.Error("루트 폴더가 한 섹터를 넘는데 오류가 없습니다")
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+)
+
+// jobPanel은 메인 창에 백그라운드 작업의 진행률과 취소 버튼을 표시합니다.
+type jobPanel struct {
+	box *fyne.Container
+}
+
+func newJobPanel() *jobPanel {
+	return &jobPanel{box: container.NewVBox()}
+}
+
+// start는 run을 별도 고루틴에서 실행합니다. run은 report로 0~1 사이 진행률을 알리고,
+// 취소 버튼을 누르면 ctx가 취소됩니다. 작업이 끝나면 onDone이 호출됩니다.
+func (p *jobPanel) start(title string, run func(ctx context.Context, report func(float64)) error, onDone func(error)) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	label := widget.NewLabel(title)
+	label.Wrapping = fyne.TextWrapWord
+	progress := widget.NewProgressBar()
+	cancelBtn := widget.NewButton("취소", cancel)
+	row := container.NewVBox(label, container.NewBorder(nil, nil, nil, cancelBtn, progress))
+
+	p.box.Add(row)
+	p.box.Refresh()
+
+	go func() {
+		err := run(ctx, progress.SetValue)
+		cancel()
+		if err == nil {
+			progress.SetValue(1)
+		} else if errors.Is(err, context.Canceled) {
+			err = errJobCanceled
+		}
+		p.box.Remove(row)
+		p.box.Refresh()
+		if onDone != nil {
+			onDone(err)
+		}
+	}()
+}
+
+var errJobCanceled = errors.New("작업이 취소되었습니다.")
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// validateKernelBoot는 직접 커널 부팅 설정(-kernel/-initrd/-append/-dtb)을 확인합니다.
+func validateKernelBoot(config VMConfig) error {
+	if config.Kernel == "" {
+		if config.Initrd != "" || config.KernelAppend != "" || config.DTB != "" {
+			return errors.New("initrd, 커널 명령줄, DTB를 쓰려면 커널 이미지를 지정해야 합니다.")
+		}
+		return nil
+	}
+	files := []struct{ name, path string }{
+		{"커널 이미지", config.Kernel},
+		{"initrd", config.Initrd},
+		{"DTB", config.DTB},
+	}
+	for _, f := range files {
+		if f.path == "" {
+			continue
+		}
+		if _, err := os.Stat(f.path); err != nil {
+			return fmt.Errorf("%s 파일을 찾을 수 없습니다: %s", f.name, f.path)
+		}
+	}
+	if binary, _ := qemuCPU(config.CPUModel); config.DTB != "" && binary == "qemu-system-x86_64" {
+		return errors.New("x86 머신에서는 DTB를 사용할 수 없습니다.")
+	}
+	return nil
+}
+
+func kernelBootArgs(config VMConfig) []string {
+	if config.Kernel == "" {
+		return nil
+	}
+	args := []string{"-kernel", config.Kernel}
+	if config.Initrd != "" {
+		args = append(args, "-initrd", config.Initrd)
+	}
+	if config.KernelAppend != "" {
+		args = append(args, "-append", config.KernelAppend)
+	}
+	if config.DTB != "" {
+		args = append(args, "-dtb", config.DTB)
+	}
+	return args
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+)
+
+// runningVM은 이 앱이 시작한 QEMU 프로세스입니다.
+type runningVM struct {
+	cmd    *exec.Cmd
+	config VMConfig // 시작할 때의 설정
+
+	mu        sync.Mutex
+	qmp       *qmpClient                 // QEMU가 QMP 소켓을 열기 전에는 nil
+	throttles map[int]map[string]string  // 실행 중에 바꾼 디스크 I/O 제한 (liveThrottle 참고)
+	onTray    func(id string, open bool) // 트레이 상태가 바뀌면 호출 (CD/DVD 창)
+}
+
+// handleEvent는 QMP 읽기 고루틴에서 이벤트를 받습니다.
+// CD/DVD 창이 열려 있지 않을 때의 트레이 변화는 창을 열 때 query-block으로 다시 읽습니다.
+func (vm *runningVM) handleEvent(ev qmpEvent) {
+	if ev.Event != "DEVICE_TRAY_MOVED" {
+		return
+	}
+	var data struct {
+		ID       string `json:"id"`
+		TrayOpen bool   `json:"tray-open"`
+	}
+	if json.Unmarshal(ev.Data, &data) != nil {
+		return
+	}
+	vm.mu.Lock()
+	onTray := vm.onTray
+	vm.mu.Unlock()
+	if onTray != nil {
+		onTray(data.ID, data.TrayOpen)
+	}
+}
+
+func (vm *runningVM) setTrayListener(onTray func(id string, open bool)) {
+	vm.mu.Lock()
+	vm.onTray = onTray
+	vm.mu.Unlock()
+}
+
+// 실행 중인 가상머신 (이름 → 프로세스)
+var runningVMs = struct {
+	sync.Mutex
+	vms map[string]*runningVM
+}{vms: make(map[string]*runningVM)}
+
+// vmRunning은 이 앱이 시작한 가상머신이 아직 실행 중인지 확인합니다.
+func vmRunning(name string) bool {
+	return lookupRunningVM(name) != nil
+}
+
+func lookupRunningVM(name string) *runningVM {
+	runningVMs.Lock()
+	defer runningVMs.Unlock()
+	return runningVMs.vms[name]
+}
+
+// vmQMP는 실행 중인 가상머신의 QMP 클라이언트를 반환합니다.
+func vmQMP(name string) (*qmpClient, error) {
+	vm := lookupRunningVM(name)
+	if vm == nil {
+		return nil, fmt.Errorf("%s 가상머신이 실행 중이 아닙니다.", name)
+	}
+	vm.mu.Lock()
+	defer vm.mu.Unlock()
+	if vm.qmp == nil {
+		return nil, errors.New("QMP에 아직 연결되지 않았습니다. 잠시 후 다시 시도하십시오.")
+	}
+	return vm.qmp, nil
+}
+
+// connectQMP는 QEMU가 소켓을 열 때까지 잠시 재시도하며 QMP에 연결하고, 연결되었는지 반환합니다.
+func (vm *runningVM) connectQMP(socket string, exited <-chan struct{}) bool {
+	for i := 0; i < 50; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		q, err := dialQMP(ctx, socket, vm.handleEvent)
+		cancel()
+		if err == nil {
+			vm.mu.Lock()
+			vm.qmp = q
+			vm.mu.Unlock()
+			return true
+		}
+		select {
+		case <-exited:
+			return false
+		case <-time.After(200 * time.Millisecond):
+		}
+	}
+	return false
+}
+
+// startVM은 저장된 설정으로 QEMU를 시작합니다. 암호화 디스크의 암호 파일은 시작 직전에 만들고,
+// QMP에 연결되면(QEMU가 명령줄의 secret 객체를 모두 읽은 뒤) 바로 지웁니다.
+// 연결되지 않으면 QEMU가 끝날 때 지웁니다. TPM을 쓰면 swtpm을 먼저 실행하고 QEMU가 끝나면 끝냅니다.
+// QEMU가 끝나면 onExit가 호출됩니다 (정상 종료면 nil).
+func startVM(configDir, name string, passphrases map[string]string, onExit func(error)) error {
+	if vmRunning(name) {
+		return fmt.Errorf("%s 가상머신이 이미 실행 중입니다.", name)
+	}
+	// 목록의 설정은 오래되었을 수 있으므로 (1회 부팅 장치 등) 저장된 설정으로 시작
+	config, err := loadVMConfig(configDir, name)
+	if err != nil {
+		return err
+	}
+	binary, args, err := buildQEMUArgs(config)
+	if err != nil {
+		return err
+	}
+	// 실행 중 제어(스냅샷, I/O 제한, CD 교체)용 QMP 소켓
+	socket := qmpSocketPath(config.Name)
+	if err := os.MkdirAll(filepath.Dir(socket), os.ModePerm); err != nil {
+		return err
+	}
+	os.Remove(socket)
+	args = append(args, "-qmp", "unix:"+escapeOptionValue(socket)+",server=on,wait=off")
+
+	// TPM은 QEMU가 연결할 swtpm을 먼저 실행
+	stopTPM := func() {}
+	if config.TPM != "" {
+		if stopTPM, err = startSWTPM(config.TPM); err != nil {
+			return err
+		}
+	}
+	cleanup, err := writeDiskSecrets(config, passphrases)
+	if err != nil {
+		stopTPM()
+		return err
+	}
+	cmd := exec.Command(binary, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	// 관리형이면 QEMU의 오류 출력을 logs\qemu.log에도 남김 (이전 실행의 로그는 덮어씀)
+	closeLog := func() {}
+	if logPath := vmLogPath(configDir, config); logPath != "" {
+		if err := os.MkdirAll(filepath.Dir(logPath), os.ModePerm); err != nil {
+			cleanup()
+			stopTPM()
+			return err
+		}
+		logFile, err := os.Create(logPath)
+		if err != nil {
+			cleanup()
+			stopTPM()
+			return err
+		}
+		cmd.Stderr = io.MultiWriter(&stderr, logFile)
+		closeLog = func() { logFile.Close() }
+	}
+	if err := cmd.Start(); err != nil {
+		closeLog()
+		cleanup()
+		stopTPM()
+		return err
+	}
+
+	vm := &runningVM{cmd: cmd, config: config}
+	runningVMs.Lock()
+	runningVMs.vms[config.Name] = vm
+	runningVMs.Unlock()
+
+	exited := make(chan struct{})
+	go func() {
+		if vm.connectQMP(socket, exited) {
+			cleanup()
+		}
+	}()
+	go func() {
+		err := cmd.Wait()
+		close(exited)
+		closeLog()
+		cleanup()
+		stopTPM()
+		vm.mu.Lock()
+		if vm.qmp != nil {
+			vm.qmp.Close()
+		}
+		vm.mu.Unlock()
+		os.Remove(socket)
+		runningVMs.Lock()
+		delete(runningVMs.vms, config.Name)
+		runningVMs.Unlock()
+		if err != nil {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				err = fmt.Errorf("%s: %s", config.Name, msg)
+			}
+		}
+		if onExit != nil {
+			onExit(err)
+		}
+	}()
+
+	// 1회 부팅 장치는 이번 실행에만 쓰고 설정에서 지움
+	if config.BootOnce != "" {
+		saved := config
+		saved.BootOnce = ""
+		if err := saveVMConfig(configDir, saved); err != nil {
+			return fmt.Errorf("%s 가상머신은 시작되었지만 1회 부팅 설정을 지우지 못했습니다: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// ShowStartVM은 자격 증명 관리자에 없는 디스크 암호를 입력받은 뒤 가상머신을 시작합니다.
+// QEMU가 비정상 종료되면 오류를 보여 주고 디스크를 자동으로 검사합니다.
+func ShowStartVM(configDir, name string, parent fyne.Window, jobs *jobPanel) {
+	config, err := loadVMConfig(configDir, name)
+	if err != nil {
+		dialog.ShowError(err, parent)
+		return
+	}
+	askMissingPassphrases(parseDiskConfigs(config.Disk), parent, func(passphrases map[string]string) {
+		err := startVM(configDir, name, passphrases, func(err error) {
+			if err == nil {
+				return
+			}
+			dialog.ShowError(err, parent)
+			autoCheckDisks(config, passphrases, parent, jobs)
+		})
+		if err != nil {
+			dialog.ShowError(err, parent)
+		}
+	})
+}
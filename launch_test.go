@@ -0,0 +1,134 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 가짜 QEMU: 테스트 실행 파일을 qemu-system-x86_64라는 이름으로 복사해 실행하면
+// 받은 인자를 GOQEMU_FAKE_QEMU 파일에 적고, 오류 출력에 한 줄을 쓰고 끝납니다.
+func TestMain(m *testing.M) {
+	if out := os.Getenv("GOQEMU_FAKE_QEMU"); out != "" {
+		os.WriteFile(out, []byte(strings.Join(os.Args[1:], "\n")), 0644)
+		os.Stderr.WriteString("fake qemu\n")
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// installFakeQEMU는 가짜 qemu-system-x86_64만 있는 폴더를 PATH로 설정합니다.
+func installFakeQEMU(t *testing.T) {
+	t.Helper()
+	exe, err := os.Executable()
+	if err != nil {
+		t.Skip("테스트 실행 파일을 찾을 수 없습니다:", err)
+	}
+	name := "qemu-system-x86_64"
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	dir := t.TempDir()
+	src, err := os.Open(exe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(filepath.Join(dir, name), os.O_WRONLY|os.O_CREATE, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		t.Fatal(err)
+	}
+	if err := dst.Close(); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+}
+
+// runFakeVM은 가상머신을 가짜 QEMU로 시작하고 끝날 때까지 기다려 QEMU가 받은 인자를 반환합니다.
+func runFakeVM(t *testing.T, configDir, name string) []string {
+	t.Helper()
+	out := filepath.Join(t.TempDir(), "args")
+	t.Setenv("GOQEMU_FAKE_QEMU", out)
+	exited := make(chan error, 1)
+	if err := startVM(configDir, name, nil, func(err error) { exited <- err }); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-exited:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(30 * time.Second):
+		t.Fatal("가짜 QEMU가 끝나지 않았습니다")
+	}
+	return strings.Split(readTestFile(t, out), "\n")
+}
+
+// bootIndexOf는 인자에서 id=<device>인 -device의 bootindex 값입니다.
+func bootIndexOf(args []string, device string) string {
+	for _, arg := range args {
+		if !strings.Contains(arg, ",id="+device+",") && !strings.HasSuffix(arg, ",id="+device) {
+			continue
+		}
+		for _, opt := range strings.Split(arg, ",") {
+			if value, ok := strings.CutPrefix(opt, "bootindex="); ok {
+				return value
+			}
+		}
+	}
+	return ""
+}
+
+func TestStartVMBootOnce(t *testing.T) {
+	installFakeQEMU(t)
+	configDir := t.TempDir()
+	config := VMConfig{
+		Name: "once", Machine: "q35", Network: "none",
+		Disk:      formatDiskConfigs([]diskConfig{{Type: "RAW", Path: filepath.Join(configDir, "os.img"), Bus: "virtio-blk"}}),
+		CDROM:     formatCDROMConfigs([]cdromConfig{{Path: filepath.Join(configDir, "install.iso")}}),
+		BootOrder: "disk0,cd0",
+		BootOnce:  "cd0",
+	}
+	if err := saveVMConfig(configDir, config); err != nil {
+		t.Fatal(err)
+	}
+
+	// 첫 실행은 CD로 먼저 부팅하고, 설정에서 1회 부팅 장치를 지움
+	args := runFakeVM(t, configDir, config.Name)
+	if cd, disk := bootIndexOf(args, "cd0-dev"), bootIndexOf(args, "disk0-dev"); cd != "1" || disk != "2" {
+		t.Errorf("첫 실행: cd0 bootindex=%q, disk0 bootindex=%q\n%q", cd, disk, args)
+	}
+	saved, err := loadVMConfig(configDir, config.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.BootOnce != "" || saved.BootOrder != config.BootOrder {
+		t.Errorf("저장된 설정: BootOnce = %q, BootOrder = %q", saved.BootOnce, saved.BootOrder)
+	}
+
+	// 두 번째 실행은 평소 순서
+	args = runFakeVM(t, configDir, config.Name)
+	if cd, disk := bootIndexOf(args, "cd0-dev"), bootIndexOf(args, "disk0-dev"); cd != "2" || disk != "1" {
+		t.Errorf("두 번째 실행: cd0 bootindex=%q, disk0 bootindex=%q\n%q", cd, disk, args)
+	}
+}
+
+func TestStartVMLog(t *testing.T) {
+	installFakeQEMU(t)
+	configDir := t.TempDir()
+	config := VMConfig{Name: "logged", Machine: "q35", Network: "none", Managed: true}
+	if err := saveVMConfig(configDir, config); err != nil {
+		t.Fatal(err)
+	}
+	runFakeVM(t, configDir, config.Name)
+	if got := readTestFile(t, vmLogPath(configDir, config)); got != "fake qemu\n" {
+		t.Errorf("qemu.log = %q", got)
+	}
+}
@@ -0,0 +1,833 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// libvirt 도메인 XML 중 가상머신 설정과 대응되는 부분
+type libvirtDomain struct {
+	XMLName  xml.Name         `xml:"domain"`
+	Type     string           `xml:"type,attr"`
+	Name     string           `xml:"name"`
+	UUID     string           `xml:"uuid,omitempty"`
+	Memory   libvirtMemory    `xml:"memory"`
+	VCPU     int              `xml:"vcpu"`
+	OS       libvirtOS        `xml:"os"`
+	Features *libvirtFeatures `xml:"features"`
+	CPU      *libvirtCPU      `xml:"cpu"`
+	Devices  libvirtDevices   `xml:"devices"`
+
+	// <qemu:commandline>의 인자 (추가 인자)
+	Commandline *libvirtCommandline `xml:"http://libvirt.org/schemas/domain/qemu/1.0 commandline"`
+
+	Other []libvirtElement `xml:",any"`
+}
+
+type libvirtFeatures struct {
+	ACPI *struct{} `xml:"acpi"`
+	APIC *struct{} `xml:"apic"`
+}
+
+type libvirtCommandline struct {
+	Args []libvirtArg `xml:"http://libvirt.org/schemas/domain/qemu/1.0 arg"`
+}
+
+type libvirtMemory struct {
+	Unit  string `xml:"unit,attr,omitempty"`
+	Value int64  `xml:",chardata"`
+}
+
+type libvirtOS struct {
+	Type struct {
+		Arch    string `xml:"arch,attr,omitempty"`
+		Machine string `xml:"machine,attr,omitempty"`
+		Value   string `xml:",chardata"`
+	} `xml:"type"`
+	Loader  *libvirtPath `xml:"loader"`
+	NVRAM   *libvirtPath `xml:"nvram"`
+	Kernel  string       `xml:"kernel,omitempty"`
+	Initrd  string       `xml:"initrd,omitempty"`
+	Cmdline string       `xml:"cmdline,omitempty"`
+	DTB     string       `xml:"dtb,omitempty"`
+	Boot    []struct {
+		Dev string `xml:"dev,attr"`
+	} `xml:"boot"`
+	BootMenu *libvirtBootMenu `xml:"bootmenu"`
+}
+
+type libvirtPath struct {
+	ReadOnly string `xml:"readonly,attr,omitempty"`
+	Type     string `xml:"type,attr,omitempty"`
+	Path     string `xml:",chardata"`
+}
+
+type libvirtCPU struct {
+	Mode     string              `xml:"mode,attr,omitempty"`
+	Match    string              `xml:"match,attr,omitempty"`
+	Model    *libvirtCPUModel    `xml:"model"`
+	Topology *libvirtTopology    `xml:"topology"`
+	Features []libvirtCPUFeature `xml:"feature"`
+}
+
+type libvirtDevices struct {
+	Disks       []libvirtDisk       `xml:"disk"`
+	Controllers []libvirtController `xml:"controller"`
+	Interfaces  []libvirtInterface  `xml:"interface"`
+	Graphics    []libvirtGraphics   `xml:"graphics"`
+	Videos      []libvirtVideo      `xml:"video"`
+	Channels    []libvirtChannel    `xml:"channel"`
+	Other       []libvirtElement    `xml:",any"`
+}
+
+type libvirtDisk struct {
+	Type   string         `xml:"type,attr"`
+	Device string         `xml:"device,attr"`
+	Driver *libvirtDriver `xml:"driver"`
+	Source *libvirtSource `xml:"source"`
+	Target struct {
+		Dev string `xml:"dev,attr"`
+		Bus string `xml:"bus,attr"`
+	} `xml:"target"`
+	ReadOnly   *struct{}          `xml:"readonly"`
+	IOTune     *libvirtIOTune     `xml:"iotune"`
+	Encryption *libvirtEncryption `xml:"encryption"`
+	Boot       *libvirtBoot       `xml:"boot"`
+}
+
+type libvirtController struct {
+	Type  string `xml:"type,attr"`
+	Model string `xml:"model,attr,omitempty"`
+}
+
+type libvirtInterface struct {
+	Type  string        `xml:"type,attr"`
+	MAC   *libvirtMAC   `xml:"mac"`
+	Model *libvirtModel `xml:"model"`
+	Boot  *libvirtBoot  `xml:"boot"`
+}
+
+type libvirtGraphics struct {
+	Type     string     `xml:"type,attr"`
+	AutoPort string     `xml:"autoport,attr,omitempty"`
+	GL       *libvirtGL `xml:"gl"`
+}
+
+type libvirtVideo struct {
+	Model struct {
+		Type         string               `xml:"type,attr"`
+		Acceleration *libvirtAcceleration `xml:"acceleration"`
+	} `xml:"model"`
+}
+
+// 게스트 에이전트 채널 (<target type="virtio" name="org.qemu.guest_agent.0"/>)
+type libvirtChannel struct {
+	Type   string `xml:"type,attr"`
+	Target struct {
+		Type string `xml:"type,attr"`
+		Name string `xml:"name,attr,omitempty"`
+	} `xml:"target"`
+}
+
+// libvirt가 게스트 에이전트 채널에 쓰는 이름 (QEMU의 virtserialport 이름과 같음)
+const libvirtGuestAgentChannel = "org.qemu.guest_agent.0"
+
+type libvirtBoot struct {
+	Order int `xml:"order,attr"`
+}
+
+type libvirtBootMenu struct {
+	Enable  string `xml:"enable,attr"`
+	Timeout string `xml:"timeout,attr,omitempty"`
+}
+
+type libvirtCPUModel struct {
+	Fallback string `xml:"fallback,attr,omitempty"`
+	Value    string `xml:",chardata"`
+}
+
+type libvirtTopology struct {
+	Sockets int `xml:"sockets,attr"`
+	Cores   int `xml:"cores,attr"`
+	Threads int `xml:"threads,attr"`
+}
+
+type libvirtCPUFeature struct {
+	Policy string `xml:"policy,attr"`
+	Name   string `xml:"name,attr"`
+}
+
+type libvirtDriver struct {
+	Name         string `xml:"name,attr"`
+	Type         string `xml:"type,attr"`
+	Cache        string `xml:"cache,attr,omitempty"`
+	IO           string `xml:"io,attr,omitempty"`
+	Discard      string `xml:"discard,attr,omitempty"`
+	DetectZeroes string `xml:"detect_zeroes,attr,omitempty"`
+}
+
+type libvirtSource struct {
+	File string `xml:"file,attr,omitempty"`
+	Dev  string `xml:"dev,attr,omitempty"`
+}
+
+type libvirtIOTune struct {
+	Items []libvirtElement `xml:",any"`
+}
+
+type libvirtEncryption struct {
+	Format string `xml:"format,attr"`
+}
+
+type libvirtMAC struct {
+	Address string `xml:"address,attr"`
+}
+
+type libvirtModel struct {
+	Type string `xml:"type,attr"`
+}
+
+type libvirtGL struct {
+	Enable string `xml:"enable,attr"`
+}
+
+type libvirtAcceleration struct {
+	Accel3D string `xml:"accel3d,attr"`
+}
+
+type libvirtArg struct {
+	Value string `xml:"value,attr"`
+}
+
+// 이름과 값만 보는 임의의 요소 (처리하지 않는 요소 목록, iotune 항목 등)
+type libvirtElement struct {
+	XMLName xml.Name
+	Value   string `xml:",chardata"`
+}
+
+// throttleLimitKeys 이름 → libvirt iotune 요소 이름
+var libvirtIOTuneNames = map[string]string{
+	"iops-total": "total_iops_sec", "iops-read": "read_iops_sec", "iops-write": "write_iops_sec",
+	"iops-total-max": "total_iops_sec_max", "iops-read-max": "read_iops_sec_max", "iops-write-max": "write_iops_sec_max",
+	"bps-total": "total_bytes_sec", "bps-read": "read_bytes_sec", "bps-write": "write_bytes_sec",
+	"bps-total-max": "total_bytes_sec_max", "bps-read-max": "read_bytes_sec_max", "bps-write-max": "write_bytes_sec_max",
+}
+
+// libvirt 디스크 버스 → 디스크 버스 (CD/DVD도 같은 이름 사용)
+var libvirtDiskBus = map[string]string{
+	"virtio": "virtio-blk", "scsi": "virtio-scsi", "nvme": "nvme", "ide": "ide", "sata": "ahci",
+}
+
+// 경고 없이 무시하는 요소 (실행할 때 다시 만들어지거나 동작에 영향이 없음)
+var libvirtIgnoredElements = map[string]bool{
+	"metadata": true, "title": true, "description": true, "currentMemory": true, "resource": true,
+	"features": true, "clock": true, "on_poweroff": true, "on_reboot": true, "on_crash": true,
+	"pm": true, "seclabel": true, "emulator": true, "controller": true, "input": true,
+	"memballoon": true, "console": true, "serial": true, "channel": true, "audio": true,
+}
+
+// QEMU 실행 파일 → libvirt 아키텍처 이름
+var libvirtArch = map[string]string{
+	"qemu-system-x86_64": "x86_64", "qemu-system-aarch64": "aarch64",
+	"qemu-system-arm": "armv7l", "qemu-system-mips": "mips",
+}
+
+// libvirt 메모리 크기를 MB로 (unit이 없으면 KiB)
+func libvirtMemoryMB(m libvirtMemory) int64 {
+	switch strings.ToLower(m.Unit) {
+	case "b", "bytes":
+		return m.Value / (1024 * 1024)
+	case "m", "mib", "mb":
+		return m.Value
+	case "g", "gib", "gb":
+		return m.Value * 1024
+	case "t", "tib", "tb":
+		return m.Value * 1024 * 1024
+	}
+	return m.Value / 1024
+}
+
+// importLibvirtFile은 도메인 XML 파일을 읽어 가상머신 설정으로 바꿉니다.
+func importLibvirtFile(path string) (VMConfig, []string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return VMConfig{}, nil, err
+	}
+	var dom libvirtDomain
+	if err := xml.Unmarshal(data, &dom); err != nil {
+		return VMConfig{}, nil, fmt.Errorf("libvirt XML을 읽을 수 없습니다: %w", err)
+	}
+	config, notes := importLibvirtDomain(dom)
+	return config, notes, nil
+}
+
+// importLibvirtDomain은 도메인 XML을 설정으로 옮기고, 옮기지 못한 요소를 notes에 적습니다.
+func importLibvirtDomain(dom libvirtDomain) (VMConfig, []string) {
+	var config VMConfig
+	var notes []string
+	var extra []string
+
+	config.Name = dom.Name
+	config.UUID = dom.UUID
+	if mb := libvirtMemoryMB(dom.Memory); mb > 0 {
+		if mb%1024 == 0 {
+			config.RAM = strconv.FormatInt(mb/1024, 10) + "GB"
+		} else {
+			config.RAM = strconv.FormatInt(mb, 10) + "MB"
+		}
+	}
+	switch strings.ToLower(dom.Type) {
+	case "kvm":
+		config.CPUAccel = "true"
+		config.CPUAccelerator = "KVM"
+	case "hvf", "xen":
+		if a, ok := findOption(acceleratorOptions, dom.Type); ok {
+			config.CPUAccel = "true"
+			config.CPUAccelerator = a
+		}
+	}
+
+	// 머신 종류와 아키텍처
+	if machine := dom.OS.Type.Machine; machine != "" {
+		if m, ok := importMachineType(machine); ok {
+			config.Machine = m
+		} else {
+			notes = append(notes, "지원하지 않는 머신 종류 "+machine+" 대신 기본 머신을 사용합니다.")
+		}
+	}
+	arch := dom.OS.Type.Arch
+	x86 := arch == "" || arch == "x86_64" || arch == "i686"
+	if config.Machine == "" && x86 {
+		config.Machine = "pc"
+	}
+
+	// CPU
+	if dom.CPU != nil {
+		switch dom.CPU.Mode {
+		case "host-passthrough", "host-model", "maximum":
+			notes = append(notes, "CPU 모드 "+dom.CPU.Mode+"는 가져오지 않았습니다. CPU 모델을 직접 선택하세요.")
+		default:
+			if dom.CPU.Model != nil {
+				if option, ok := cpuModelOption(strings.TrimSpace(dom.CPU.Model.Value)); ok {
+					config.CPUModel = option
+				} else {
+					notes = append(notes, "지원하지 않는 CPU 모델 "+dom.CPU.Model.Value+"는 가져오지 않았습니다.")
+				}
+			}
+		}
+		if config.CPUModel != "" {
+			var features []string
+			for _, f := range dom.CPU.Features {
+				switch f.Policy {
+				case "require", "force":
+					features = append(features, "+"+f.Name)
+				case "disable", "forbid":
+					features = append(features, "-"+f.Name)
+				}
+			}
+			config.CPUFeatures = strings.Join(features, ",")
+		}
+		if t := dom.CPU.Topology; t != nil && t.Sockets > 0 && t.Cores > 0 && t.Threads > 0 {
+			config.CPUSockets = strconv.Itoa(t.Sockets)
+			config.CPUCores = strconv.Itoa(t.Cores)
+			config.CPUThreads = strconv.Itoa(t.Threads)
+		}
+	}
+	if config.CPUCores == "" && dom.VCPU > 0 {
+		config.CPUCores = strconv.Itoa(dom.VCPU)
+	}
+	if config.CPUModel == "" && !x86 {
+		notes = append(notes, arch+"용 CPU 모델을 알 수 없어 x86_64 가상머신으로 가져왔습니다.")
+	}
+
+	// UEFI 펌웨어는 이 컴퓨터의 QEMU에 포함된 EDK2로
+	// (변수 저장소는 <nvram> 파일이 이 컴퓨터에 있으면 그대로 쓰고, 없으면 새로 만듦)
+	if l := dom.OS.Loader; l != nil && l.Path != "" {
+		config.Firmware = "uefi"
+		note := "UEFI 펌웨어(" + filepath.Base(l.Path) + ") 대신 QEMU에 포함된 EDK2 펌웨어를 사용합니다."
+		if n := dom.OS.NVRAM; n != nil && n.Path != "" {
+			if _, err := os.Stat(n.Path); err == nil {
+				config.NVRAM = n.Path
+			}
+		}
+		if config.NVRAM == "" {
+			note += " UEFI 변수(부팅 항목)는 새로 만들어집니다."
+		}
+		notes = append(notes, note)
+	}
+
+	// 직접 커널 부팅
+	config.Kernel = dom.OS.Kernel
+	config.Initrd = dom.OS.Initrd
+	config.KernelAppend = dom.OS.Cmdline
+	config.DTB = dom.OS.DTB
+
+	// 디스크와 CD/DVD
+	var disks []diskConfig
+	var cdroms []cdromConfig
+	bootIndex := make(map[string]int)
+	for _, d := range dom.Devices.Disks {
+		path := ""
+		if d.Source != nil {
+			path = d.Source.File
+			if path == "" {
+				path = d.Source.Dev
+			}
+		}
+		bus, ok := libvirtDiskBus[d.Target.Bus]
+		if !ok {
+			notes = append(notes, fmt.Sprintf("%s 버스의 %s 장치(%s)는 가져오지 않았습니다.", d.Target.Bus, d.Device, d.Target.Dev))
+			continue
+		}
+		switch d.Device {
+		case "cdrom":
+			if bus != "ide" && bus != "ahci" && bus != "virtio-scsi" {
+				notes = append(notes, fmt.Sprintf("%s 버스의 CD/DVD 드라이브(%s)는 가져오지 않았습니다.", d.Target.Bus, d.Target.Dev))
+				continue
+			}
+			if d.Boot != nil {
+				bootIndex[fmt.Sprintf("cd%d", len(cdroms))] = d.Boot.Order
+			}
+			cdroms = append(cdroms, cdromConfig{Bus: bus, Path: path})
+		case "disk", "":
+			format := ""
+			if d.Driver != nil {
+				format = d.Driver.Type
+			}
+			diskType, ok := importDiskType(format, path)
+			if !ok || path == "" {
+				notes = append(notes, fmt.Sprintf("디스크 %s(%s 포맷)는 가져오지 않았습니다.", d.Target.Dev, format))
+				continue
+			}
+			disk := diskConfig{Type: diskType, Path: path, Bus: bus, Capacity: importDiskCapacity(path)}
+			if drv := d.Driver; drv != nil {
+				if cache, ok := findOption(diskCacheOptions, drv.Cache); ok && cache != "writeback" {
+					disk.Cache = cache
+				}
+				if aio, ok := findOption(diskAIOOptions, drv.IO); ok && aio != "threads" {
+					disk.AIO = aio
+				}
+				disk.Discard = drv.Discard == "unmap"
+				if dz, ok := findOption(diskDetectZeroesOptions, drv.DetectZeroes); ok && dz != "off" {
+					disk.DetectZeroes = dz
+				}
+			}
+			if d.Encryption != nil {
+				if d.Encryption.Format == "luks" && diskType == "QCOW2" {
+					disk.Encrypted = true
+				} else {
+					notes = append(notes, fmt.Sprintf("디스크 %s의 %s 암호화는 가져오지 않았습니다.", d.Target.Dev, d.Encryption.Format))
+				}
+			}
+			if d.IOTune != nil {
+				for _, item := range d.IOTune.Items {
+					for key, name := range libvirtIOTuneNames {
+						if item.XMLName.Local == name {
+							if disk.Throttle == nil {
+								disk.Throttle = make(map[string]string)
+							}
+							disk.Throttle[key] = strings.TrimSpace(item.Value)
+						}
+					}
+				}
+			}
+			if d.Boot != nil {
+				bootIndex[fmt.Sprintf("disk%d", len(disks))] = d.Boot.Order
+			}
+			disks = append(disks, disk)
+		default:
+			notes = append(notes, fmt.Sprintf("%s 장치(%s)는 가져오지 않았습니다.", d.Device, d.Target.Dev))
+		}
+	}
+	config.Disk = formatDiskConfigs(disks)
+	config.CDROM = formatCDROMConfigs(cdroms)
+
+	// 네트워크: 첫 번째 인터페이스만 사용자 모드 네트워크로 가져옴
+	if len(dom.Devices.Interfaces) > 0 {
+		iface := dom.Devices.Interfaces[0]
+		config.Network = "user"
+		if iface.Type != "user" {
+			notes = append(notes, iface.Type+" 네트워크 인터페이스를 사용자 모드(user) 네트워크로 바꿨습니다.")
+		}
+		if iface.MAC != nil {
+			config.MAC = iface.MAC.Address
+		}
+		if iface.Model != nil {
+			model := iface.Model.Type
+			if model == "virtio" {
+				model = "virtio-net-pci"
+			}
+			if want := nicModel(vmMachine(config)); model != want {
+				notes = append(notes, fmt.Sprintf("네트워크 카드 모델 %s 대신 %s를 사용합니다.", iface.Model.Type, want))
+			}
+		}
+		if iface.Boot != nil {
+			bootIndex["net0"] = iface.Boot.Order
+		}
+		if n := len(dom.Devices.Interfaces) - 1; n > 0 {
+			notes = append(notes, fmt.Sprintf("나머지 네트워크 인터페이스 %d개는 가져오지 않았습니다.", n))
+		}
+	} else {
+		config.Network = "none"
+	}
+
+	// 그래픽
+	var gpuPairs []string
+	if len(dom.Devices.Videos) > 0 {
+		model := dom.Devices.Videos[0].Model
+		switch model.Type {
+		case "vga":
+			gpuPairs = append(gpuPairs, "vga=std")
+		case "cirrus", "qxl":
+			gpuPairs = append(gpuPairs, "vga="+model.Type)
+		case "virtio":
+			gpuPairs = append(gpuPairs, "vga=virtio")
+		case "none":
+		default:
+			notes = append(notes, "비디오 모델 "+model.Type+"는 가져오지 않았습니다.")
+		}
+	}
+	if len(dom.Devices.Graphics) > 0 {
+		g := dom.Devices.Graphics[0]
+		switch g.Type {
+		case "sdl", "vnc":
+			gpuPairs = append(gpuPairs, "display="+g.Type)
+		default:
+			gpuPairs = append(gpuPairs, "display=gtk")
+			notes = append(notes, g.Type+" 그래픽 대신 gtk 창으로 표시합니다.")
+		}
+		if g.GL != nil && g.GL.Enable == "yes" {
+			gpuPairs = append(gpuPairs, "gl=on")
+		}
+	}
+	config.GPU = strings.Join(gpuPairs, ",")
+
+	// 게스트 에이전트 채널 (소켓 경로는 저장할 때 정해짐, saveImportedConfig 참고)
+	for _, ch := range dom.Devices.Channels {
+		if ch.Target.Type == "virtio" && ch.Target.Name == libvirtGuestAgentChannel {
+			config.GuestAgent = "true"
+		}
+	}
+
+	// 부팅 순서: 장치별 <boot order>가 없으면 <os><boot dev>를 따름
+	if len(bootIndex) == 0 {
+		for i, b := range dom.OS.Boot {
+			id := ""
+			switch b.Dev {
+			case "hd":
+				if len(disks) > 0 {
+					id = "disk0"
+				}
+			case "cdrom":
+				if len(cdroms) > 0 {
+					id = "cd0"
+				}
+			case "network":
+				id = "net0"
+			}
+			if _, dup := bootIndex[id]; id != "" && !dup {
+				bootIndex[id] = i + 1
+			}
+		}
+	}
+	if len(bootIndex) > 0 {
+		var ids []string
+		for id := range bootIndex {
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(a, b int) bool { return bootIndex[ids[a]] < bootIndex[ids[b]] })
+		config.BootOrder = strings.Join(syncBootOrder(ids, bootDevices(config)), ",")
+	}
+	if m := dom.OS.BootMenu; m != nil && m.Enable == "yes" {
+		config.BootMenu = "true"
+		config.BootMenuTimeout = m.Timeout
+	}
+
+	// 처리하지 않는 요소 (같은 요소는 한 번만 알림)
+	seen := make(map[string]bool)
+	for _, list := range [][]libvirtElement{dom.Other, dom.Devices.Other} {
+		for _, e := range list {
+			name := e.XMLName.Local
+			if !libvirtIgnoredElements[name] && !seen[name] {
+				seen[name] = true
+				notes = append(notes, "<"+name+"> 요소는 가져오지 않았습니다.")
+			}
+		}
+	}
+
+	if cl := dom.Commandline; cl != nil {
+		for _, arg := range cl.Args {
+			extra = append(extra, arg.Value)
+		}
+	}
+	config.ExtraArgs = joinArgs(extra)
+	return config, notes
+}
+
+// libvirt 대상 장치 이름 (vda, sdb, hdc, nvme0n1 …)
+func libvirtTargetDev(bus string, counters map[string]int) string {
+	prefix := map[string]string{"virtio": "vd", "sata": "sd", "scsi": "sd", "ide": "hd"}[bus]
+	if bus == "nvme" {
+		n := counters["nvme"]
+		counters["nvme"]++
+		return fmt.Sprintf("nvme%dn1", n)
+	}
+	n := counters[prefix]
+	counters[prefix]++
+	name := ""
+	for n >= 0 {
+		name = string(rune('a'+n%26)) + name
+		n = n/26 - 1
+	}
+	return prefix + name
+}
+
+// 디스크 버스 → libvirt 디스크 버스
+func libvirtBusName(bus string) string {
+	for name, b := range libvirtDiskBus {
+		if b == bus {
+			return name
+		}
+	}
+	return bus
+}
+
+// exportLibvirtDomain은 설정을 libvirt 도메인으로 바꿉니다.
+// libvirt로 옮기지 못하는 설정은 notes에 적습니다.
+func exportLibvirtDomain(config VMConfig) (libvirtDomain, []string, error) {
+	var notes []string
+	binary, cpuModel := qemuCPU(config.CPUModel)
+	machine := vmMachine(config)
+
+	dom := libvirtDomain{Type: "qemu", Name: config.Name, UUID: config.UUID}
+	if config.CPUAccel == "true" {
+		switch strings.ToLower(config.CPUAccelerator) {
+		case "kvm", "hvf", "xen":
+			dom.Type = strings.ToLower(config.CPUAccelerator)
+		default:
+			notes = append(notes, config.CPUAccelerator+" 가속기는 libvirt에서 지원하지 않아 qemu(TCG)로 내보냈습니다.")
+		}
+	}
+
+	if mem := qemuMemorySize(config.RAM); mem != "" {
+		n, err := strconv.ParseInt(mem[:len(mem)-1], 10, 64)
+		if err != nil {
+			return dom, nil, fmt.Errorf("메모리 크기가 올바르지 않습니다: %s", config.RAM)
+		}
+		if strings.HasSuffix(mem, "G") {
+			n *= 1024
+		}
+		dom.Memory = libvirtMemory{Unit: "MiB", Value: n}
+	}
+
+	// CPU 수와 토폴로지 (지정하지 않은 값은 1)
+	topo := []int{1, 1, 1}
+	for i, v := range []string{config.CPUSockets, config.CPUCores, config.CPUThreads} {
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return dom, nil, fmt.Errorf("CPU 수가 올바르지 않습니다: %s", v)
+		}
+		topo[i] = n
+	}
+	dom.VCPU = topo[0] * topo[1] * topo[2]
+	if cpuModel != "" || config.CPUSockets != "" || config.CPUCores != "" || config.CPUThreads != "" {
+		cpu := &libvirtCPU{}
+		if cpuModel != "" {
+			cpu.Mode, cpu.Match = "custom", "exact"
+			cpu.Model = &libvirtCPUModel{Fallback: "allow", Value: cpuModel}
+			for _, f := range strings.FieldsFunc(config.CPUFeatures, func(r rune) bool {
+				return r == ',' || r == ' ' || r == '\n' || r == '\r'
+			}) {
+				policy := "require"
+				if strings.HasPrefix(f, "-") {
+					policy = "disable"
+				}
+				cpu.Features = append(cpu.Features, libvirtCPUFeature{policy, strings.TrimLeft(f, "+-")})
+			}
+		}
+		if config.CPUSockets != "" || config.CPUCores != "" || config.CPUThreads != "" {
+			cpu.Topology = &libvirtTopology{topo[0], topo[1], topo[2]}
+		}
+		dom.CPU = cpu
+	}
+
+	// OS, 부팅, 직접 커널 부팅
+	dom.OS.Type.Arch = libvirtArch[binary]
+	dom.OS.Type.Machine = machine
+	dom.OS.Type.Value = "hvm"
+	dom.OS.Kernel = config.Kernel
+	dom.OS.Initrd = config.Initrd
+	dom.OS.Cmdline = config.KernelAppend
+	dom.OS.DTB = config.DTB
+	if config.BootMenu == "true" {
+		dom.OS.BootMenu = &libvirtBootMenu{"yes", config.BootMenuTimeout}
+	}
+	if fw, ok := uefiFirmware[binary]; ok && config.Firmware == "uefi" {
+		code, err := qemuShareFile(binary, fw.code)
+		if err != nil {
+			code = fw.code
+		}
+		dom.OS.Loader = &libvirtPath{ReadOnly: "yes", Type: "pflash", Path: code}
+		if config.NVRAM != "" {
+			dom.OS.NVRAM = &libvirtPath{Path: config.NVRAM}
+		}
+		notes = append(notes, "UEFI 펌웨어 경로는 대상 컴퓨터의 OVMF/AAVMF 경로로 바꾸어야 합니다.")
+	}
+	if config.TPM != "" {
+		notes = append(notes, "TPM(swtpm)은 내보내지 않았습니다. 대상 컴퓨터에서 <tpm model=\"tpm-crb\"><backend type=\"emulator\" version=\"2.0\"/></tpm>을 추가하세요.")
+	}
+	if binary == "qemu-system-x86_64" {
+		dom.Features = &libvirtFeatures{ACPI: &struct{}{}, APIC: &struct{}{}}
+	}
+	// 한 번만 부팅할 장치는 내보내지 않음
+	persistent := config
+	persistent.BootOnce = ""
+	bootIndex := bootIndexes(persistent)
+	if config.BootOnce != "" {
+		notes = append(notes, "다음 한 번만 부팅할 장치 설정은 내보내지 않았습니다.")
+	}
+	bootOf := func(id string) *libvirtBoot {
+		if index, ok := bootIndex[id]; ok {
+			return &libvirtBoot{Order: index}
+		}
+		return nil
+	}
+
+	// 디스크와 CD/DVD
+	counters := make(map[string]int)
+	scsi := false
+	for i, d := range parseDiskConfigs(config.Disk) {
+		bus := libvirtBusName(d.bus(machine))
+		scsi = scsi || bus == "scsi"
+		disk := libvirtDisk{Type: "file", Device: "disk", Boot: bootOf(fmt.Sprintf("disk%d", i))}
+		disk.Driver = &libvirtDriver{Name: "qemu", Type: qemuImgFormat(d.Type), Cache: d.Cache, IO: d.AIO, DetectZeroes: d.DetectZeroes}
+		if d.Discard {
+			disk.Driver.Discard = "unmap"
+		}
+		disk.Source = &libvirtSource{File: d.Path}
+		disk.Target.Bus = bus
+		disk.Target.Dev = libvirtTargetDev(bus, counters)
+		if d.Encrypted {
+			disk.Encryption = &libvirtEncryption{"luks"}
+			notes = append(notes, d.Path+": 암호는 virsh secret-define으로 등록한 뒤 <encryption>에 <secret>을 추가해야 합니다.")
+		}
+		for _, key := range throttleLimitKeys {
+			if value := d.Throttle[key]; value != "" && value != "0" {
+				if disk.IOTune == nil {
+					disk.IOTune = &libvirtIOTune{}
+				}
+				disk.IOTune.Items = append(disk.IOTune.Items, libvirtElement{XMLName: xml.Name{Local: libvirtIOTuneNames[key]}, Value: value})
+			}
+		}
+		dom.Devices.Disks = append(dom.Devices.Disks, disk)
+	}
+	for i, c := range parseCDROMConfigs(config.CDROM) {
+		bus := libvirtBusName(c.bus(machine))
+		scsi = scsi || bus == "scsi"
+		cd := libvirtDisk{Type: "file", Device: "cdrom", ReadOnly: &struct{}{}, Boot: bootOf(fmt.Sprintf("cd%d", i))}
+		cd.Driver = &libvirtDriver{Name: "qemu", Type: "raw"}
+		if c.Path != "" {
+			cd.Source = &libvirtSource{File: c.Path}
+		}
+		cd.Target.Bus = bus
+		cd.Target.Dev = libvirtTargetDev(bus, counters)
+		dom.Devices.Disks = append(dom.Devices.Disks, cd)
+	}
+	// libvirt의 기본 SCSI 컨트롤러는 lsilogic이므로 virtio-scsi를 명시
+	if scsi {
+		dom.Devices.Controllers = append(dom.Devices.Controllers, libvirtController{Type: "scsi", Model: "virtio-scsi"})
+	}
+
+	// 네트워크: 사용자 모드 인터페이스 하나
+	if config.Network != "none" && nicModel(machine) != "" {
+		model := nicModel(machine)
+		if model == "virtio-net-pci" {
+			model = "virtio"
+		}
+		iface := libvirtInterface{Type: "user", Boot: bootOf("net0")}
+		iface.Model = &libvirtModel{model}
+		if config.MAC != "" {
+			iface.MAC = &libvirtMAC{config.MAC}
+		}
+		if _, opts, _ := strings.Cut(config.Network, ","); opts != "" || (config.Network != "" && !strings.HasPrefix(config.Network, "user")) {
+			notes = append(notes, "네트워크 옵션 "+config.Network+"는 내보내지 않았습니다. 사용자 모드(user) 인터페이스로 내보냅니다.")
+		}
+		dom.Devices.Interfaces = append(dom.Devices.Interfaces, iface)
+	}
+
+	// 그래픽
+	gpu := parseGPUString(config.GPU)
+	video := ""
+	switch {
+	case gpu["device"] != "":
+		video = "virtio"
+	case gpu["vga"] == "std":
+		video = "vga"
+	case gpu["vga"] != "":
+		video = gpu["vga"]
+	}
+	if video != "" {
+		v := libvirtVideo{}
+		v.Model.Type = video
+		if video == "virtio" && (gpu["gl"] == "on" || strings.HasSuffix(gpu["device"], "-gl")) {
+			v.Model.Acceleration = &libvirtAcceleration{"yes"}
+		}
+		dom.Devices.Videos = append(dom.Devices.Videos, v)
+	}
+	if display := gpu["display"]; display != "" && display != "none" {
+		// libvirt에는 gtk 창이 없으므로 spice로 내보냄
+		g := libvirtGraphics{Type: display}
+		if display == "gtk" {
+			g.Type = "spice"
+		}
+		if g.Type != "sdl" {
+			g.AutoPort = "yes"
+		}
+		if gpu["gl"] == "on" {
+			g.GL = &libvirtGL{"yes"}
+		}
+		dom.Devices.Graphics = append(dom.Devices.Graphics, g)
+	}
+
+	// 게스트 에이전트 채널 (소켓 경로는 libvirt가 정함)
+	if config.GuestAgent != "" && machineHasPCI(machine) {
+		ch := libvirtChannel{Type: "unix"}
+		ch.Target.Type = "virtio"
+		ch.Target.Name = libvirtGuestAgentChannel
+		dom.Devices.Channels = append(dom.Devices.Channels, ch)
+	}
+
+	// 추가 인자는 <qemu:commandline>으로
+	extra, err := splitArgs(config.ExtraArgs)
+	if err != nil {
+		return dom, nil, err
+	}
+	if len(extra) > 0 {
+		dom.Commandline = &libvirtCommandline{}
+		for _, arg := range extra {
+			dom.Commandline.Args = append(dom.Commandline.Args, libvirtArg{arg})
+		}
+	}
+	return dom, notes, nil
+}
+
+// writeLibvirtFile은 설정을 libvirt 도메인 XML 파일로 저장합니다.
+func writeLibvirtFile(path string, config VMConfig) ([]string, error) {
+	dom, notes, err := exportLibvirtDomain(config)
+	if err != nil {
+		return nil, err
+	}
+	data, err := xml.MarshalIndent(dom, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return notes, os.WriteFile(path, append(data, '\n'), 0644)
+}
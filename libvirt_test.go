@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// libvirtRoundTrip은 설정을 도메인 XML로 내보낸 뒤 다시 가져옵니다.
+func libvirtRoundTrip(t *testing.T, config VMConfig) (VMConfig, string) {
+	t.Helper()
+	dom, _, err := exportLibvirtDomain(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := xml.MarshalIndent(dom, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parsed libvirtDomain
+	if err := xml.Unmarshal(data, &parsed); err != nil {
+		t.Fatalf("%v\n%s", err, data)
+	}
+	imported, _ := importLibvirtDomain(parsed)
+	return imported, string(data)
+}
+
+func TestLibvirtRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	nvram := filepath.Join(dir, "rt_VARS.fd")
+	if err := os.WriteFile(nvram, []byte("vars"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	disks := []diskConfig{
+		{Type: "QCOW2", Path: filepath.Join(dir, "a.qcow2"), Bus: "virtio-blk", Cache: "none", Discard: true,
+			Throttle: map[string]string{"iops-total": "500", "bps-read-max": "1048576"}},
+		{Type: "RAW", Path: filepath.Join(dir, "b.img"), Bus: "ahci", DetectZeroes: "unmap"},
+	}
+	cdroms := []cdromConfig{{Bus: "ahci", Path: filepath.Join(dir, "install.iso")}, {Bus: "ahci"}}
+
+	tests := []struct {
+		name   string
+		config VMConfig
+	}{
+		{"uefi", VMConfig{
+			Name: "rt", UUID: "6f1b3c2e-1d2a-4b7e-9a55-0c8d4e2f7a10", RAM: "4GB", Machine: "q35",
+			CPUModel: "Intel: Haswell", CPUFeatures: "+avx2,-hle", CPUSockets: "1", CPUCores: "4", CPUThreads: "2",
+			CPUAccel: "true", CPUAccelerator: "KVM",
+			Firmware: "uefi", NVRAM: nvram,
+			Disk: formatDiskConfigs(disks), CDROM: formatCDROMConfigs(cdroms),
+			BootOrder: "cd0,disk1,disk0,cd1,net0", BootMenu: "true", BootMenuTimeout: "3000",
+			Network: "user", MAC: "52:54:00:12:34:56",
+			GuestAgent: `C:\vm\rt\qga.sock`,
+			ExtraArgs:  joinArgs([]string{"-device", "usb-tablet", "-rtc", "base=localtime", "-fw_cfg", "name=opt/x,string=a b"}),
+		}},
+		{"bios", VMConfig{
+			Name: "plain", RAM: "512MB", Machine: "pc", CPUSockets: "1", CPUCores: "2", CPUThreads: "1",
+			Disk:    formatDiskConfigs([]diskConfig{{Type: "QCOW2", Path: filepath.Join(dir, "c.qcow2"), Bus: "ide"}}),
+			CDROM:   formatCDROMConfigs([]cdromConfig{{Bus: "ide"}}),
+			Network: "none",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, data := libvirtRoundTrip(t, tt.config)
+			want := tt.config
+
+			// 가져온 디스크 용량은 이미지에서 읽으므로 비교하지 않음
+			gotDisks := parseDiskConfigs(got.Disk)
+			for i := range gotDisks {
+				gotDisks[i].Capacity = ""
+			}
+			got.Disk = formatDiskConfigs(gotDisks)
+
+			fields := []struct {
+				name      string
+				got, want string
+			}{
+				{"Name", got.Name, want.Name},
+				{"UUID", got.UUID, want.UUID},
+				{"RAM", got.RAM, want.RAM},
+				{"Machine", got.Machine, want.Machine},
+				{"CPUModel", got.CPUModel, want.CPUModel},
+				{"CPUFeatures", got.CPUFeatures, want.CPUFeatures},
+				{"CPUSockets", got.CPUSockets, want.CPUSockets},
+				{"CPUCores", got.CPUCores, want.CPUCores},
+				{"CPUThreads", got.CPUThreads, want.CPUThreads},
+				{"CPUAccel", got.CPUAccel, want.CPUAccel},
+				{"CPUAccelerator", got.CPUAccelerator, want.CPUAccelerator},
+				{"Firmware", got.Firmware, want.Firmware},
+				{"NVRAM", got.NVRAM, want.NVRAM},
+				{"Disk", got.Disk, want.Disk},
+				{"CDROM", got.CDROM, want.CDROM},
+				{"BootOrder", got.BootOrder, want.BootOrder},
+				{"BootMenu", got.BootMenu, want.BootMenu},
+				{"BootMenuTimeout", got.BootMenuTimeout, want.BootMenuTimeout},
+				{"Network", got.Network, want.Network},
+				{"MAC", got.MAC, want.MAC},
+				{"ExtraArgs", got.ExtraArgs, want.ExtraArgs},
+			}
+			for _, f := range fields {
+				if f.got != f.want {
+					t.Errorf("%s = %q, want %q", f.name, f.got, f.want)
+				}
+			}
+			// 소켓 경로는 가져와서 저장할 때 정해짐
+			if (got.GuestAgent != "") != (want.GuestAgent != "") {
+				t.Errorf("GuestAgent = %q, want channel %v", got.GuestAgent, want.GuestAgent != "")
+			}
+			if want.GuestAgent != "" && !strings.Contains(data, `name="`+libvirtGuestAgentChannel+`"`) {
+				t.Errorf("게스트 에이전트 채널이 없습니다:\n%s", data)
+			}
+			if t.Failed() {
+				t.Logf("XML:\n%s", data)
+			}
+		})
+	}
+}
+
+func TestLibvirtImportMissingNVRAM(t *testing.T) {
+	var dom libvirtDomain
+	dom.Name = "x"
+	dom.OS.Loader = &libvirtPath{ReadOnly: "yes", Type: "pflash", Path: "/usr/share/OVMF/OVMF_CODE.fd"}
+	dom.OS.NVRAM = &libvirtPath{Path: filepath.Join(t.TempDir(), "missing_VARS.fd")}
+	config, notes := importLibvirtDomain(dom)
+	if config.Firmware != "uefi" || config.NVRAM != "" {
+		t.Errorf("Firmware = %q, NVRAM = %q", config.Firmware, config.NVRAM)
+	}
+	if !strings.Contains(strings.Join(notes, "\n"), "새로 만들어집니다") {
+		t.Errorf("notes = %q", notes)
+	}
+}
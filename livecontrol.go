@@ -0,0 +1,330 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	sqdialog "github.com/sqweek/dialog"
+)
+
+// 실행 중인 가상머신을 QMP로 제어하는 창들 (launch.go의 runningVM 참고)
+
+// "iops-read-max" → block_set_io_throttle 인자 이름 "iops_rd_max"
+func blockIOThrottleArg(key string) string {
+	parts := strings.Split(key, "-")
+	name := parts[0]
+	switch parts[1] {
+	case "read":
+		name += "_rd"
+	case "write":
+		name += "_wr"
+	}
+	if len(parts) > 2 {
+		name += "_max"
+	}
+	return name
+}
+
+// liveThrottle은 실행 중인 가상머신의 index번째 디스크(parseDiskConfigs 순서)의 I/O 제한을 바꿉니다.
+// 시작할 때 throttle-group이 있던 디스크는 그 그룹의 limits를 qom-set으로 바꾸고,
+// 없던 디스크는 장치에 block_set_io_throttle을 씁니다. 빈 값은 0(제한 없음)으로 보냅니다.
+func liveThrottle(name string, index int, throttle map[string]string) error {
+	vm := lookupRunningVM(name)
+	q, err := vmQMP(name)
+	if err != nil {
+		return err
+	}
+	disks := parseDiskConfigs(vm.config.Disk)
+	if index < 0 || index >= len(disks) {
+		return fmt.Errorf("디스크 %d이(가) 없습니다.", index)
+	}
+	node := fmt.Sprintf("disk%d", index)
+
+	values := make(map[string]int64)
+	for _, key := range throttleLimitKeys {
+		values[key] = 0
+		if text := throttle[key]; text != "" {
+			value, err := strconv.ParseInt(text, 10, 64)
+			if err != nil {
+				return fmt.Errorf("%s 값이 올바르지 않습니다: %s", key, text)
+			}
+			values[key] = value
+		}
+	}
+
+	ctx := context.Background()
+	if len(disks[index].Throttle) > 0 {
+		err = q.call(ctx, "qom-set", map[string]any{
+			"path":     "/objects/" + node + "-throttle-group",
+			"property": "limits",
+			"value":    values,
+		}, nil)
+	} else {
+		args := map[string]any{"id": node + "-dev"}
+		for key, value := range values {
+			args[blockIOThrottleArg(key)] = value
+		}
+		err = q.call(ctx, "block_set_io_throttle", args, nil)
+	}
+	if err != nil {
+		return err
+	}
+	vm.mu.Lock()
+	if vm.throttles == nil {
+		vm.throttles = make(map[int]map[string]string)
+	}
+	vm.throttles[index] = throttle
+	vm.mu.Unlock()
+	return nil
+}
+
+// liveThrottleLimits는 디스크의 현재 I/O 제한입니다 (실행 중에 바꾸지 않았으면 시작할 때의 설정).
+func (vm *runningVM) liveThrottleLimits(index int, d diskConfig) map[string]string {
+	vm.mu.Lock()
+	defer vm.mu.Unlock()
+	if throttle, ok := vm.throttles[index]; ok {
+		return throttle
+	}
+	return d.Throttle
+}
+
+// ShowLiveThrottleWindow는 실행 중인 가상머신의 디스크별 I/O 제한을 바꾸는 창을 엽니다.
+// 바꾼 값은 가상머신이 꺼지면 사라지며, 계속 쓰려면 설정 창에서 저장합니다.
+func ShowLiveThrottleWindow(name string) {
+	vm := lookupRunningVM(name)
+	a := fyne.CurrentApp()
+	win := a.NewWindow(name + " I/O 제한 (실행 중)")
+	win.Resize(fyne.NewSize(450, 250))
+
+	rows := container.NewVBox()
+	if vm == nil {
+		rows.Add(widget.NewLabel(name + " 가상머신이 실행 중이 아닙니다."))
+	} else {
+		for i, d := range parseDiskConfigs(vm.config.Disk) {
+			i, d := i, d
+			var btn *widget.Button
+			btn = widget.NewButton(throttleButtonText(vm.liveThrottleLimits(i, d)), func() {
+				showThrottleForm(vm.liveThrottleLimits(i, d), win, func(throttle map[string]string) {
+					btn.Disable()
+					go func() {
+						err := liveThrottle(name, i, throttle)
+						btn.Enable()
+						if err != nil {
+							dialog.ShowError(err, win)
+							return
+						}
+						btn.SetText(throttleButtonText(throttle))
+					}()
+				})
+			})
+			rows.Add(container.NewBorder(nil, nil, nil, btn, widget.NewLabel(filepath.Base(d.Path))))
+		}
+	}
+
+	closeBtn := widget.NewButton("닫기", func() {
+		win.Close()
+	})
+	win.SetContent(container.NewBorder(
+		widget.NewLabel("바꾼 제한은 가상머신이 꺼질 때까지 적용됩니다."),
+		container.NewHBox(closeBtn), nil, nil,
+		container.NewVScroll(rows),
+	))
+	win.CenterOnScreen()
+	win.Show()
+}
+
+// blockInfo는 query-block 결과 중 CD 드라이브 상태에 필요한 부분입니다.
+type blockInfo struct {
+	Qdev     string `json:"qdev"`
+	TrayOpen bool   `json:"tray_open"`
+	Inserted *struct {
+		File string `json:"file"`
+	} `json:"inserted"`
+}
+
+// cdDriveState는 실행 중인 CD/DVD 드라이브의 매체와 트레이 상태입니다.
+type cdDriveState struct {
+	Path     string // 비어 있으면 매체 없음
+	TrayOpen bool
+}
+
+// queryCDDrives는 query-block으로 드라이브(cdN-dev)마다 상태를 읽어 vmCDROMs 순서로 반환합니다.
+func queryCDDrives(name string) ([]cdDriveState, error) {
+	vm := lookupRunningVM(name)
+	q, err := vmQMP(name)
+	if err != nil {
+		return nil, err
+	}
+	var blocks []blockInfo
+	if err := q.call(context.Background(), "query-block", nil, &blocks); err != nil {
+		return nil, err
+	}
+	byID := make(map[string]blockInfo)
+	for _, b := range blocks {
+		byID[b.Qdev] = b
+	}
+	states := make([]cdDriveState, len(vmCDROMs(vm.config)))
+	for i := range states {
+		b, ok := byID[fmt.Sprintf("cd%d-dev", i)]
+		if !ok {
+			continue
+		}
+		if b.Inserted != nil {
+			states[i].Path = b.Inserted.File
+		}
+		states[i].TrayOpen = b.TrayOpen
+	}
+	return states, nil
+}
+
+// changeCDMedia는 index번째 드라이브에 ISO를 넣습니다 (blockdev-change-medium).
+// path가 비어 있으면 매체를 꺼냅니다 (eject).
+func changeCDMedia(name string, index int, path string) error {
+	q, err := vmQMP(name)
+	if err != nil {
+		return err
+	}
+	id := fmt.Sprintf("cd%d-dev", index)
+	if path == "" {
+		return q.call(context.Background(), "eject", map[string]any{"id": id, "force": true}, nil)
+	}
+	return q.call(context.Background(), "blockdev-change-medium", map[string]any{
+		"id":             id,
+		"filename":       path,
+		"format":         "raw",
+		"read-only-mode": "read-only",
+	}, nil)
+}
+
+// saveCDMedia는 바꾼 매체를 설정 파일에도 저장합니다.
+// cloud-init 시드나 무인 설치 미디어처럼 설정의 CD 목록 뒤에 붙는 드라이브는 저장하지 않습니다.
+func saveCDMedia(configDir, name string, index int, path string) error {
+	config, err := loadVMConfig(configDir, name)
+	if err != nil {
+		return err
+	}
+	cdroms := parseCDROMConfigs(config.CDROM)
+	if index >= len(cdroms) {
+		return nil
+	}
+	cdroms[index].Path = path
+	config.CDROM = formatCDROMConfigs(cdroms)
+	return saveVMConfig(configDir, config)
+}
+
+// ShowLiveCDWindow는 실행 중인 가상머신의 CD/DVD 드라이브에 ISO를 넣거나 꺼내는 창을 엽니다.
+// 트레이 상태는 DEVICE_TRAY_MOVED 이벤트로 갱신하며, 바꾼 매체는 설정에도 저장합니다.
+func ShowLiveCDWindow(configDir, name string) {
+	a := fyne.CurrentApp()
+	win := a.NewWindow(name + " CD/DVD (실행 중)")
+	win.Resize(fyne.NewSize(500, 250))
+
+	rows := container.NewVBox()
+	var statusLabels []*widget.Label
+	// states는 refresh와 QMP 이벤트 고루틴에서 함께 쓰므로 statesMu로 보호
+	var statesMu sync.Mutex
+	var states []cdDriveState
+
+	refresh := func() {
+		list, err := queryCDDrives(name)
+		if err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		statesMu.Lock()
+		defer statesMu.Unlock()
+		states = list
+		for i, label := range statusLabels {
+			if i < len(states) {
+				label.SetText(cdDriveText(states[i]))
+			}
+		}
+	}
+
+	vm := lookupRunningVM(name)
+	if vm == nil {
+		rows.Add(widget.NewLabel(name + " 가상머신이 실행 중이 아닙니다."))
+	} else {
+		for i := range vmCDROMs(vm.config) {
+			i := i
+			label := widget.NewLabel("")
+			label.Wrapping = fyne.TextWrapWord
+			statusLabels = append(statusLabels, label)
+
+			// change는 매체를 바꾸고 설정에도 저장합니다 (path가 비어 있으면 꺼내기).
+			change := func(path string) {
+				go func() {
+					if err := changeCDMedia(name, i, path); err != nil {
+						dialog.ShowError(err, win)
+						return
+					}
+					if err := saveCDMedia(configDir, name, i, path); err != nil {
+						dialog.ShowError(err, win)
+					}
+					refresh()
+				}()
+			}
+			insertBtn := widget.NewButton("ISO 넣기", func() {
+				path, err := sqdialog.File().Title("ISO 이미지 선택").Filter("ISO 이미지", "iso", "img").Load()
+				if err != nil || path == "" {
+					return
+				}
+				change(path)
+			})
+			ejectBtn := widget.NewButton("꺼내기", func() { change("") })
+			rows.Add(container.NewVBox(
+				widget.NewLabel(fmt.Sprintf("드라이브 %d", i+1)),
+				label,
+				container.NewHBox(insertBtn, ejectBtn),
+				widget.NewSeparator(),
+			))
+		}
+		// 게스트가 트레이를 열고 닫으면 이벤트로 받은 상태를 바로 표시
+		vm.setTrayListener(func(id string, open bool) {
+			var i int
+			if _, err := fmt.Sscanf(id, "cd%d-dev", &i); err != nil {
+				return
+			}
+			statesMu.Lock()
+			defer statesMu.Unlock()
+			if i < 0 || i >= len(states) || i >= len(statusLabels) {
+				return
+			}
+			states[i].TrayOpen = open
+			statusLabels[i].SetText(cdDriveText(states[i]))
+		})
+		win.SetOnClosed(func() { vm.setTrayListener(nil) })
+	}
+	if len(rows.Objects) == 0 {
+		rows.Add(widget.NewLabel("CD/DVD 드라이브가 없습니다."))
+	}
+
+	closeBtn := widget.NewButton("닫기", func() {
+		win.Close()
+	})
+	win.SetContent(container.NewBorder(nil, container.NewHBox(closeBtn), nil, nil, container.NewVScroll(rows)))
+	win.CenterOnScreen()
+	win.Show()
+	if vm != nil {
+		go refresh()
+	}
+}
+
+func cdDriveText(state cdDriveState) string {
+	text := "비어 있음"
+	if state.Path != "" {
+		text = filepath.Base(state.Path)
+	}
+	if state.TrayOpen {
+		text += " (트레이 열림)"
+	}
+	return text
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBlockIOThrottleArg(t *testing.T) {
+	tests := map[string]string{
+		"iops-total":     "iops",
+		"iops-read":      "iops_rd",
+		"iops-write":     "iops_wr",
+		"iops-total-max": "iops_max",
+		"iops-read-max":  "iops_rd_max",
+		"iops-write-max": "iops_wr_max",
+		"bps-total":      "bps",
+		"bps-read":       "bps_rd",
+		"bps-write":      "bps_wr",
+		"bps-total-max":  "bps_max",
+		"bps-read-max":   "bps_rd_max",
+		"bps-write-max":  "bps_wr_max",
+	}
+	if len(tests) != len(throttleLimitKeys) {
+		t.Fatalf("throttleLimitKeys %d개 중 %d개만 확인합니다", len(throttleLimitKeys), len(tests))
+	}
+	for _, key := range throttleLimitKeys {
+		if got := blockIOThrottleArg(key); got != tests[key] {
+			t.Errorf("blockIOThrottleArg(%q) = %q, want %q", key, got, tests[key])
+		}
+	}
+}
+
+func TestLiveThrottle(t *testing.T) {
+	commands := make(chan map[string]any, 1)
+	socket := fakeQMP(t, func(cmd map[string]any) []string {
+		if cmd["execute"] != "qmp_capabilities" {
+			commands <- cmd
+		}
+		return []string{`{"return": {}}`}
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	q, err := dialQMP(ctx, socket, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+
+	// disk0은 시작할 때 제한이 없었고 disk1은 throttle-group이 있었음
+	config := VMConfig{Name: "live", Disk: formatDiskConfigs([]diskConfig{
+		{Type: "QCOW2", Path: "a.qcow2"},
+		{Type: "QCOW2", Path: "b.qcow2", Throttle: map[string]string{"iops-total": "100"}},
+	})}
+	vm := fakeRunningVM(t, config, q)
+	throttle := map[string]string{"iops-total": "500", "bps-write-max": "1048576"}
+
+	tests := []struct {
+		index   int
+		execute string
+		want    map[string]any // 인자 중 확인할 값 (JSON 숫자는 float64)
+	}{
+		{0, "block_set_io_throttle", map[string]any{"id": "disk0-dev", "iops": 500.0, "bps_wr_max": 1048576.0, "iops_rd": 0.0, "bps": 0.0}},
+		{1, "qom-set", map[string]any{"path": "/objects/disk1-throttle-group", "property": "limits"}},
+	}
+	for _, tt := range tests {
+		if err := liveThrottle(config.Name, tt.index, throttle); err != nil {
+			t.Fatalf("disk%d: %v", tt.index, err)
+		}
+		cmd := <-commands
+		args, _ := cmd["arguments"].(map[string]any)
+		if cmd["execute"] != tt.execute {
+			t.Errorf("disk%d: execute = %v, want %s", tt.index, cmd["execute"], tt.execute)
+		}
+		for key, want := range tt.want {
+			if args[key] != want {
+				t.Errorf("disk%d: %s = %v, want %v", tt.index, key, args[key], want)
+			}
+		}
+		if tt.execute == "block_set_io_throttle" && len(args) != len(throttleLimitKeys)+1 {
+			t.Errorf("disk%d: 인자 %d개, want %d", tt.index, len(args), len(throttleLimitKeys)+1)
+		}
+		if tt.execute == "qom-set" {
+			limits, _ := args["value"].(map[string]any)
+			if len(limits) != len(throttleLimitKeys) || limits["iops-total"] != 500.0 || limits["bps-write-max"] != 1048576.0 || limits["iops-read"] != 0.0 {
+				t.Errorf("disk%d: limits = %v", tt.index, limits)
+			}
+		}
+		if got := vm.liveThrottleLimits(tt.index, parseDiskConfigs(config.Disk)[tt.index]); got["iops-total"] != "500" {
+			t.Errorf("disk%d: liveThrottleLimits = %v", tt.index, got)
+		}
+	}
+
+	for _, bad := range []struct {
+		index    int
+		throttle map[string]string
+	}{
+		{2, throttle},
+		{0, map[string]string{"iops-total": "많이"}},
+	} {
+		if err := liveThrottle(config.Name, bad.index, bad.throttle); err == nil {
+			t.Errorf("liveThrottle(%d, %v): 오류가 없습니다", bad.index, bad.throttle)
+		}
+	}
+}
@@ -3,38 +3,257 @@ package main
 import (
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 )
 
 // loadVMConfigs는 설정 파일들을 읽어 VMConfig 목록으로 반환합니다.
+// 관리형 레이아웃(vms\<이름>\vm.conf)의 설정도 함께 읽습니다.
+// 같은 이름이 두 레이아웃에 모두 있으면 관리형 설정만 씁니다 (loadVMConfig와 같음).
 func loadVMConfigs(configDir string) []VMConfig {
 	var configs []VMConfig
+	managedNames := make(map[string]bool)
+	managedFiles, _ := filepath.Glob(filepath.Join(configDir, "vms", "*", vmDirConfigName))
+	for _, conf := range managedFiles {
+		if config, err := loadManagedVMConfig(filepath.Dir(conf)); err == nil {
+			configs = append(configs, config)
+			managedNames[config.Name] = true
+		}
+	}
 	confFiles, _ := filepath.Glob(filepath.Join(configDir, "*.conf"))
 	for _, conf := range confFiles {
+		if managedNames[strings.TrimSuffix(filepath.Base(conf), ".conf")] {
+			continue
+		}
 		data, err := os.ReadFile(conf)
 		if err != nil {
 			continue
 		}
-		config := VMConfig{}
-		lines := strings.Split(string(data), "\n")
-		for _, line := range lines {
-			if strings.TrimSpace(line) == "" {
-				continue
-			}
-			parts := strings.SplitN(line, "=", 2)
-			if len(parts) != 2 {
-				continue
-			}
-			key := strings.TrimSpace(parts[0])
-			value := strings.TrimSpace(parts[1])
-			switch key {
-			case "name":
-				config.Name = value
-			case "cpuModel":
-				config.CPUModel = value
-			}
-		}
-		configs = append(configs, config)
+		configs = append(configs, parseVMConfig(string(data)))
 	}
+	sort.SliceStable(configs, func(i, j int) bool { return configs[i].Name < configs[j].Name })
 	return configs
-}
\ No newline at end of file
+}
+
+// loadManagedVMConfig는 가상머신 폴더의 vm.conf를 읽고 상대 경로를 절대 경로로 바꿉니다.
+// 이름은 폴더 이름을 따릅니다.
+func loadManagedVMConfig(dir string) (VMConfig, error) {
+	data, err := os.ReadFile(filepath.Join(dir, vmDirConfigName))
+	if err != nil {
+		return VMConfig{}, err
+	}
+	config := absoluteVMPaths(parseVMConfig(string(data)), dir)
+	config.Name = filepath.Base(dir)
+	config.Managed = true
+	return config, nil
+}
+
+// loadVMConfig는 이름으로 설정 파일 하나를 읽습니다. 관리형 설정이 있으면 그것을 읽습니다.
+func loadVMConfig(configDir, name string) (VMConfig, error) {
+	config, err := loadManagedVMConfig(vmStorageDir(configDir, name))
+	if !os.IsNotExist(err) {
+		return config, err
+	}
+	data, err := os.ReadFile(filepath.Join(configDir, name+".conf"))
+	if err != nil {
+		return VMConfig{}, err
+	}
+	return parseVMConfig(string(data)), nil
+}
+
+// "key=value" 줄 단위 설정 내용을 VMConfig로 변환
+func parseVMConfig(data string) VMConfig {
+	config := VMConfig{}
+	var hw string
+	lines := strings.Split(data, "\n")
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		key := strings.TrimSpace(parts[0])
+		value := strings.TrimSpace(parts[1])
+		switch key {
+		case "name":
+			config.Name = value
+		case "cpu":
+			config.CPU = value
+		case "cpuModel":
+			config.CPUModel = value
+		case "cpuCores":
+			config.CPUCores = value
+		case "cpuSockets":
+			config.CPUSockets = value
+		case "cpuThreads":
+			config.CPUThreads = value
+		case "cpuFeatures":
+			config.CPUFeatures = value
+		case "cpuAccel":
+			config.CPUAccel = value
+		case "cpuAccelerator":
+			config.CPUAccelerator = value
+		case "ram":
+			config.RAM = value
+		case "disk":
+			config.Disk = value
+		case "gpu":
+			config.GPU = value
+		case "network":
+			config.Network = value
+		case "hw":
+			// 예전 "하드웨어" 입력란은 쓰이지 않았으므로 내용을 추가 인자 앞에 옮김
+			hw = value
+		case "uuid":
+			config.UUID = value
+		case "mac":
+			config.MAC = value
+		case "machine":
+			config.Machine = value
+		case "cdrom":
+			config.CDROM = value
+		case "bootOrder":
+			config.BootOrder = value
+		case "bootMenu":
+			config.BootMenu = value
+		case "bootMenuTimeout":
+			config.BootMenuTimeout = value
+		case "bootOnce":
+			config.BootOnce = value
+		case "kernel":
+			config.Kernel = value
+		case "initrd":
+			config.Initrd = value
+		case "kernelAppend":
+			config.KernelAppend = value
+		case "dtb":
+			config.DTB = value
+		case "firmware":
+			config.Firmware = value
+		case "nvram":
+			config.NVRAM = value
+		case "tpm":
+			config.TPM = value
+		case "cloudInit":
+			config.CloudInit.Enabled = value == "true"
+		case "cloudInitSeed":
+			config.CloudInit.Seed = value
+		case "cloudInitHostname":
+			config.CloudInit.Hostname = value
+		case "cloudInitUsers":
+			config.CloudInit.Users = value
+		case "cloudInitPassword":
+			config.CloudInit.Password = value
+		case "cloudInitSSHKeys":
+			config.CloudInit.SSHKeys = unescapeConfigLines(value)
+		case "cloudInitPackages":
+			config.CloudInit.Packages = value
+		case "cloudInitRunCmd":
+			config.CloudInit.RunCmd = unescapeConfigLines(value)
+		case "cloudInitNetworkConfig":
+			config.CloudInit.NetworkConfig = unescapeConfigLines(value)
+		case "unattend":
+			config.Unattend.Enabled = value == "true"
+		case "unattendMedia":
+			config.Unattend.Media = value
+		case "unattendEdition":
+			config.Unattend.Edition = value
+		case "unattendProductKey":
+			config.Unattend.ProductKey = value
+		case "unattendLocale":
+			config.Unattend.Locale = value
+		case "unattendUser":
+			config.Unattend.User = value
+		case "unattendPassword":
+			config.Unattend.Password = value
+		case "unattendPartition":
+			config.Unattend.Partition = value
+		case "unattendDriverISO":
+			config.Unattend.DriverISO = value
+		case "unattendDrivers":
+			config.Unattend.Drivers = unescapeConfigLines(value)
+		case "guestAgent":
+			config.GuestAgent = value
+		case "extraArgs":
+			config.ExtraArgs = value
+		}
+	}
+	if hw != "" {
+		config.ExtraArgs = strings.TrimSpace(hw + " " + config.ExtraArgs)
+	}
+	return config
+}
+
+// VMConfig를 "key=value" 줄 단위 설정 내용으로 변환
+func formatVMConfig(config VMConfig) string {
+	return "name=" + config.Name + "\n" +
+		"cpuModel=" + config.CPUModel + "\n" +
+		"cpuCores=" + config.CPUCores + "\n" +
+		"cpuSockets=" + config.CPUSockets + "\n" +
+		"cpuThreads=" + config.CPUThreads + "\n" +
+		"cpuFeatures=" + config.CPUFeatures + "\n" +
+		"cpuAccel=" + config.CPUAccel + "\n" +
+		"cpuAccelerator=" + config.CPUAccelerator + "\n" +
+		"ram=" + config.RAM + "\n" +
+		"disk=" + config.Disk + "\n" +
+		"gpu=" + config.GPU + "\n" +
+		"network=" + config.Network + "\n" +
+		"uuid=" + config.UUID + "\n" +
+		"mac=" + config.MAC + "\n" +
+		"machine=" + config.Machine + "\n" +
+		"cdrom=" + config.CDROM + "\n" +
+		"bootOrder=" + config.BootOrder + "\n" +
+		"bootMenu=" + config.BootMenu + "\n" +
+		"bootMenuTimeout=" + config.BootMenuTimeout + "\n" +
+		"bootOnce=" + config.BootOnce + "\n" +
+		"kernel=" + config.Kernel + "\n" +
+		"initrd=" + config.Initrd + "\n" +
+		"kernelAppend=" + config.KernelAppend + "\n" +
+		"dtb=" + config.DTB + "\n" +
+		"firmware=" + config.Firmware + "\n" +
+		"nvram=" + config.NVRAM + "\n" +
+		"tpm=" + config.TPM + "\n" +
+		"cloudInit=" + strconv.FormatBool(config.CloudInit.Enabled) + "\n" +
+		"cloudInitSeed=" + config.CloudInit.Seed + "\n" +
+		"cloudInitHostname=" + config.CloudInit.Hostname + "\n" +
+		"cloudInitUsers=" + config.CloudInit.Users + "\n" +
+		"cloudInitPassword=" + config.CloudInit.Password + "\n" +
+		"cloudInitSSHKeys=" + escapeConfigLines(config.CloudInit.SSHKeys) + "\n" +
+		"cloudInitPackages=" + config.CloudInit.Packages + "\n" +
+		"cloudInitRunCmd=" + escapeConfigLines(config.CloudInit.RunCmd) + "\n" +
+		"cloudInitNetworkConfig=" + escapeConfigLines(config.CloudInit.NetworkConfig) + "\n" +
+		"unattend=" + strconv.FormatBool(config.Unattend.Enabled) + "\n" +
+		"unattendMedia=" + config.Unattend.Media + "\n" +
+		"unattendEdition=" + config.Unattend.Edition + "\n" +
+		"unattendProductKey=" + config.Unattend.ProductKey + "\n" +
+		"unattendLocale=" + config.Unattend.Locale + "\n" +
+		"unattendUser=" + config.Unattend.User + "\n" +
+		"unattendPassword=" + config.Unattend.Password + "\n" +
+		"unattendPartition=" + config.Unattend.Partition + "\n" +
+		"unattendDriverISO=" + config.Unattend.DriverISO + "\n" +
+		"unattendDrivers=" + escapeConfigLines(config.Unattend.Drivers) + "\n" +
+		"guestAgent=" + config.GuestAgent + "\n" +
+		"extraArgs=" + config.ExtraArgs + "\n"
+}
+
+// saveVMConfig는 설정을 <이름>.conf 파일로 저장합니다.
+// 관리형이면 vms\<이름>\vm.conf에 폴더 안 경로를 상대 경로로 바꿔 저장합니다.
+func saveVMConfig(configDir string, config VMConfig) error {
+	// QEMU는 게스트 에이전트 소켓의 폴더를 만들지 않으므로 미리 만듦
+	if config.GuestAgent != "" {
+		if err := os.MkdirAll(filepath.Dir(config.GuestAgent), os.ModePerm); err != nil {
+			return err
+		}
+	}
+	if config.Managed {
+		dir := vmStorageDir(configDir, config.Name)
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			return err
+		}
+		config = relativeVMPaths(config, dir)
+	}
+	return os.WriteFile(vmConfigPath(configDir, config), []byte(formatVMConfig(config)), 0644)
+}
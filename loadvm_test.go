@@ -0,0 +1,103 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseVMConfigMigratesHW(t *testing.T) {
+	config := parseVMConfig("name=vm\nhw=-usb -device usb-tablet\nextraArgs=-rtc base=localtime\n")
+	if want := "-usb -device usb-tablet -rtc base=localtime"; config.ExtraArgs != want {
+		t.Errorf("ExtraArgs = %q, want %q", config.ExtraArgs, want)
+	}
+	if out := formatVMConfig(config); containsLine(out, "hw=") {
+		t.Errorf("hw= 줄이 다시 저장되었습니다:\n%s", out)
+	}
+	if config = parseVMConfig("name=vm\nhw=\nextraArgs=-usb\n"); config.ExtraArgs != "-usb" {
+		t.Errorf("빈 hw: ExtraArgs = %q", config.ExtraArgs)
+	}
+}
+
+// containsLine은 prefix로 시작하는 줄이 있는지 확인합니다.
+func containsLine(text, prefix string) bool {
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// fullVMConfig는 저장되는 모든 항목을 채운 설정입니다. 파일 경로는 dir 아래에 둡니다.
+func fullVMConfig(dir string) VMConfig {
+	return VMConfig{
+		Name: "full", CPUModel: "Intel: Haswell", CPUCores: "4", CPUSockets: "1", CPUThreads: "2",
+		CPUFeatures: "+avx2,-hle", CPUAccel: "true", CPUAccelerator: "WHPX", RAM: "8GB",
+		Disk: formatDiskConfigs([]diskConfig{
+			{Type: "QCOW2", Path: filepath.Join(dir, "disk1.qcow2"), Capacity: "20480", Bus: "virtio-blk", Cache: "none", Discard: true},
+			{Type: "RAW", Path: filepath.Join(dir, "..", "shared", "data.img"), Bus: "ahci", Encrypted: true},
+		}),
+		GPU: "vga=virtio,display=gtk,gl=on", Network: "user,hostfwd=tcp::2222-:22",
+		UUID: "6f1b3c2e-1d2a-4b7e-9a55-0c8d4e2f7a10", MAC: "52:54:00:12:34:56", Machine: "q35",
+		CDROM:     formatCDROMConfigs([]cdromConfig{{Bus: "ahci", Path: filepath.Join(dir, "..", "iso", "install.iso")}, {Bus: "ahci"}}),
+		BootOrder: "cd0,disk0,disk1,cd1,net0", BootMenu: "true", BootMenuTimeout: "3000", BootOnce: "cd0",
+		Kernel: filepath.Join(dir, "boot", "vmlinuz"), Initrd: filepath.Join(dir, "boot", "initrd"),
+		KernelAppend: "console=ttyS0 root=/dev/vda1 quiet", DTB: filepath.Join(dir, "boot", "board.dtb"),
+		Firmware: "uefi", NVRAM: filepath.Join(dir, "nvram", "VARS.fd"), TPM: filepath.Join(dir, "tpm", "swtpm-sock"),
+		CloudInit: cloudInitConfig{
+			Enabled: true, Seed: filepath.Join(dir, "seed.iso"), Hostname: "full", Users: "ubuntu,admin",
+			Password: "$6$salt$hash", SSHKeys: "ssh-ed25519 AAAA one\nssh-rsa BBBB two", Packages: "qemu-guest-agent,htop",
+			RunCmd: "systemctl enable --now qemu-guest-agent\necho done > /root/ok", NetworkConfig: "version: 2\nethernets:\n  eth0:\n    dhcp4: true",
+		},
+		Unattend: unattendConfig{
+			Enabled: true, Media: filepath.Join(dir, "unattend.iso"), Edition: "Windows 11 Pro", ProductKey: "VK7JG-NPHTM-C97JM-9MPGT-3V66T",
+			Locale: "ko-KR", User: "사용자", Password: "비밀=번호", Partition: "gpt", DriverISO: filepath.Join(dir, "..", "iso", "virtio-win.iso"),
+			Drivers: "vioscsi\\w11\\amd64\nNetKVM\\w11\\amd64",
+		},
+		GuestAgent: filepath.Join(dir, "qga.sock"),
+		ExtraArgs:  joinArgs([]string{"-device", "usb-tablet", "-fw_cfg", "name=opt/x,string=a=b c"}),
+	}
+}
+
+func TestVMConfigRoundTrip(t *testing.T) {
+	config := fullVMConfig(filepath.Join(t.TempDir(), "full"))
+	if got := parseVMConfig(formatVMConfig(config)); got != config {
+		t.Errorf("parseVMConfig(formatVMConfig(c)) =\n%+v\nwant\n%+v", got, config)
+	}
+}
+
+func TestManagedVMConfigRoundTrip(t *testing.T) {
+	configDir := t.TempDir()
+	config := fullVMConfig(vmStorageDir(configDir, "full"))
+	config.Managed = true
+	if err := saveVMConfig(configDir, config); err != nil {
+		t.Fatal(err)
+	}
+
+	// 폴더 안의 파일은 상대 경로로, 밖의 파일은 절대 경로로 저장
+	saved := readTestFile(t, filepath.Join(vmStorageDir(configDir, "full"), vmDirConfigName))
+	for _, line := range []string{
+		"nvram=" + filepath.Join("nvram", "VARS.fd"), "tpm=" + filepath.Join("tpm", "swtpm-sock"),
+		"kernel=" + filepath.Join("boot", "vmlinuz"), "cloudInitSeed=seed.iso", "guestAgent=qga.sock",
+		"unattendDriverISO=" + filepath.Join(configDir, "vms", "iso", "virtio-win.iso"),
+	} {
+		if !strings.Contains(saved, "\n"+line+"\n") {
+			t.Errorf("%q 줄이 없습니다:\n%s", line, saved)
+		}
+	}
+	if disks := parseDiskConfigs(parseVMConfig(saved).Disk); disks[0].Path != "disk1.qcow2" || !filepath.IsAbs(disks[1].Path) {
+		t.Errorf("disks = %+v", disks)
+	}
+
+	loaded, err := loadVMConfig(configDir, "full")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded != config {
+		t.Errorf("loadVMConfig =\n%+v\nwant\n%+v", loaded, config)
+	}
+	if configs := loadVMConfigs(configDir); len(configs) != 1 || configs[0] != config {
+		t.Errorf("loadVMConfigs = %+v", configs)
+	}
+}
@@ -1,8 +1,12 @@
 package main
 
 import (
+	"context"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
@@ -19,21 +23,69 @@ func main() {
 	appData := os.Getenv("APPDATA")
 	configDir := filepath.Join(appData, "goqemu")
 	os.MkdirAll(configDir, os.ModePerm)
+	removeStaleDiskSecrets()
 	configs := loadVMConfigs(configDir)
+	// 디스크 사용량은 qemu-img를 디스크마다 실행하므로 백그라운드에서 계산 (updateUsages)
+	// usages와 generation은 계산 고루틴에서도 쓰므로 usageMu로 보호
+	var usageMu sync.Mutex
+	usages := make([]diskUsage, len(configs))
+	generation := 0
 
 	vmList := widget.NewList(
 		func() int { return len(configs) },
 		func() fyne.CanvasObject { return widget.NewLabel("template") },
 		func(i widget.ListItemID, o fyne.CanvasObject) {
 			// 예: "VM 이름 (CPU 모델)" 형태로 표시
-			o.(*widget.Label).SetText(configs[i].Name + " (" + configs[i].CPUModel + ")")
+			text := configs[i].Name + " (" + configs[i].CPUModel + ")"
+			var usage diskUsage
+			usageMu.Lock()
+			if i < len(usages) {
+				usage = usages[i]
+			}
+			usageMu.Unlock()
+			if usage.VirtualMB > 0 {
+				text += " - " + diskUsageText(usage)
+			}
+			o.(*widget.Label).SetText(text)
 		},
 	)
 
+	// 호스트 저장소 요약
+	storageLabel := widget.NewLabel("")
+	storageLabel.Wrapping = fyne.TextWrapWord
+
+	// updateUsages는 사용량과 저장소 요약을 고루틴에서 계산해 표시합니다.
+	// 계산 중에 목록을 다시 읽었으면 이전 결과는 버립니다.
+	updateUsages := func() {
+		usageMu.Lock()
+		generation++
+		current, list := generation, configs
+		usageMu.Unlock()
+		go func() {
+			result := vmDiskUsages(list)
+			text := storageSummaryText(list)
+			usageMu.Lock()
+			if current != generation {
+				usageMu.Unlock()
+				return
+			}
+			usages = result
+			usageMu.Unlock()
+			storageLabel.SetText(text)
+			vmList.Refresh()
+		}()
+	}
+	updateUsages()
+
 	// 함수: 리스트를 새로 읽어오고 refresh 처리
 	refreshVMList := func() {
-		configs = loadVMConfigs(configDir)
+		list := loadVMConfigs(configDir)
+		usageMu.Lock()
+		configs = list
+		usages = make([]diskUsage, len(list))
+		usageMu.Unlock()
 		vmList.Refresh()
+		updateUsages()
 	}
 
 	// 왼쪽 패널: 관리 버튼 영역 (전체 너비의 1/5 차지)
@@ -41,14 +93,25 @@ func main() {
 		// 저장 콜백 전달하여 생성 후 자동 갱신
 		EditVMConfig("", w, refreshVMList)
 	})
-	managementPanel := container.NewVBox(createBtn)
+	templateBtn := widget.NewButton("템플릿으로 생성", func() {
+		ShowTemplateWizard(configDir, w, refreshVMList)
+	})
+	// 복제 등 오래 걸리는 작업의 진행률 표시
+	jobs := newJobPanel()
+	mediaBtn := widget.NewButton("미디어 라이브러리", func() {
+		ShowMediaLibraryWindow(configDir, jobs)
+	})
+	importBtn := widget.NewButton("가져오기", func() {
+		ShowImportWindow(configDir, w, jobs, refreshVMList)
+	})
+	managementPanel := container.NewVBox(createBtn, templateBtn, importBtn, mediaBtn, jobs.box, storageLabel)
 
 	// vmList 항목 클릭 시 관리창 코드 수정 (삭제 버튼 추가)
 	vmList.OnSelected = func(id widget.ListItemID) {
 		config := configs[id]
 		ctrlWin := a.NewWindow(config.Name + " 관리")
 		startBtn := widget.NewButton("시작", func() {
-			dialog.ShowInformation("시작", config.Name+" 가상머신을 시작합니다.", w)
+			ShowStartVM(configDir, config.Name, w, jobs)
 			ctrlWin.Close()
 		})
 		settingBtn := widget.NewButton("설정", func() {
@@ -60,8 +123,7 @@ func main() {
 			confirmWin := a.NewWindow("삭제 확인")
 			confirmLabel := widget.NewLabel(config.Name + " 가상머신을 삭제하시겠습니까?")
 			yesBtn := widget.NewButton("네", func() {
-				configPath := filepath.Join(configDir, config.Name+".conf")
-				if err := os.Remove(configPath); err != nil {
+				if err := removeVMConfig(configDir, config); err != nil {
 					dialog.ShowError(err, confirmWin)
 				} else {
 					dialog.ShowInformation("삭제", config.Name+" 가상머신이 삭제되었습니다.", confirmWin)
@@ -83,13 +145,94 @@ func main() {
 			confirmWin.CenterOnScreen()
 			confirmWin.Show()
 		})
+		snapshotBtn := widget.NewButton("스냅샷", func() {
+			ShowSnapshotWindow(config)
+			ctrlWin.Close()
+		})
+		cloneBtn := widget.NewButton("복제", func() {
+			ShowCloneWindow(config, configDir, w, jobs, refreshVMList)
+			ctrlWin.Close()
+		})
+		exportBtn := widget.NewButton("내보내기", func() {
+			ShowExportWindow(config, w, jobs)
+			ctrlWin.Close()
+		})
+		templateBtn := widget.NewButton("템플릿 저장", func() {
+			ShowSaveTemplateWindow(config, configDir, w)
+			ctrlWin.Close()
+		})
+		checkBtn := widget.NewButton("검사", func() {
+			ShowDiskCheckWindow(config, jobs)
+			ctrlWin.Close()
+		})
 		closeBtn := widget.NewButton("닫기", func() {
 			ctrlWin.Close()
 		})
+		// 이전 레이아웃(설정 폴더의 .conf)을 쓰는 가상머신을 가상머신 폴더로 옮김
+		managedBtn := widget.NewButton("관리형으로 전환", func() {
+			ctrlWin.Close()
+			message := config.Name + " 가상머신의 설정, 디스크, UEFI 변수, TPM 상태를\n" +
+				vmStorageDir(configDir, config.Name) + " 폴더로 옮기시겠습니까?"
+			dialog.ShowConfirm("관리형으로 전환", message, func(ok bool) {
+				if !ok {
+					return
+				}
+				var notes []string
+				jobs.start(config.Name+" 관리형으로 전환 중", func(ctx context.Context, report func(float64)) error {
+					var err error
+					_, notes, err = convertToManaged(configDir, config)
+					return err
+				}, func(err error) {
+					if err != nil {
+						dialog.ShowError(err, w)
+						return
+					}
+					refreshVMList()
+					message := config.Name + " 가상머신을 관리형으로 바꿨습니다."
+					if len(notes) > 0 {
+						message += "\n\n" + strings.Join(notes, "\n")
+					}
+					dialog.ShowInformation("관리형으로 전환", message, w)
+				})
+			}, w)
+		})
+		if config.Managed {
+			managedBtn.Hide()
+		} else if vmRunning(config.Name) {
+			managedBtn.Disable()
+		}
+		// 실행 중인 가상머신만 바꿀 수 있는 설정
+		liveThrottleBtn := widget.NewButton("I/O 제한", func() {
+			ShowLiveThrottleWindow(config.Name)
+			ctrlWin.Close()
+		})
+		liveCDBtn := widget.NewButton("CD/DVD", func() {
+			ShowLiveCDWindow(configDir, config.Name)
+			ctrlWin.Close()
+		})
+		if !vmRunning(config.Name) {
+			liveThrottleBtn.Disable()
+			liveCDBtn.Disable()
+		}
+		// 실행 중이고 게스트 에이전트가 응답하면 게스트 운영체제와 IP 주소 표시
+		guestLabel := widget.NewLabel("")
+		if config.GuestAgent != "" && vmRunning(config.Name) && machineHasPCI(vmMachine(config)) {
+			guestLabel.SetText("게스트 에이전트 확인 중...")
+			go func() {
+				ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+				defer cancel()
+				text, err := guestSummary(ctx, config.GuestAgent)
+				if err != nil {
+					text = "게스트 에이전트 응답 없음"
+				}
+				guestLabel.SetText(text)
+			}()
+		}
 		ctrlWin.SetContent(
 			container.NewVBox(
 				widget.NewLabel(config.Name+" 가상머신"),
-				container.NewHBox(settingBtn, deleteBtn, startBtn, closeBtn),
+				guestLabel,
+				container.NewHBox(settingBtn, snapshotBtn, cloneBtn, exportBtn, templateBtn, checkBtn, deleteBtn, startBtn, liveThrottleBtn, liveCDBtn, managedBtn, closeBtn),
 			),
 		)
 		ctrlWin.Resize(fyne.NewSize(300, 100))
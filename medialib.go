@@ -0,0 +1,434 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	sqdialog "github.com/sqweek/dialog"
+)
+
+// 미디어 라이브러리 항목 (ISO/IMG 파일 하나)
+type mediaItem struct {
+	Path    string
+	Size    int64
+	ModTime int64
+	SHA256  string
+	Label   string // ISO9660 볼륨 레이블
+	Status  string // "", "verified", "mismatch"
+}
+
+// mediaLibrary는 설정 폴더의 media.list에 저장됩니다.
+// (.conf로 저장하면 가상머신 설정으로 읽히므로 확장자를 다르게 둠)
+type mediaLibrary struct {
+	Folders []string
+	Items   []mediaItem
+}
+
+func mediaLibraryPath(configDir string) string {
+	return filepath.Join(configDir, "media.list")
+}
+
+// loadMediaLibrary는 "folder=경로", "media=경로|크기|수정시각|sha256|레이블|상태" 줄을 읽습니다.
+func loadMediaLibrary(configDir string) *mediaLibrary {
+	lib := &mediaLibrary{}
+	data, err := os.ReadFile(mediaLibraryPath(configDir))
+	if err != nil {
+		return lib
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "folder":
+			lib.Folders = append(lib.Folders, value)
+		case "media":
+			fields := strings.Split(value, "|")
+			if len(fields) != 6 {
+				continue
+			}
+			size, _ := strconv.ParseInt(fields[1], 10, 64)
+			modTime, _ := strconv.ParseInt(fields[2], 10, 64)
+			lib.Items = append(lib.Items, mediaItem{
+				Path:    fields[0],
+				Size:    size,
+				ModTime: modTime,
+				SHA256:  fields[3],
+				Label:   fields[4],
+				Status:  fields[5],
+			})
+		}
+	}
+	return lib
+}
+
+func saveMediaLibrary(configDir string, lib *mediaLibrary) error {
+	var b strings.Builder
+	for _, folder := range lib.Folders {
+		b.WriteString("folder=" + folder + "\n")
+	}
+	for _, item := range lib.Items {
+		fmt.Fprintf(&b, "media=%s|%d|%d|%s|%s|%s\n", item.Path, item.Size, item.ModTime, item.SHA256, item.Label, item.Status)
+	}
+	return os.WriteFile(mediaLibraryPath(configDir), []byte(b.String()), 0644)
+}
+
+// readISOVolumeLabel은 ISO9660 기본 볼륨 기술자(섹터 16)의 볼륨 식별자를 읽습니다.
+func readISOVolumeLabel(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	pvd := make([]byte, 72)
+	if _, err := f.ReadAt(pvd, 16*2048); err != nil {
+		return ""
+	}
+	if pvd[0] != 1 || string(pvd[1:6]) != "CD001" {
+		return ""
+	}
+	return strings.TrimSpace(string(pvd[40:72]))
+}
+
+// 볼륨 레이블로 운영체제를 추정
+func detectMediaOS(label string) string {
+	upper := strings.ToUpper(label)
+	patterns := []struct{ prefix, name string }{
+		{"CCCOMA", "Windows"},
+		{"CPBA", "Windows"},
+		{"CENA", "Windows"},
+		{"SSS_X64", "Windows Server"},
+		{"VIRTIO-WIN", "VirtIO 드라이버"},
+		{"UBUNTU", "Ubuntu"},
+		{"DEBIAN", "Debian"},
+		{"FEDORA", "Fedora"},
+		{"ALPINE", "Alpine Linux"},
+		{"CENTOS", "CentOS"},
+		{"ROCKY", "Rocky Linux"},
+		{"ALMALINUX", "AlmaLinux"},
+		{"RHEL", "Red Hat Enterprise Linux"},
+		{"ARCH_", "Arch Linux"},
+		{"OPENSUSE", "openSUSE"},
+		{"SLE-", "SUSE Linux Enterprise"},
+		{"FREEBSD", "FreeBSD"},
+		{"CIDATA", "cloud-init"},
+	}
+	for _, p := range patterns {
+		if strings.HasPrefix(upper, p.prefix) {
+			return p.name
+		}
+	}
+	return ""
+}
+
+// 진행률 보고와 취소를 위해 읽은 바이트 수를 세는 Reader
+type progressReader struct {
+	ctx    context.Context
+	r      io.Reader
+	onRead func(n int)
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	if err := p.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := p.r.Read(b)
+	p.onRead(n)
+	return n, err
+}
+
+func sha256File(ctx context.Context, path string, onRead func(n int)) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, &progressReader{ctx: ctx, r: f, onRead: onRead}); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// scanMediaLibrary는 등록된 폴더의 ISO/IMG 파일을 색인합니다.
+// 크기와 수정 시각이 같은 파일은 이전 해시를 재사용합니다.
+func scanMediaLibrary(ctx context.Context, lib *mediaLibrary, report func(float64)) error {
+	known := make(map[string]mediaItem)
+	for _, item := range lib.Items {
+		known[strings.ToLower(item.Path)] = item
+	}
+
+	var items []mediaItem
+	var toHash []int
+	var totalBytes int64
+	for _, folder := range lib.Folders {
+		filepath.WalkDir(folder, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			ext := strings.ToLower(filepath.Ext(path))
+			if ext != ".iso" && ext != ".img" {
+				return nil
+			}
+			fi, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			item := mediaItem{Path: path, Size: fi.Size(), ModTime: fi.ModTime().Unix()}
+			if old, ok := known[strings.ToLower(path)]; ok && old.Size == item.Size && old.ModTime == item.ModTime {
+				item = old
+			} else {
+				toHash = append(toHash, len(items))
+				totalBytes += item.Size
+			}
+			items = append(items, item)
+			return nil
+		})
+	}
+
+	var doneBytes int64
+	for _, i := range toHash {
+		sum, err := sha256File(ctx, items[i].Path, func(n int) {
+			doneBytes += int64(n)
+			if totalBytes > 0 {
+				report(float64(doneBytes) / float64(totalBytes))
+			}
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			continue
+		}
+		items[i].SHA256 = sum
+		items[i].Label = readISOVolumeLabel(items[i].Path)
+	}
+	lib.Items = items
+	return nil
+}
+
+// parseChecksumFile은 SHA256SUMS 형식("<해시>  <파일명>", "<해시> *<파일명>")과
+// BSD 형식("SHA256 (<파일명>) = <해시>")을 읽어 파일명(소문자) → 해시 맵을 반환합니다.
+func parseChecksumFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	sums := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "SHA256 (") {
+			name, hash, ok := strings.Cut(strings.TrimPrefix(line, "SHA256 ("), ") = ")
+			if ok {
+				sums[strings.ToLower(filepath.Base(name))] = strings.ToLower(hash)
+			}
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 || len(fields[0]) != 64 {
+			continue
+		}
+		name := strings.TrimPrefix(fields[1], "*")
+		sums[strings.ToLower(filepath.Base(name))] = strings.ToLower(fields[0])
+	}
+	return sums, scanner.Err()
+}
+
+// verifyMediaChecksums는 체크섬 파일에 있는 항목의 상태를 갱신하고 확인한 개수를 반환합니다.
+func verifyMediaChecksums(lib *mediaLibrary, sums map[string]string) (verified, mismatched int) {
+	for i, item := range lib.Items {
+		expected, ok := sums[strings.ToLower(filepath.Base(item.Path))]
+		if !ok || item.SHA256 == "" {
+			continue
+		}
+		if item.SHA256 == expected {
+			lib.Items[i].Status = "verified"
+			verified++
+		} else {
+			lib.Items[i].Status = "mismatch"
+			mismatched++
+		}
+	}
+	return verified, mismatched
+}
+
+// 목록 표시용 문자열: "ubuntu.iso  4.7GB  Ubuntu (Ubuntu-Server 24.04)  [검증됨]"
+func mediaItemText(item mediaItem) string {
+	text := filepath.Base(item.Path) + "  " + formatSizeMB(item.Size/(1024*1024))
+	if item.Label != "" {
+		if osName := detectMediaOS(item.Label); osName != "" {
+			text += "  " + osName + " (" + item.Label + ")"
+		} else {
+			text += "  (" + item.Label + ")"
+		}
+	}
+	switch item.Status {
+	case "verified":
+		text += "  [검증됨]"
+	case "mismatch":
+		text += "  [체크섬 불일치]"
+	}
+	return text
+}
+
+// ShowMediaLibraryWindow는 미디어 라이브러리 관리 창을 엽니다. 스캔은 작업 패널에서 진행됩니다.
+func ShowMediaLibraryWindow(configDir string, jobs *jobPanel) {
+	a := fyne.CurrentApp()
+	win := a.NewWindow("미디어 라이브러리")
+	win.Resize(fyne.NewSize(650, 450))
+
+	lib := loadMediaLibrary(configDir)
+	selectedFolder := -1
+
+	folderList := widget.NewList(
+		func() int { return len(lib.Folders) },
+		func() fyne.CanvasObject { return widget.NewLabel("template") },
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			o.(*widget.Label).SetText(lib.Folders[i])
+		},
+	)
+	folderList.OnSelected = func(id widget.ListItemID) {
+		selectedFolder = id
+	}
+
+	itemList := widget.NewList(
+		func() int { return len(lib.Items) },
+		func() fyne.CanvasObject { return widget.NewLabel("template") },
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			o.(*widget.Label).SetText(mediaItemText(lib.Items[i]))
+		},
+	)
+	detailLabel := widget.NewLabel("")
+	detailLabel.Wrapping = fyne.TextWrapWord
+	itemList.OnSelected = func(id widget.ListItemID) {
+		item := lib.Items[id]
+		detailLabel.SetText(item.Path + "\nSHA-256: " + item.SHA256)
+	}
+
+	refresh := func() {
+		selectedFolder = -1
+		folderList.UnselectAll()
+		itemList.UnselectAll()
+		folderList.Refresh()
+		itemList.Refresh()
+		detailLabel.SetText("")
+	}
+	save := func() {
+		if err := saveMediaLibrary(configDir, lib); err != nil {
+			dialog.ShowError(err, win)
+		}
+	}
+
+	addFolderBtn := widget.NewButton("폴더 추가", func() {
+		dir, err := sqdialog.Directory().Title("ISO 폴더 선택").Browse()
+		if err != nil || dir == "" {
+			return
+		}
+		lib.Folders = append(lib.Folders, dir)
+		save()
+		refresh()
+	})
+	removeFolderBtn := widget.NewButton("폴더 제거", func() {
+		if selectedFolder < 0 || selectedFolder >= len(lib.Folders) {
+			return
+		}
+		lib.Folders = append(lib.Folders[:selectedFolder], lib.Folders[selectedFolder+1:]...)
+		save()
+		refresh()
+	})
+	var scanBtn *widget.Button
+	scanBtn = widget.NewButton("스캔", func() {
+		scanBtn.Disable()
+		scanned := &mediaLibrary{Folders: lib.Folders, Items: lib.Items}
+		jobs.start("미디어 라이브러리 스캔 중", func(ctx context.Context, report func(float64)) error {
+			return scanMediaLibrary(ctx, scanned, report)
+		}, func(err error) {
+			scanBtn.Enable()
+			if err != nil {
+				dialog.ShowError(err, win)
+				return
+			}
+			lib.Items = scanned.Items
+			save()
+			refresh()
+		})
+	})
+	verifyBtn := widget.NewButton("체크섬 확인", func() {
+		path, err := sqdialog.File().Title("체크섬 파일 선택 (SHA256SUMS)").Load()
+		if err != nil || path == "" {
+			return
+		}
+		sums, err := parseChecksumFile(path)
+		if err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		verified, mismatched := verifyMediaChecksums(lib, sums)
+		save()
+		refresh()
+		if verified == 0 && mismatched == 0 {
+			dialog.ShowError(errors.New("체크섬 파일과 일치하는 항목이 없습니다."), win)
+			return
+		}
+		dialog.ShowInformation("체크섬 확인", fmt.Sprintf("검증됨 %d개, 불일치 %d개", verified, mismatched), win)
+	})
+	closeBtn := widget.NewButton("닫기", func() {
+		win.Close()
+	})
+
+	folderPanel := container.NewBorder(
+		widget.NewLabel("폴더"),
+		container.NewHBox(addFolderBtn, removeFolderBtn),
+		nil, nil,
+		folderList,
+	)
+	itemPanel := container.NewBorder(widget.NewLabel("미디어"), detailLabel, nil, nil, itemList)
+	split := container.NewVSplit(folderPanel, itemPanel)
+	split.SetOffset(0.25)
+
+	win.SetContent(container.NewBorder(nil, container.NewHBox(scanBtn, verifyBtn, closeBtn), nil, nil, split))
+	win.CenterOnScreen()
+	win.Show()
+}
+
+// pickMediaItem은 라이브러리에서 ISO를 골라 onPick으로 경로를 넘깁니다.
+func pickMediaItem(configDir string, win fyne.Window, onPick func(path string)) {
+	lib := loadMediaLibrary(configDir)
+	if len(lib.Items) == 0 {
+		dialog.ShowError(errors.New("미디어 라이브러리가 비어 있습니다. 메인 창에서 폴더를 추가하고 스캔하십시오."), win)
+		return
+	}
+	var options []string
+	paths := make(map[string]string)
+	for _, item := range lib.Items {
+		text := mediaItemText(item)
+		options = append(options, text)
+		paths[text] = item.Path
+	}
+	mediaSelect := widget.NewSelect(options, nil)
+	mediaSelect.PlaceHolder = "ISO 선택"
+	dialog.ShowForm("미디어 라이브러리", "선택", "취소",
+		[]*widget.FormItem{widget.NewFormItem("미디어", mediaSelect)},
+		func(ok bool) {
+			if ok && mediaSelect.Selected != "" {
+				onPick(paths[mediaSelect.Selected])
+			}
+		}, win)
+}
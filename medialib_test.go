@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseChecksumFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "SHA256SUMS")
+	writeTestFile(t, path, ""+
+		"# 주석과 빈 줄은 무시\n\n"+
+		"5E8C6D6A7B3F1C2D4E5F60718293A4B5C6D7E8F9011223344556677889900AAB  ubuntu-24.04-desktop-amd64.iso\n"+
+		"0000000000000000000000000000000000000000000000000000000000000001 *Win11_Korean_x64.ISO\r\n"+
+		"SHA256 (FreeBSD-14.1-RELEASE-amd64-disc1.iso) = 00000000000000000000000000000000000000000000000000000000000000ff\n"+
+		"SHA512 (FreeBSD-14.1-RELEASE-amd64-disc1.iso) = ignored\n"+
+		"abcdef  too-short.iso\n")
+	sums, err := parseChecksumFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"ubuntu-24.04-desktop-amd64.iso":       "5e8c6d6a7b3f1c2d4e5f60718293a4b5c6d7e8f9011223344556677889900aab",
+		"win11_korean_x64.iso":                 "0000000000000000000000000000000000000000000000000000000000000001",
+		"freebsd-14.1-release-amd64-disc1.iso": "00000000000000000000000000000000000000000000000000000000000000ff",
+	}
+	if !reflect.DeepEqual(sums, want) {
+		t.Errorf("parseChecksumFile =\n%v\nwant\n%v", sums, want)
+	}
+}
+
+func TestMediaLibrary(t *testing.T) {
+	configDir, folder := t.TempDir(), t.TempDir()
+	iso := filepath.Join(folder, "linux.iso")
+	writeTestFile(t, iso, "hello")
+	writeTestFile(t, filepath.Join(folder, "notes.txt"), "not media")
+
+	lib := &mediaLibrary{Folders: []string{folder}}
+	if err := scanMediaLibrary(context.Background(), lib, func(float64) {}); err != nil {
+		t.Fatal(err)
+	}
+	const helloSum = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	if len(lib.Items) != 1 || lib.Items[0].Path != iso || lib.Items[0].Size != 5 || lib.Items[0].SHA256 != helloSum {
+		t.Fatalf("Items = %+v", lib.Items)
+	}
+
+	verified, mismatched := verifyMediaChecksums(lib, map[string]string{"linux.iso": helloSum})
+	if verified != 1 || mismatched != 0 || lib.Items[0].Status != "verified" {
+		t.Errorf("verified %d, mismatched %d, item %+v", verified, mismatched, lib.Items[0])
+	}
+	verifyMediaChecksums(lib, map[string]string{"linux.iso": "00"})
+	if lib.Items[0].Status != "mismatch" {
+		t.Errorf("Status = %q, want mismatch", lib.Items[0].Status)
+	}
+
+	if err := saveMediaLibrary(configDir, lib); err != nil {
+		t.Fatal(err)
+	}
+	loaded := loadMediaLibrary(configDir)
+	if !reflect.DeepEqual(loaded, lib) {
+		t.Errorf("loadMediaLibrary =\n%+v\nwant\n%+v", loaded, lib)
+	}
+
+	// 크기와 수정 시각이 그대로면 다시 해시하지 않고 이전 값을 씀
+	loaded.Items[0].SHA256 = "cached"
+	if err := scanMediaLibrary(context.Background(), loaded, func(float64) {}); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Items[0].SHA256 != "cached" {
+		t.Errorf("SHA256 = %q, 바뀌지 않은 파일을 다시 해시했습니다", loaded.Items[0].SHA256)
+	}
+}
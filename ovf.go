@@ -0,0 +1,695 @@
+package main
+
+import (
+	"archive/tar"
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// OVF 설명자 중 가져오기에 쓰는 부분 (네임스페이스는 무시하고 요소 이름으로 읽음)
+type ovfEnvelope struct {
+	XMLName    xml.Name `xml:"Envelope"`
+	References []struct {
+		ID   string `xml:"id,attr"`
+		Href string `xml:"href,attr"`
+	} `xml:"References>File"`
+	Disks []struct {
+		DiskID   string `xml:"diskId,attr"`
+		FileRef  string `xml:"fileRef,attr"`
+		Capacity string `xml:"capacity,attr"`
+		Units    string `xml:"capacityAllocationUnits,attr"`
+		Format   string `xml:"format,attr"`
+	} `xml:"DiskSection>Disk"`
+	Systems []ovfSystem `xml:"VirtualSystem"`
+}
+
+type ovfSystem struct {
+	ID    string    `xml:"id,attr"`
+	Name  string    `xml:"Name"`
+	Items []ovfItem `xml:"VirtualHardwareSection>Item"`
+	// OVF 2.0은 디스크와 NIC를 별도 요소로 씀
+	StorageItems  []ovfItem `xml:"VirtualHardwareSection>StorageItem"`
+	EthernetItems []ovfItem `xml:"VirtualHardwareSection>EthernetPortItem"`
+}
+
+type ovfItem struct {
+	InstanceID      string `xml:"InstanceID"`
+	ResourceType    int    `xml:"ResourceType"`
+	ResourceSubType string `xml:"ResourceSubType"`
+	VirtualQuantity int64  `xml:"VirtualQuantity"`
+	AllocationUnits string `xml:"AllocationUnits"`
+	HostResource    string `xml:"HostResource"`
+	Parent          string `xml:"Parent"`
+	Address         string `xml:"Address"`
+	ElementName     string `xml:"ElementName"`
+}
+
+// CIM ResourceType 값
+const (
+	ovfResourceCPU      = 3
+	ovfResourceMemory   = 4
+	ovfResourceIDE      = 5
+	ovfResourceSCSI     = 6
+	ovfResourceEthernet = 10
+	ovfResourceCDDrive  = 15
+	ovfResourceDVDDrive = 16
+	ovfResourceDisk     = 17
+	ovfResourceSATA     = 20
+)
+
+// "byte * 2^20", "MegaBytes" 같은 할당 단위를 바이트 수로 (비어 있으면 def)
+func ovfUnitBytes(units string, def int64) int64 {
+	u := strings.ToLower(strings.ReplaceAll(units, " ", ""))
+	if _, exp, ok := strings.Cut(u, "2^"); ok {
+		if n, err := strconv.Atoi(exp); err == nil && n < 63 {
+			return 1 << n
+		}
+	}
+	switch {
+	case u == "":
+		return def
+	case strings.HasPrefix(u, "k"):
+		return 1 << 10
+	case strings.HasPrefix(u, "m"):
+		return 1 << 20
+	case strings.HasPrefix(u, "g"):
+		return 1 << 30
+	case strings.HasPrefix(u, "t"):
+		return 1 << 40
+	}
+	return 1
+}
+
+// fileDigest는 파일의 해시를 16진수 문자열로 계산합니다.
+func fileDigest(ctx context.Context, path string, h hash.Hash, onRead func(n int)) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if _, err := io.Copy(h, &progressReader{ctx: ctx, r: f, onRead: onRead}); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// verifyOVFManifest는 .mf 파일의 "SHA256(파일)= 해시" 줄로 같은 폴더의 파일을 확인합니다.
+func verifyOVFManifest(ctx context.Context, manifestPath string) error {
+	f, err := os.Open(manifestPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	dir := filepath.Dir(manifestPath)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		algo, rest, ok := strings.Cut(line, "(")
+		if !ok {
+			continue
+		}
+		name, sum, ok := strings.Cut(rest, ")=")
+		if !ok {
+			continue
+		}
+		var h hash.Hash
+		switch strings.ToUpper(algo) {
+		case "SHA1":
+			h = sha1.New()
+		case "SHA256":
+			h = sha256.New()
+		default:
+			continue
+		}
+		got, err := fileDigest(ctx, filepath.Join(dir, filepath.Base(name)), h, func(int) {})
+		if err != nil {
+			return err
+		}
+		if !strings.EqualFold(got, strings.TrimSpace(sum)) {
+			return fmt.Errorf("%s 파일의 체크섬이 매니페스트와 다릅니다.", name)
+		}
+	}
+	return scanner.Err()
+}
+
+// extractOVA는 OVA(tar)의 파일을 dir에 풀고 OVF 설명자 경로를 돌려줍니다.
+func extractOVA(ctx context.Context, ovaPath, dir string, report func(float64)) (string, error) {
+	f, err := os.Open(ovaPath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	var read int64
+	tr := tar.NewReader(&progressReader{ctx: ctx, r: f, onRead: func(n int) {
+		read += int64(n)
+		report(float64(read) / float64(info.Size()))
+	}})
+	ovfPath := ""
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("OVA 파일을 읽을 수 없습니다: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		// 경로 조작을 막기 위해 파일 이름만 사용
+		path := filepath.Join(dir, filepath.Base(hdr.Name))
+		out, err := os.Create(path)
+		if err != nil {
+			return "", err
+		}
+		_, err = io.Copy(out, tr)
+		out.Close()
+		if err != nil {
+			return "", err
+		}
+		if strings.EqualFold(filepath.Ext(path), ".ovf") && ovfPath == "" {
+			ovfPath = path
+		}
+	}
+	if ovfPath == "" {
+		return "", errors.New("OVA 안에 OVF 설명자가 없습니다.")
+	}
+	return ovfPath, nil
+}
+
+// importOVF는 OVF/OVA를 읽어 디스크를 destDir에 diskType 형식으로 변환하고 설정을 만듭니다.
+// 설정 저장은 호출한 쪽에서 합니다 (saveImportedConfig).
+func importOVF(ctx context.Context, configDir, srcPath, name, destDir, diskType string, report func(float64)) (VMConfig, []string, error) {
+	var notes []string
+	_, statErr := os.Stat(destDir)
+	createdDir := os.IsNotExist(statErr)
+	if err := os.MkdirAll(destDir, os.ModePerm); err != nil {
+		return VMConfig{}, nil, err
+	}
+	var created []string
+	tmp := ""
+	cleanup := func() {
+		if tmp != "" {
+			os.RemoveAll(tmp)
+		}
+		for _, path := range created {
+			os.Remove(path)
+		}
+		if createdDir {
+			os.Remove(destDir)
+		}
+	}
+
+	// OVA는 임시 폴더에 풀어서 읽고, 변환이 끝나면 지움 (풀기 30%, 변환 70%)
+	ovfPath := srcPath
+	extractWeight := 0.0
+	if strings.EqualFold(filepath.Ext(srcPath), ".ova") {
+		extractWeight = 0.3
+		var err error
+		tmp, err = os.MkdirTemp(destDir, "ova-")
+		if err != nil {
+			cleanup()
+			return VMConfig{}, nil, err
+		}
+		defer os.RemoveAll(tmp)
+		ovfPath, err = extractOVA(ctx, srcPath, tmp, func(p float64) { report(p * extractWeight) })
+		if err != nil {
+			cleanup()
+			return VMConfig{}, nil, err
+		}
+	}
+	srcDir := filepath.Dir(ovfPath)
+	if mf := strings.TrimSuffix(ovfPath, filepath.Ext(ovfPath)) + ".mf"; fileExists(mf) {
+		if err := verifyOVFManifest(ctx, mf); err != nil {
+			cleanup()
+			return VMConfig{}, nil, err
+		}
+	}
+
+	data, err := os.ReadFile(ovfPath)
+	if err != nil {
+		cleanup()
+		return VMConfig{}, nil, err
+	}
+	var env ovfEnvelope
+	if err := xml.Unmarshal(data, &env); err != nil {
+		cleanup()
+		return VMConfig{}, nil, fmt.Errorf("OVF 설명자를 읽을 수 없습니다: %w", err)
+	}
+	if len(env.Systems) == 0 {
+		cleanup()
+		return VMConfig{}, nil, errors.New("OVF 설명자에 가상 시스템이 없습니다.")
+	}
+	sys := env.Systems[0]
+	if len(env.Systems) > 1 {
+		notes = append(notes, fmt.Sprintf("가상 시스템 %d개 중 첫 번째(%s)만 가져왔습니다.", len(env.Systems), sys.Name))
+	}
+
+	config := VMConfig{Name: name}
+	if config.Name == "" {
+		config.Name = sys.Name
+	}
+	if config.Name == "" {
+		config.Name = sys.ID
+	}
+	if err := checkCloneName(configDir, config.Name); err != nil {
+		cleanup()
+		return VMConfig{}, nil, err
+	}
+
+	items := append(append(append([]ovfItem{}, sys.Items...), sys.StorageItems...), sys.EthernetItems...)
+	controllers := make(map[string]ovfItem)
+	for _, item := range items {
+		controllers[item.InstanceID] = item
+	}
+	// 디스크와 CD의 버스는 상위 컨트롤러 종류로 정함
+	busOf := func(item ovfItem) string {
+		switch controllers[item.Parent].ResourceType {
+		case ovfResourceIDE:
+			return "ide"
+		case ovfResourceSATA:
+			return "ahci"
+		case ovfResourceSCSI:
+			return "virtio-scsi"
+		}
+		return ""
+	}
+	hrefOf := func(fileRef string) string {
+		for _, ref := range env.References {
+			if ref.ID == fileRef {
+				return ref.Href
+			}
+		}
+		return ""
+	}
+
+	var diskItems []ovfItem
+	var cdroms []cdromConfig
+	scsi := false
+	nics := 0
+	for _, item := range items {
+		switch item.ResourceType {
+		case ovfResourceCPU:
+			config.CPUCores = strconv.FormatInt(item.VirtualQuantity, 10)
+		case ovfResourceMemory:
+			mb := item.VirtualQuantity * ovfUnitBytes(item.AllocationUnits, 1<<20) / (1 << 20)
+			if mb%1024 == 0 {
+				config.RAM = strconv.FormatInt(mb/1024, 10) + "GB"
+			} else {
+				config.RAM = strconv.FormatInt(mb, 10) + "MB"
+			}
+		case ovfResourceDisk:
+			diskItems = append(diskItems, item)
+			scsi = scsi || busOf(item) == "virtio-scsi"
+		case ovfResourceCDDrive, ovfResourceDVDDrive:
+			cdroms = append(cdroms, cdromConfig{Bus: busOf(item)})
+		case ovfResourceEthernet:
+			nics++
+			if nics == 1 {
+				config.Network = "user"
+				config.MAC = ovfMACAddress(item.Address)
+			}
+		}
+	}
+	if nics == 0 {
+		config.Network = "none"
+	} else if nics > 1 {
+		notes = append(notes, fmt.Sprintf("나머지 네트워크 어댑터 %d개는 가져오지 않았습니다.", nics-1))
+	}
+	if scsi {
+		notes = append(notes, "SCSI 디스크는 virtio-scsi로 연결됩니다. 게스트에 virtio 드라이버가 필요합니다.")
+	}
+	if len(cdroms) > 0 {
+		notes = append(notes, "CD/DVD 드라이브는 빈 드라이브로 만들었습니다.")
+	}
+
+	var disks []diskConfig
+	for i, item := range diskItems {
+		diskID := item.HostResource[strings.LastIndex(item.HostResource, "/")+1:]
+		var capacity int64
+		fileRef, format := "", ""
+		for _, d := range env.Disks {
+			if d.DiskID == diskID {
+				fileRef, format = d.FileRef, d.Format
+				capacity, _ = strconv.ParseInt(d.Capacity, 10, 64)
+				capacity *= ovfUnitBytes(d.Units, 1)
+			}
+		}
+		newPath := filepath.Join(destDir, fmt.Sprintf("%s-disk%d%s", config.Name, i+1, diskFileExt(diskType)))
+		if fileExists(newPath) {
+			cleanup()
+			return VMConfig{}, nil, fmt.Errorf("%s 파일이 이미 있습니다.", newPath)
+		}
+		created = append(created, newPath)
+		progress := func(percent float64) {
+			report(extractWeight + (1-extractWeight)*(float64(i)+percent/100)/float64(len(diskItems)))
+		}
+
+		if href := hrefOf(fileRef); href != "" {
+			srcFormat := "vmdk"
+			if !strings.Contains(strings.ToLower(format), "vmdk") {
+				if t, ok := importDiskType("", href); ok {
+					srcFormat = qemuImgFormat(t)
+				}
+			}
+			if err := convertDiskImage(ctx, filepath.Join(srcDir, filepath.Base(href)), srcFormat, newPath, qemuImgFormat(diskType), progress); err != nil {
+				cleanup()
+				return VMConfig{}, nil, err
+			}
+		} else {
+			// 파일 없이 크기만 있는 디스크는 빈 디스크로 만듦
+			if capacity <= 0 {
+				cleanup()
+				return VMConfig{}, nil, fmt.Errorf("디스크 %s의 크기를 알 수 없습니다.", diskID)
+			}
+			if err := runQemuImg("create", "-f", qemuImgFormat(diskType), newPath, strconv.FormatInt(capacity, 10)); err != nil {
+				cleanup()
+				return VMConfig{}, nil, err
+			}
+		}
+		disks = append(disks, diskConfig{Type: diskType, Path: newPath, Bus: busOf(item), Capacity: importDiskCapacity(newPath)})
+	}
+	config.Disk = formatDiskConfigs(disks)
+	config.CDROM = formatCDROMConfigs(cdroms)
+	config.Machine = importedMachine(config)
+	if len(disks) == 0 && createdDir {
+		os.Remove(destDir)
+	}
+	return config, notes, nil
+}
+
+// VirtualBox는 MAC을 콜론 없이 씀 (080027D1E0F4)
+func ovfMACAddress(addr string) string {
+	if len(addr) != 12 || strings.Contains(addr, ":") {
+		return addr
+	}
+	var parts []string
+	for i := 0; i < 12; i += 2 {
+		parts = append(parts, addr[i:i+2])
+	}
+	return strings.ToLower(strings.Join(parts, ":"))
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// OVF의 디스크 컨트롤러 (버스별로 하나씩)
+type ovfController struct {
+	id      int
+	rtype   int
+	subtype string
+	name    string
+	used    int // 연결된 장치 수 (AddressOnParent)
+}
+
+// 속성값과 텍스트 모두에 쓸 수 있도록 XML 특수문자를 이스케이프
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// writeOVFItem은 VirtualHardwareSection의 Item 하나를 씁니다.
+func writeOVFItem(b *strings.Builder, fields ...string) {
+	b.WriteString("      <Item>\n")
+	for i := 0; i+1 < len(fields); i += 2 {
+		b.WriteString("        <rasd:" + fields[i] + ">" + xmlEscape(fields[i+1]) + "</rasd:" + fields[i] + ">\n")
+	}
+	b.WriteString("      </Item>\n")
+}
+
+// exportOVA는 설정과 디스크를 OVF 1.0 설명자, 매니페스트(SHA256), streamOptimized VMDK로 묶어 OVA로 저장합니다.
+func exportOVA(ctx context.Context, config VMConfig, ovaPath string, report func(float64)) ([]string, error) {
+	var notes []string
+	machine := vmMachine(config)
+	disks := parseDiskConfigs(config.Disk)
+	for _, d := range disks {
+		if d.Encrypted {
+			return nil, fmt.Errorf("%s: 암호화된 디스크는 OVA로 내보낼 수 없습니다.", d.Path)
+		}
+	}
+
+	tmp, err := os.MkdirTemp("", "goqemu-ova-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	// 디스크 변환 (진행률 80%)
+	type ovfDiskFile struct {
+		name     string
+		capacity int64
+		bus      string
+	}
+	var files []ovfDiskFile
+	for i, d := range disks {
+		name := fmt.Sprintf("%s-disk%d.vmdk", config.Name, i+1)
+		err := convertDiskImage(ctx, d.Path, qemuImgFormat(d.Type), filepath.Join(tmp, name), "vmdk", func(percent float64) {
+			report(0.8 * (float64(i) + percent/100) / float64(len(disks)))
+		}, "subformat=streamOptimized")
+		if err != nil {
+			return nil, err
+		}
+		var capacity int64
+		if info, err := getDiskImageInfo(d.Path); err == nil {
+			capacity = info.VirtualSize
+		} else if mb, err := strconv.ParseInt(d.Capacity, 10, 64); err == nil {
+			capacity = mb << 20
+		}
+		files = append(files, ovfDiskFile{name: name, capacity: capacity, bus: d.bus(machine)})
+	}
+
+	// 버스 → OVF 컨트롤러 (virtio-blk, nvme는 OVF에 없으므로 SATA로)
+	controllers := make(map[string]*ovfController)
+	nextID := 3
+	controllerFor := func(bus string) *ovfController {
+		switch bus {
+		case "ide":
+		case "virtio-scsi":
+		case "ahci":
+		default:
+			notes = append(notes, bus+" 디스크는 SATA 컨트롤러에 연결해 내보냈습니다.")
+			bus = "ahci"
+		}
+		if c, ok := controllers[bus]; ok {
+			return c
+		}
+		c := &ovfController{id: nextID}
+		nextID++
+		switch bus {
+		case "ide":
+			c.rtype, c.name = ovfResourceIDE, "IDE Controller"
+		case "virtio-scsi":
+			c.rtype, c.subtype, c.name = ovfResourceSCSI, "VirtualSCSI", "SCSI Controller"
+		default:
+			c.rtype, c.subtype, c.name = ovfResourceSATA, "AHCI", "SATA Controller"
+		}
+		controllers[bus] = c
+		return c
+	}
+
+	var hw strings.Builder
+	cpus := 1
+	for _, v := range []string{config.CPUSockets, config.CPUCores, config.CPUThreads} {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cpus *= n
+		}
+	}
+	writeOVFItem(&hw, "AllocationUnits", "hertz * 10^6", "Description", "Number of Virtual CPUs",
+		"ElementName", fmt.Sprintf("%d virtual CPU(s)", cpus), "InstanceID", "1", "ResourceType", "3",
+		"VirtualQuantity", strconv.Itoa(cpus))
+	memMB := int64(0)
+	if mem := qemuMemorySize(config.RAM); mem != "" {
+		memMB, _ = strconv.ParseInt(mem[:len(mem)-1], 10, 64)
+		if strings.HasSuffix(mem, "G") {
+			memMB *= 1024
+		}
+	}
+	writeOVFItem(&hw, "AllocationUnits", "byte * 2^20", "Description", "Memory Size",
+		"ElementName", fmt.Sprintf("%dMB of memory", memMB), "InstanceID", "2", "ResourceType", "4",
+		"VirtualQuantity", strconv.FormatInt(memMB, 10))
+
+	// 장치는 컨트롤러 뒤에 쓰기 위해 모아 둠
+	var devices strings.Builder
+	for i, f := range files {
+		c := controllerFor(f.bus)
+		writeOVFItem(&devices, "AddressOnParent", strconv.Itoa(c.used), "ElementName", fmt.Sprintf("Hard Disk %d", i+1),
+			"HostResource", fmt.Sprintf("ovf:/disk/vmdisk%d", i+1), "InstanceID", strconv.Itoa(100+i),
+			"Parent", strconv.Itoa(c.id), "ResourceType", "17")
+		c.used++
+	}
+	cdroms := parseCDROMConfigs(config.CDROM)
+	for i, cd := range cdroms {
+		c := controllerFor(cd.bus(machine))
+		writeOVFItem(&devices, "AddressOnParent", strconv.Itoa(c.used), "AutomaticAllocation", "false",
+			"ElementName", fmt.Sprintf("CD/DVD Drive %d", i+1), "InstanceID", strconv.Itoa(200+i),
+			"Parent", strconv.Itoa(c.id), "ResourceType", "15")
+		c.used++
+		if cd.Path != "" {
+			notes = append(notes, cd.Path+" ISO는 OVA에 포함하지 않았습니다.")
+		}
+	}
+	if config.Network != "none" && nicModel(machine) != "" {
+		fields := []string{"AutomaticAllocation", "true", "Connection", "NAT", "ElementName", "Ethernet adapter 1",
+			"InstanceID", "300", "ResourceSubType", "E1000", "ResourceType", "10"}
+		if config.MAC != "" {
+			fields = append([]string{"Address", config.MAC}, fields...)
+		}
+		writeOVFItem(&devices, fields...)
+	}
+	for _, bus := range []string{"ide", "ahci", "virtio-scsi"} {
+		if c, ok := controllers[bus]; ok {
+			fields := []string{"Address", "0", "ElementName", c.name, "InstanceID", strconv.Itoa(c.id)}
+			if c.subtype != "" {
+				fields = append(fields, "ResourceSubType", c.subtype)
+			}
+			writeOVFItem(&hw, append(fields, "ResourceType", strconv.Itoa(c.rtype))...)
+		}
+	}
+	hw.WriteString(devices.String())
+
+	if config.Kernel != "" {
+		notes = append(notes, "직접 커널 부팅 설정은 내보내지 않았습니다.")
+	}
+	if config.ExtraArgs != "" {
+		notes = append(notes, "추가 인자는 내보내지 않았습니다.")
+	}
+	if config.Firmware == "uefi" || config.TPM != "" {
+		notes = append(notes, "UEFI, TPM 설정은 OVF에 담기지 않습니다. 가져온 뒤 다시 켜세요.")
+	}
+
+	// OVF 설명자
+	var ovf strings.Builder
+	ovf.WriteString(xml.Header)
+	ovf.WriteString(`<Envelope xmlns="http://schemas.dmtf.org/ovf/envelope/1" xmlns:ovf="http://schemas.dmtf.org/ovf/envelope/1"` +
+		` xmlns:rasd="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_ResourceAllocationSettingData"` +
+		` xmlns:vssd="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_VirtualSystemSettingData">` + "\n")
+	ovf.WriteString("  <References>\n")
+	for i, f := range files {
+		info, err := os.Stat(filepath.Join(tmp, f.name))
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&ovf, "    <File ovf:href=\"%s\" ovf:id=\"file%d\" ovf:size=\"%d\"/>\n", xmlEscape(f.name), i+1, info.Size())
+	}
+	ovf.WriteString("  </References>\n")
+	ovf.WriteString("  <DiskSection>\n    <Info>Virtual disk information</Info>\n")
+	for i, f := range files {
+		fmt.Fprintf(&ovf, "    <Disk ovf:capacity=\"%d\" ovf:capacityAllocationUnits=\"byte\" ovf:diskId=\"vmdisk%d\" ovf:fileRef=\"file%d\""+
+			" ovf:format=\"http://www.vmware.com/interfaces/specifications/vmdk.html#streamOptimized\"/>\n", f.capacity, i+1, i+1)
+	}
+	ovf.WriteString("  </DiskSection>\n")
+	ovf.WriteString("  <NetworkSection>\n    <Info>The list of logical networks</Info>\n" +
+		"    <Network ovf:name=\"NAT\">\n      <Description>The NAT network</Description>\n    </Network>\n  </NetworkSection>\n")
+	fmt.Fprintf(&ovf, "  <VirtualSystem ovf:id=\"%s\">\n    <Info>A virtual machine</Info>\n    <Name>%s</Name>\n", xmlEscape(config.Name), xmlEscape(config.Name))
+	ovf.WriteString("    <OperatingSystemSection ovf:id=\"1\">\n      <Info>The kind of installed guest operating system</Info>\n" +
+		"      <Description>Other</Description>\n    </OperatingSystemSection>\n")
+	// VMware와 VirtualBox가 모두 읽을 수 있도록 vmx-07 가상 하드웨어로 표시
+	ovf.WriteString("    <VirtualHardwareSection>\n      <Info>Virtual hardware requirements</Info>\n      <System>\n" +
+		"        <vssd:ElementName>Virtual Hardware Family</vssd:ElementName>\n        <vssd:InstanceID>0</vssd:InstanceID>\n" +
+		"        <vssd:VirtualSystemIdentifier>" + xmlEscape(config.Name) + "</vssd:VirtualSystemIdentifier>\n" +
+		"        <vssd:VirtualSystemType>vmx-07</vssd:VirtualSystemType>\n      </System>\n")
+	ovf.WriteString(hw.String())
+	ovf.WriteString("    </VirtualHardwareSection>\n  </VirtualSystem>\n</Envelope>\n")
+
+	base := strings.TrimSuffix(filepath.Base(ovaPath), filepath.Ext(ovaPath))
+	ovfName := base + ".ovf"
+	if err := os.WriteFile(filepath.Join(tmp, ovfName), []byte(ovf.String()), 0644); err != nil {
+		return nil, err
+	}
+
+	// 매니페스트
+	names := []string{ovfName}
+	for _, f := range files {
+		names = append(names, f.name)
+	}
+	var mf strings.Builder
+	for _, name := range names {
+		sum, err := fileDigest(ctx, filepath.Join(tmp, name), sha256.New(), func(int) {})
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&mf, "SHA256(%s)= %s\n", name, sum)
+	}
+	mfName := base + ".mf"
+	if err := os.WriteFile(filepath.Join(tmp, mfName), []byte(mf.String()), 0644); err != nil {
+		return nil, err
+	}
+
+	// OVA는 OVF 설명자가 맨 앞에 오는 tar (진행률 20%)
+	names = append([]string{names[0], mfName}, names[1:]...)
+	var total, written int64
+	for _, name := range names {
+		if info, err := os.Stat(filepath.Join(tmp, name)); err == nil {
+			total += info.Size()
+		}
+	}
+	if err := writeTar(ctx, ovaPath, tmp, names, func(n int) {
+		written += int64(n)
+		report(0.8 + 0.2*float64(written)/float64(total))
+	}); err != nil {
+		os.Remove(ovaPath)
+		return nil, err
+	}
+	return notes, nil
+}
+
+// writeTar는 dir 안의 names 파일을 순서대로 USTAR 형식 tar로 묶습니다.
+func writeTar(ctx context.Context, path, dir string, names []string, onRead func(n int)) error {
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	tw := tar.NewWriter(out)
+	for _, name := range names {
+		if _, err := addTarFile(ctx, tw, filepath.Join(dir, name), name, onRead); err != nil {
+			out.Close()
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// addTarFile은 path 파일을 name으로 tar에 넣고 넣은 내용의 SHA256을 돌려줍니다.
+func addTarFile(ctx context.Context, tw *tar.Writer, path, name string, onRead func(n int)) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+	hdr := &tar.Header{Name: name, Mode: 0644, Size: info.Size(), ModTime: info.ModTime(), Format: tar.FormatUSTAR}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tw, h), &progressReader{ctx: ctx, r: f, onRead: onRead}); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOVARoundTrip(t *testing.T) {
+	if _, err := exec.LookPath("qemu-img"); err != nil {
+		t.Skip("qemu-img이 없습니다")
+	}
+	src := t.TempDir()
+	osDisk, dataDisk := filepath.Join(src, "os.qcow2"), filepath.Join(src, "data.img")
+	if err := runQemuImg("create", "-f", "qcow2", osDisk, "64M"); err != nil {
+		t.Fatal(err)
+	}
+	if err := runQemuImg("create", "-f", "raw", dataDisk, "32M"); err != nil {
+		t.Fatal(err)
+	}
+	config := VMConfig{
+		Name: "rt", RAM: "2GB", Machine: "q35", CPUSockets: "2", CPUCores: "2",
+		Disk: formatDiskConfigs([]diskConfig{
+			{Type: "QCOW2", Path: osDisk, Bus: "ahci"},
+			{Type: "RAW", Path: dataDisk, Bus: "virtio-scsi"},
+		}),
+		CDROM:   formatCDROMConfigs([]cdromConfig{{Bus: "ahci", Path: filepath.Join(src, "install.iso")}}),
+		Network: "user", MAC: "52:54:00:12:34:56",
+	}
+	ova := filepath.Join(t.TempDir(), "rt.ova")
+	notes, err := exportOVA(context.Background(), config, ova, func(float64) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(strings.Join(notes, "\n"), "install.iso") {
+		t.Errorf("export notes = %q", notes)
+	}
+
+	dest := filepath.Join(t.TempDir(), "copy")
+	got, _, err := importOVF(context.Background(), t.TempDir(), ova, "copy", dest, "QCOW2", func(float64) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := VMConfig{
+		Name: "copy", RAM: "2GB", Machine: "q35", CPUCores: "4",
+		Disk: formatDiskConfigs([]diskConfig{
+			{Type: "QCOW2", Path: filepath.Join(dest, "copy-disk1.qcow2"), Capacity: "64", Bus: "ahci"},
+			{Type: "QCOW2", Path: filepath.Join(dest, "copy-disk2.qcow2"), Capacity: "32", Bus: "virtio-scsi"},
+		}),
+		// ISO는 묶지 않으므로 빈 드라이브
+		CDROM:   formatCDROMConfigs([]cdromConfig{{Bus: "ahci"}}),
+		Network: "user", MAC: "52:54:00:12:34:56",
+	}
+	if got != want {
+		t.Errorf("importOVF =\n%+v\nwant\n%+v", got, want)
+	}
+	if files, _ := filepath.Glob(filepath.Join(dest, "ova-*")); len(files) != 0 {
+		t.Errorf("임시 폴더가 남았습니다: %q", files)
+	}
+}
+
+// VirtualBox가 내보낸 것 같은 디스크 없는 OVF (qemu-img 없이 확인)
+func TestImportOVFWithoutDisks(t *testing.T) {
+	dir := t.TempDir()
+	ovf := filepath.Join(dir, "box.ovf")
+	writeTestFile(t, ovf, `<?xml version="1.0"?>
+<Envelope ovf:version="2.0" xmlns="http://schemas.dmtf.org/ovf/envelope/2" xmlns:ovf="http://schemas.dmtf.org/ovf/envelope/2"
+    xmlns:rasd="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_ResourceAllocationSettingData"
+    xmlns:epasd="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_EthernetPortAllocationSettingData">
+  <References/>
+  <VirtualSystem ovf:id="box">
+    <VirtualHardwareSection>
+      <Item><rasd:InstanceID>1</rasd:InstanceID><rasd:ResourceType>3</rasd:ResourceType><rasd:VirtualQuantity>2</rasd:VirtualQuantity></Item>
+      <Item><rasd:AllocationUnits>MegaBytes</rasd:AllocationUnits><rasd:InstanceID>2</rasd:InstanceID><rasd:ResourceType>4</rasd:ResourceType><rasd:VirtualQuantity>1536</rasd:VirtualQuantity></Item>
+      <Item><rasd:InstanceID>3</rasd:InstanceID><rasd:ResourceType>5</rasd:ResourceType></Item>
+      <Item><rasd:InstanceID>4</rasd:InstanceID><rasd:Parent>3</rasd:Parent><rasd:ResourceType>15</rasd:ResourceType></Item>
+      <EthernetPortItem><epasd:Address>080027D1E0F4</epasd:Address><epasd:InstanceID>5</epasd:InstanceID><epasd:ResourceType>10</epasd:ResourceType></EthernetPortItem>
+      <EthernetPortItem><epasd:InstanceID>6</epasd:InstanceID><epasd:ResourceType>10</epasd:ResourceType></EthernetPortItem>
+    </VirtualHardwareSection>
+  </VirtualSystem>
+</Envelope>
+`)
+	dest := filepath.Join(dir, "vm")
+	got, notes, err := importOVF(context.Background(), t.TempDir(), ovf, "", dest, "QCOW2", func(float64) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := VMConfig{
+		Name: "box", RAM: "1536MB", Machine: "pc", CPUCores: "2",
+		CDROM: formatCDROMConfigs([]cdromConfig{{Bus: "ide"}}), Network: "user", MAC: "08:00:27:d1:e0:f4",
+	}
+	if got != want {
+		t.Errorf("importOVF =\n%+v\nwant\n%+v", got, want)
+	}
+	if len(notes) != 2 {
+		t.Errorf("notes = %q", notes)
+	}
+}
+
+func TestOVFUnitBytes(t *testing.T) {
+	for units, want := range map[string]int64{"": 7, "byte": 1, "byte * 2^20": 1 << 20, "MegaBytes": 1 << 20, "GigaBytes": 1 << 30, "KiloBytes": 1 << 10} {
+		if got := ovfUnitBytes(units, 7); got != want {
+			t.Errorf("ovfUnitBytes(%q) = %d, want %d", units, got, want)
+		}
+	}
+}
@@ -0,0 +1,353 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// qemuCPU는 cpuModels 항목("Intel: Skylake-Server/Client")을
+// QEMU 실행 파일과 -cpu 모델 이름으로 변환합니다.
+func qemuCPU(cpuModel string) (binary, model string) {
+	vendor, name, found := strings.Cut(cpuModel, ": ")
+	if !found {
+		return "qemu-system-x86_64", ""
+	}
+	// "Skylake-Server/Client"처럼 여러 모델을 묶은 항목은 첫 번째 모델 사용
+	name, _, _ = strings.Cut(name, "/")
+	switch vendor {
+	case "ARM":
+		model = strings.ToLower(name)
+		if strings.HasPrefix(model, "cortex-a") {
+			return "qemu-system-aarch64", model
+		}
+		return "qemu-system-arm", model
+	case "MIPS":
+		return "qemu-system-mips", name
+	}
+	return "qemu-system-x86_64", name
+}
+
+// 머신 종류 선택 목록 (비어 있으면 CPU 모델에 따라 자동)
+var machineOptions = []string{"pc", "q35", "virt", "malta", "microbit", "mps2-an386", "mps2-an505"}
+
+// CPU 모델에 맞는 기본 머신 종류
+func defaultMachine(cpuModel string) string {
+	binary, model := qemuCPU(cpuModel)
+	switch binary {
+	case "qemu-system-aarch64":
+		return "virt"
+	case "qemu-system-arm":
+		switch model {
+		case "cortex-m0":
+			return "microbit"
+		case "cortex-m4":
+			return "mps2-an386"
+		case "cortex-m33":
+			return "mps2-an505"
+		}
+		return "virt"
+	case "qemu-system-mips":
+		return "malta"
+	}
+	return "q35"
+}
+
+func vmMachine(config VMConfig) string {
+	if config.Machine != "" {
+		return config.Machine
+	}
+	return defaultMachine(config.CPUModel)
+}
+
+// QEMU 옵션 값 안의 ','는 ',,'로 써야 함
+func escapeOptionValue(s string) string {
+	return strings.ReplaceAll(s, ",", ",,")
+}
+
+// "4096MB" → "4096M", "4GB" → "4G"
+func qemuMemorySize(ram string) string {
+	switch {
+	case strings.HasSuffix(ram, "MB"):
+		ram = strings.TrimSuffix(ram, "MB") + "M"
+	case strings.HasSuffix(ram, "GB"):
+		ram = strings.TrimSuffix(ram, "GB") + "G"
+	}
+	if ram == "M" || ram == "G" {
+		return ""
+	}
+	return ram
+}
+
+// 디스크 캐시 모드 → blockdev의 cache.direct, cache.no-flush 옵션과 장치의 write-cache 여부
+func blockdevCacheOptions(cache string) (direct, noFlush, writeCache bool) {
+	switch cache {
+	case "none":
+		return true, false, true
+	case "writethrough":
+		return false, false, false
+	case "directsync":
+		return true, false, false
+	case "unsafe":
+		return false, true, true
+	}
+	return false, false, true
+}
+
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
+
+// storageArgs는 디스크마다 file/포맷 -blockdev 두 개와 버스에 맞는 -device를,
+// CD/DVD 드라이브마다 읽기 전용 -blockdev와 ide-cd/scsi-cd 장치를 만듭니다.
+// bootIndex에 있는 장치에는 bootindex를 붙입니다.
+func storageArgs(disks []diskConfig, cdroms []cdromConfig, machine string, bootIndex map[string]int) ([]string, error) {
+	if err := validateDisks(disks, cdroms, machine); err != nil {
+		return nil, err
+	}
+
+	var args []string
+	scsiAdded, ahciAdded := false, false
+	ahciPort := 0
+	// 버스에 맞는 -device 앞부분 (kind는 "hd" 또는 "cd"), 필요한 컨트롤러도 추가
+	attach := func(bus, kind string) string {
+		switch bus {
+		case "virtio-blk":
+			return "virtio-blk-pci"
+		case "virtio-scsi":
+			if !scsiAdded {
+				args = append(args, "-device", "virtio-scsi-pci,id=scsi0")
+				scsiAdded = true
+			}
+			return "scsi-" + kind + ",bus=scsi0.0"
+		case "nvme":
+			return "nvme"
+		case "ide", "ahci":
+			// q35는 IDE 대신 AHCI 컨트롤러(ide.0~ide.5)가 내장되어 있어 두 버스가 같은 포트를 나눠 씀
+			if machine == "q35" {
+				port := ahciPort
+				ahciPort++
+				return fmt.Sprintf("ide-%s,bus=ide.%d", kind, port)
+			}
+			if bus == "ide" {
+				return "ide-" + kind
+			}
+			port := ahciPort
+			ahciPort++
+			if !ahciAdded {
+				args = append(args, "-device", "ahci,id=ahci0")
+				ahciAdded = true
+			}
+			return fmt.Sprintf("ide-%s,bus=ahci0.%d", kind, port)
+		}
+		return ""
+	}
+
+	for i, d := range disks {
+		node := fmt.Sprintf("disk%d", i)
+		direct, noFlush, writeCache := blockdevCacheOptions(d.Cache)
+		cacheOpts := ",cache.direct=" + onOff(direct) + ",cache.no-flush=" + onOff(noFlush)
+
+		fileNode := "driver=file,node-name=" + node + "-file,filename=" + escapeOptionValue(d.Path) + cacheOpts
+		if d.AIO != "" {
+			fileNode += ",aio=" + d.AIO
+		}
+		formatNode := "driver=" + qemuImgFormat(d.Type) + ",node-name=" + node + ",file=" + node + "-file" + cacheOpts
+		if d.Discard {
+			fileNode += ",discard=unmap"
+			formatNode += ",discard=unmap"
+		}
+		if d.DetectZeroes != "" {
+			formatNode += ",detect-zeroes=" + d.DetectZeroes
+		}
+		// 암호화 디스크는 실행 직전에 만든 암호 파일을 secret 객체로 전달 (writeDiskSecrets 참고)
+		if d.Encrypted {
+			secret := node + "-secret"
+			args = append(args, "-object", "secret,id="+secret+",format=raw,file="+escapeOptionValue(diskSecretPath(d.Path)))
+			formatNode += ",encrypt.key-secret=" + secret
+		}
+		args = append(args, "-blockdev", fileNode, "-blockdev", formatNode)
+
+		// I/O 제한은 디스크마다 throttle-group과 throttle 필터 노드를 둠
+		drive := node
+		var limits []string
+		for _, key := range throttleLimitKeys {
+			if value := d.Throttle[key]; value != "" && value != "0" {
+				limits = append(limits, ",limits."+key+"="+value)
+			}
+		}
+		if len(limits) > 0 {
+			group := node + "-throttle-group"
+			drive = node + "-throttle"
+			args = append(args,
+				"-object", "throttle-group,id="+group+strings.Join(limits, ""),
+				"-blockdev", "driver=throttle,node-name="+drive+",throttle-group="+group+",file="+node)
+		}
+
+		device := attach(d.bus(machine), "hd") + ",drive=" + drive
+		if d.bus(machine) == "nvme" {
+			device += ",serial=" + node
+		}
+		device += ",id=" + node + "-dev"
+		if !writeCache && d.bus(machine) != "nvme" {
+			device += ",write-cache=off"
+		}
+		if index, ok := bootIndex[node]; ok {
+			device += fmt.Sprintf(",bootindex=%d", index)
+		}
+		args = append(args, "-device", device)
+	}
+
+	// 빈 드라이브는 매체 없이 장치만 만들어 두고, 나중에 매체를 넣을 수 있게 함
+	for i, c := range cdroms {
+		node := fmt.Sprintf("cd%d", i)
+		device := attach(c.bus(machine), "cd")
+		if c.Path != "" {
+			args = append(args,
+				"-blockdev", "driver=file,node-name="+node+"-file,filename="+escapeOptionValue(c.Path)+",read-only=on",
+				"-blockdev", "driver=raw,node-name="+node+",file="+node+"-file,read-only=on")
+			device += ",drive=" + node
+		}
+		device += ",id=" + node + "-dev"
+		if index, ok := bootIndex[node]; ok {
+			device += fmt.Sprintf(",bootindex=%d", index)
+		}
+		args = append(args, "-device", device)
+	}
+	return args, nil
+}
+
+// 머신 종류별 기본 NIC 모델 (PCI가 없는 마이크로컨트롤러 보드는 "")
+func nicModel(machine string) string {
+	switch machine {
+	case "pc", "q35":
+		return "e1000"
+	case "malta":
+		return "pcnet"
+	case "virt":
+		return "virtio-net-pci"
+	}
+	return ""
+}
+
+// machineHasPCI는 머신에 PCI 버스가 있어 virtio-serial 같은 PCI 장치를 붙일 수 있는지입니다.
+// (microbit 등 Cortex-M 보드에는 PCI가 없음)
+func machineHasPCI(machine string) bool {
+	switch machine {
+	case "pc", "q35", "virt", "malta":
+		return true
+	}
+	return false
+}
+
+// buildQEMUArgs는 설정으로부터 QEMU 실행 파일과 인자 목록을 만듭니다.
+func buildQEMUArgs(config VMConfig) (string, []string, error) {
+	binary, cpuModel := qemuCPU(config.CPUModel)
+	machine := vmMachine(config)
+
+	args := []string{"-name", escapeOptionValue(config.Name), "-machine", machine}
+	if config.CPUAccel == "true" && config.CPUAccelerator != "" {
+		args = append(args, "-accel", strings.ToLower(config.CPUAccelerator))
+	}
+
+	if cpuModel != "" {
+		cpu := cpuModel
+		features := strings.FieldsFunc(config.CPUFeatures, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\n' || r == '\r'
+		})
+		if len(features) > 0 {
+			cpu += "," + strings.Join(features, ",")
+		}
+		args = append(args, "-cpu", cpu)
+	}
+
+	var smp []string
+	if config.CPUSockets != "" {
+		smp = append(smp, "sockets="+config.CPUSockets)
+	}
+	if config.CPUCores != "" {
+		smp = append(smp, "cores="+config.CPUCores)
+	}
+	if config.CPUThreads != "" {
+		smp = append(smp, "threads="+config.CPUThreads)
+	}
+	if len(smp) > 0 {
+		args = append(args, "-smp", strings.Join(smp, ","))
+	}
+
+	if mem := qemuMemorySize(config.RAM); mem != "" {
+		args = append(args, "-m", mem)
+	}
+	if config.UUID != "" {
+		args = append(args, "-uuid", config.UUID)
+	}
+
+	bootIndex := bootIndexes(config)
+	storage, err := storageArgs(parseDiskConfigs(config.Disk), vmCDROMs(config), machine, bootIndex)
+	if err != nil {
+		return "", nil, err
+	}
+	args = append(args, storage...)
+
+	gpu := parseGPUString(config.GPU)
+	if device, ok := gpu["device"]; ok {
+		dev := device
+		if hostmem, ok := gpu["hostmem"]; ok {
+			dev += ",hostmem=" + hostmem
+		}
+		args = append(args, "-vga", "none", "-device", dev)
+	} else if vga, ok := gpu["vga"]; ok {
+		args = append(args, "-vga", vga)
+	}
+	if display, ok := gpu["display"]; ok {
+		if gpu["gl"] == "on" {
+			display += ",gl=on"
+		}
+		args = append(args, "-display", display)
+	}
+
+	switch {
+	case config.Network == "none" || nicModel(machine) == "":
+		args = append(args, "-nic", "none")
+	default:
+		netdev := config.Network
+		if netdev == "" {
+			netdev = "user"
+		}
+		nic := nicModel(machine) + ",netdev=net0"
+		if config.MAC != "" {
+			nic += ",mac=" + config.MAC
+		}
+		if index, ok := bootIndex["net0"]; ok {
+			nic += fmt.Sprintf(",bootindex=%d", index)
+		}
+		args = append(args, "-netdev", netdev+",id=net0", "-device", nic)
+	}
+
+	args = append(args, bootMenuArgs(config)...)
+
+	if err := validateKernelBoot(config); err != nil {
+		return "", nil, err
+	}
+	args = append(args, kernelBootArgs(config)...)
+
+	if err := validateFirmware(config); err != nil {
+		return "", nil, err
+	}
+	args = append(args, firmwareArgs(config)...)
+	args = append(args, guestAgentArgs(config)...)
+
+	// 추가 인자는 설정에서 만든 인자 뒤에 붙임
+	extra, err := splitArgs(config.ExtraArgs)
+	if err != nil {
+		return "", nil, err
+	}
+	if err := checkArgConflicts(args, extra); err != nil {
+		return "", nil, err
+	}
+	args = append(args, extra...)
+
+	return binary, args, nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// storageDevices는 storageArgs 결과에서 -device 값만 모읍니다.
+func storageDevices(t *testing.T, disks []diskConfig, cdroms []cdromConfig, machine string) []string {
+	t.Helper()
+	args, err := storageArgs(disks, cdroms, machine, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var devices []string
+	for i := 0; i+1 < len(args); i++ {
+		if args[i] == "-device" {
+			devices = append(devices, args[i+1])
+		}
+	}
+	return devices
+}
+
+func TestStorageArgsQ35SharesAHCIPorts(t *testing.T) {
+	disks := []diskConfig{
+		{Type: "QCOW2", Path: `C:\vm\a.qcow2`, Bus: "ide"},
+		{Type: "QCOW2", Path: `C:\vm\b.qcow2`, Bus: "ahci"},
+		{Type: "QCOW2", Path: `C:\vm\c.qcow2`}, // q35 기본 버스는 ahci
+	}
+	cdroms := []cdromConfig{{Bus: "ide", Path: `C:\iso\x.iso`}, {Bus: "ahci"}}
+	devices := storageDevices(t, disks, cdroms, "q35")
+	want := []string{
+		"ide-hd,bus=ide.0,drive=disk0,id=disk0-dev",
+		"ide-hd,bus=ide.1,drive=disk1,id=disk1-dev",
+		"ide-hd,bus=ide.2,drive=disk2,id=disk2-dev",
+		"ide-cd,bus=ide.3,drive=cd0,id=cd0-dev",
+		"ide-cd,bus=ide.4,id=cd1-dev",
+	}
+	if strings.Join(devices, "\n") != strings.Join(want, "\n") {
+		t.Errorf("devices =\n%s\nwant\n%s", strings.Join(devices, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestStorageArgsPCKeepsIDEAndAHCISeparate(t *testing.T) {
+	disks := []diskConfig{
+		{Type: "QCOW2", Path: `C:\vm\a.qcow2`, Bus: "ide"},
+		{Type: "QCOW2", Path: `C:\vm\b.qcow2`, Bus: "ahci"},
+	}
+	devices := storageDevices(t, disks, nil, "pc")
+	want := []string{
+		"ide-hd,drive=disk0,id=disk0-dev",
+		"ahci,id=ahci0",
+		"ide-hd,bus=ahci0.0,drive=disk1,id=disk1-dev",
+	}
+	if strings.Join(devices, "\n") != strings.Join(want, "\n") {
+		t.Errorf("devices =\n%s\nwant\n%s", strings.Join(devices, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestValidateDisksQ35PortLimit(t *testing.T) {
+	var disks []diskConfig
+	for i := 0; i < 4; i++ {
+		disks = append(disks, diskConfig{Type: "QCOW2", Path: "d.qcow2", Bus: "ide"})
+	}
+	cdroms := []cdromConfig{{Bus: "ahci"}, {Bus: "ide"}}
+	if err := validateDisks(disks, cdroms, "q35"); err != nil {
+		t.Errorf("6개는 허용되어야 합니다: %v", err)
+	}
+	cdroms = append(cdroms, cdromConfig{Bus: "ahci"})
+	if err := validateDisks(disks, cdroms, "q35"); err == nil {
+		t.Error("CD/DVD를 포함해 7개인데 오류가 없습니다")
+	}
+	// pc는 IDE 4개와 AHCI 6개를 따로 셈
+	if err := validateDisks(disks, []cdromConfig{{Bus: "ahci"}, {Bus: "ahci"}, {Bus: "ahci"}}, "pc"); err != nil {
+		t.Errorf("pc: %v", err)
+	}
+}
+
+func TestStorageArgsThrottle(t *testing.T) {
+	tests := []struct {
+		throttle map[string]string
+		want     []string // disk0에 대한 인자 (파일·형식 노드 뒤)
+	}{
+		// 제한이 없거나 0뿐이면 throttle 노드 없이 형식 노드에 바로 연결
+		{nil, []string{"-device", "virtio-blk-pci,drive=disk0,id=disk0-dev"}},
+		{map[string]string{"iops-total": "0"}, []string{"-device", "virtio-blk-pci,drive=disk0,id=disk0-dev"}},
+		// limits는 throttleLimitKeys 순서
+		{map[string]string{"bps-read": "1048576", "iops-total-max": "1000", "iops-total": "500", "bps-write-max": "0"}, []string{
+			"-object", "throttle-group,id=disk0-throttle-group,limits.iops-total=500,limits.iops-total-max=1000,limits.bps-read=1048576",
+			"-blockdev", "driver=throttle,node-name=disk0-throttle,throttle-group=disk0-throttle-group,file=disk0",
+			"-device", "virtio-blk-pci,drive=disk0-throttle,id=disk0-dev",
+		}},
+	}
+	for _, tt := range tests {
+		disks := []diskConfig{{Type: "QCOW2", Path: "a.qcow2", Bus: "virtio-blk", Throttle: tt.throttle}}
+		args, err := storageArgs(disks, nil, "q35", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(args) < 4 || args[0] != "-blockdev" || args[2] != "-blockdev" {
+			t.Fatalf("args = %q", args)
+		}
+		if got := args[4:]; strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+			t.Errorf("throttle %v:\n%s\nwant\n%s", tt.throttle, strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+		}
+	}
+}
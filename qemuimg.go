@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// qemu-img info --output=json 결과 중 사용하는 항목
+type diskImageInfo struct {
+	Filename            string         `json:"filename"`
+	Format              string         `json:"format"`
+	VirtualSize         int64          `json:"virtual-size"`
+	ActualSize          int64          `json:"actual-size"`
+	BackingFilename     string         `json:"backing-filename"`
+	FullBackingFilename string         `json:"full-backing-filename"`
+	Encrypted           bool           `json:"encrypted"`
+	Snapshots           []diskSnapshot `json:"snapshots"`
+}
+
+// 내부 스냅샷 항목 (qemu-img info의 snapshots 배열)
+type diskSnapshot struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	VMStateSize int64  `json:"vm-state-size"`
+	DateSec     int64  `json:"date-sec"`
+	VMClockSec  int64  `json:"vm-clock-sec"`
+}
+
+// getDiskImageInfo는 qemu-img info로 디스크 이미지 정보를 조회합니다.
+func getDiskImageInfo(path string) (*diskImageInfo, error) {
+	// 실행 중인 가상머신의 이미지도 읽을 수 있도록 잠금 없이 엶 (-U)
+	output, err := exec.Command("qemu-img", "info", "-U", "--output=json", path).Output()
+	if err != nil {
+		return nil, qemuImgError(err)
+	}
+	var info diskImageInfo
+	if err := json.Unmarshal(output, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// getBackingChain은 이미지부터 최종 기반 이미지까지의 정보를 순서대로 반환합니다.
+func getBackingChain(path string) ([]diskImageInfo, error) {
+	output, err := exec.Command("qemu-img", "info", "-U", "--backing-chain", "--output=json", path).Output()
+	if err != nil {
+		return nil, qemuImgError(err)
+	}
+	var chain []diskImageInfo
+	if err := json.Unmarshal(output, &chain); err != nil {
+		return nil, err
+	}
+	return chain, nil
+}
+
+// "기반 이미지: base.qcow2 → golden.qcow2" 형태의 표시 문자열 (기반 이미지가 없으면 "")
+func backingChainText(path string) string {
+	if path == "" {
+		return ""
+	}
+	chain, err := getBackingChain(path)
+	if err != nil || len(chain) < 2 {
+		return ""
+	}
+	var names []string
+	for _, info := range chain[1:] {
+		names = append(names, filepath.Base(info.Filename))
+	}
+	return "기반 이미지: " + strings.Join(names, " → ")
+}
+
+// 디스크 종류 → qemu-img 포맷 이름
+func qemuImgFormat(diskType string) string {
+	formatMap := map[string]string{
+		"QCOW2": "qcow2",
+		"RAW":   "raw",
+		"VHD":   "vpc",
+		"VMDK":  "vmdk",
+	}
+	if format, ok := formatMap[diskType]; ok {
+		return format
+	}
+	return "qcow2"
+}
+
+// 디스크 종류별 기본 파일 확장자
+func diskFileExt(diskType string) string {
+	defaultExt := map[string]string{
+		"QCOW2": ".qcow2",
+		"RAW":   ".img",
+		"VHD":   ".vhd",
+		"VMDK":  ".vmdk",
+	}
+	return defaultExt[diskType]
+}
+
+// runQemuImg는 qemu-img를 실행하고 실패 시 출력 내용을 오류에 담아 반환합니다.
+func runQemuImg(args ...string) error {
+	output, err := exec.Command("qemu-img", args...).CombinedOutput()
+	if err != nil {
+		msg := strings.TrimSpace(string(output))
+		if msg == "" {
+			return err
+		}
+		return fmt.Errorf("qemu-img %s: %s", args[0], msg)
+	}
+	return nil
+}
+
+// exec.ExitError의 stderr 내용을 오류 메시지로 사용
+func qemuImgError(err error) error {
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		if msg := strings.TrimSpace(string(exitErr.Stderr)); msg != "" {
+			return fmt.Errorf("qemu-img: %s", msg)
+		}
+	}
+	return err
+}
+
+// convertDiskImage는 qemu-img convert -p로 이미지를 변환하며 진행률(0~100)을 report로 알립니다.
+// options는 대상 포맷의 -o 옵션입니다 (예: "subformat=streamOptimized").
+func convertDiskImage(ctx context.Context, srcPath, srcFormat, dstPath, dstFormat string, report func(float64), options ...string) error {
+	return convertImage(ctx, []string{"-f", srcFormat}, srcPath, dstPath, dstFormat, report, options...)
+}
+
+// convertImage는 srcOpts로 원본을 엽니다 ("-f 포맷" 또는 암호화 디스크의 --object/--image-opts, diskImageArgs 참고).
+func convertImage(ctx context.Context, srcOpts []string, src, dstPath, dstFormat string, report func(float64), options ...string) error {
+	args := append([]string{"convert", "-p"}, srcOpts...)
+	args = append(args, "-O", dstFormat)
+	if len(options) > 0 {
+		args = append(args, "-o", strings.Join(options, ","))
+	}
+	cmd := exec.CommandContext(ctx, "qemu-img", append(args, src, dstPath)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	// 진행률은 "    (12.34/100%)\r" 형태로 출력됨
+	scanner := bufio.NewScanner(stdout)
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+			return i + 1, data[:i], nil
+		}
+		if atEOF && len(data) > 0 {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	})
+	for scanner.Scan() {
+		var percent float64
+		if _, err := fmt.Sscanf(strings.TrimSpace(scanner.Text()), "(%f/100%%)", &percent); err == nil {
+			report(percent)
+		}
+	}
+
+	if err := cmd.Wait(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("qemu-img convert: %s", msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// qemu-img check --output=json 결과
+type diskCheckResult struct {
+	Filename         string `json:"filename"`
+	Format           string `json:"format"`
+	CheckErrors      int64  `json:"check-errors"`
+	Leaks            int64  `json:"leaks"`
+	Corruptions      int64  `json:"corruptions"`
+	LeaksFixed       int64  `json:"leaks-fixed"`
+	CorruptionsFixed int64  `json:"corruptions-fixed"`
+	TotalClusters    int64  `json:"total-clusters"`
+}
+
+// checkDiskImage는 qemu-img check를 실행합니다. repair는 "", "leaks", "all" 중 하나입니다.
+// opts와 image는 diskImageArgs로 만듭니다.
+// 손상(종료 코드 2)이나 누수(종료 코드 3)가 있어도 결과는 정상적으로 반환합니다.
+func checkDiskImage(opts []string, image, repair string) (*diskCheckResult, error) {
+	args := append([]string{"check", "--output=json"}, opts...)
+	if repair != "" {
+		args = append(args, "-r", repair)
+	}
+	args = append(args, image)
+	output, err := exec.Command("qemu-img", args...).Output()
+	if err != nil {
+		exitErr, ok := err.(*exec.ExitError)
+		if !ok || (exitErr.ExitCode() != 2 && exitErr.ExitCode() != 3) {
+			return nil, qemuImgError(err)
+		}
+	}
+	var result diskCheckResult
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// 내부 스냅샷 생성/적용/삭제 (VM이 꺼져 있어야 이미지 잠금을 얻을 수 있음)
+func createDiskSnapshot(path, name string) error {
+	return runQemuImg("snapshot", "-c", name, path)
+}
+
+func applyDiskSnapshot(path, name string) error {
+	return runQemuImg("snapshot", "-a", name, path)
+}
+
+func deleteDiskSnapshot(path, name string) error {
+	return runQemuImg("snapshot", "-d", name, path)
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// QMP(QEMU Machine Protocol)는 실행 중인 QEMU를 제어하는 JSON 프로토콜입니다.
+// 이 앱이 시작한 가상머신마다 QMP 소켓을 하나 열어 두고 스냅샷, I/O 제한, CD 교체에 사용합니다.
+
+// 응답이 없을 때 기다리는 기본 시간 (ctx에 기한이 없을 때)
+const qmpTimeout = 10 * time.Second
+
+// qmpSocketPath는 가상머신의 QMP 소켓 경로입니다. 실행할 때만 쓰므로 임시 폴더에 둡니다.
+func qmpSocketPath(name string) string {
+	return filepath.Join(os.TempDir(), "goqemu-qmp", name+".sock")
+}
+
+// qmpError는 QEMU가 돌려준 오류입니다.
+type qmpError struct {
+	Class string `json:"class"`
+	Desc  string `json:"desc"`
+}
+
+func (e *qmpError) Error() string {
+	return fmt.Sprintf("QMP 오류 (%s): %s", e.Class, e.Desc)
+}
+
+// qmpEvent는 QEMU가 보낸 비동기 이벤트입니다 (예: DEVICE_TRAY_MOVED).
+type qmpEvent struct {
+	Event string          `json:"event"`
+	Data  json.RawMessage `json:"data"`
+}
+
+type qmpReply struct {
+	Return json.RawMessage `json:"return"`
+	Error  *qmpError       `json:"error"`
+}
+
+var errQMPClosed = errors.New("QMP 연결이 끊어졌습니다.")
+
+// qmpClient는 QMP 소켓에 연결된 클라이언트입니다. 읽기 고루틴이 응답과 이벤트를 나누고,
+// 명령은 한 번에 하나씩 보냅니다.
+type qmpClient struct {
+	conn    net.Conn
+	mu      sync.Mutex // 명령 직렬화
+	replies chan qmpReply
+	done    chan struct{}
+}
+
+// dialQMP는 소켓에 연결해 인사말을 읽고 qmp_capabilities로 명령 모드에 들어갑니다.
+// onEvent는 읽기 고루틴에서 호출되며 nil이면 이벤트를 버립니다.
+func dialQMP(ctx context.Context, socket string, onEvent func(qmpEvent)) (*qmpClient, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", socket)
+	if err != nil {
+		return nil, err
+	}
+	r := bufio.NewReader(conn)
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetReadDeadline(deadline)
+	} else {
+		conn.SetReadDeadline(time.Now().Add(qmpTimeout))
+	}
+	greeting, err := r.ReadBytes('\n')
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !strings.Contains(string(greeting), `"QMP"`) {
+		conn.Close()
+		return nil, fmt.Errorf("QMP 인사말이 아닙니다: %s", strings.TrimSpace(string(greeting)))
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	// 응답은 명령마다 하나이므로, 기다리던 명령이 시간 초과로 떠나도 읽기 고루틴이 막히지 않게 한 칸을 둠
+	q := &qmpClient{conn: conn, replies: make(chan qmpReply, 1), done: make(chan struct{})}
+	go q.readLoop(r, onEvent)
+	if err := q.call(ctx, "qmp_capabilities", nil, nil); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return q, nil
+}
+
+// readLoop는 연결이 끊길 때까지 줄 단위로 읽어 이벤트는 onEvent로, 응답은 replies로 보냅니다.
+func (q *qmpClient) readLoop(r *bufio.Reader, onEvent func(qmpEvent)) {
+	defer close(q.done)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err != nil {
+			return
+		}
+		var msg struct {
+			qmpReply
+			Event string `json:"event"`
+		}
+		if json.Unmarshal(line, &msg) != nil {
+			continue
+		}
+		if msg.Event != "" {
+			if onEvent != nil {
+				var ev qmpEvent
+				json.Unmarshal(line, &ev)
+				onEvent(ev)
+			}
+			continue
+		}
+		q.replies <- msg.qmpReply
+	}
+}
+
+func (q *qmpClient) Close() error {
+	return q.conn.Close()
+}
+
+// call은 명령을 보내고 응답의 return 값을 result에 읽습니다. result가 nil이면 값을 버립니다.
+func (q *qmpClient) call(ctx context.Context, command string, args any, result any) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, qmpTimeout)
+		defer cancel()
+	}
+	req := map[string]any{"execute": command}
+	if args != nil {
+		req["arguments"] = args
+	}
+	data, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	if _, err := q.conn.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	select {
+	case reply := <-q.replies:
+		if reply.Error != nil {
+			return reply.Error
+		}
+		if result != nil && len(reply.Return) > 0 {
+			return json.Unmarshal(reply.Return, result)
+		}
+		return nil
+	case <-q.done:
+		return errQMPClosed
+	case <-ctx.Done():
+		// 늦게 온 응답이 다음 명령의 응답으로 읽히지 않도록 연결을 닫음
+		q.conn.Close()
+		return ctx.Err()
+	}
+}
+
+// HumanMonitor는 HMP 명령을 실행합니다 (savevm처럼 QMP 명령이 따로 없는 경우).
+// HMP는 실패해도 오류 대신 메시지를 출력하므로, 출력이 있으면 오류로 돌려줍니다.
+func (q *qmpClient) HumanMonitor(ctx context.Context, commandLine string) error {
+	var output string
+	if err := q.call(ctx, "human-monitor-command", map[string]any{"command-line": commandLine}, &output); err != nil {
+		return err
+	}
+	if output = strings.TrimSpace(output); output != "" {
+		return errors.New(output)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fakeQMP는 소켓에서 인사말을 보내고, 명령마다 handle의 응답 줄들을 돌려주는 가짜 QEMU입니다.
+func fakeQMP(t *testing.T, handle func(cmd map[string]any) []string) string {
+	t.Helper()
+	socket := filepath.Join(t.TempDir(), "qmp.sock")
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Skip("unix 소켓을 쓸 수 없습니다:", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.Write([]byte(`{"QMP": {"version": {"qemu": {"major": 9}}, "capabilities": []}}` + "\n"))
+		r := bufio.NewReader(conn)
+		for {
+			line, err := r.ReadBytes('\n')
+			if err != nil {
+				return
+			}
+			var cmd map[string]any
+			json.Unmarshal(line, &cmd)
+			for _, reply := range handle(cmd) {
+				conn.Write([]byte(reply + "\n"))
+			}
+		}
+	}()
+	return socket
+}
+
+func TestQMPCallAndEvents(t *testing.T) {
+	socket := fakeQMP(t, func(cmd map[string]any) []string {
+		switch cmd["execute"] {
+		case "qmp_capabilities":
+			return []string{`{"return": {}}`}
+		case "human-monitor-command":
+			args := cmd["arguments"].(map[string]any)
+			if args["command-line"] == "savevm bad" {
+				return []string{`{"return": "Error: Device 'disk1' is writable but does not support snapshots\r\n"}`}
+			}
+			return []string{`{"return": ""}`}
+		case "query-status":
+			// 응답 앞에 이벤트가 끼어들어도 응답과 구분되어야 함
+			return []string{
+				`{"event": "DEVICE_TRAY_MOVED", "data": {"id": "cd0-dev", "tray-open": true}, "timestamp": {"seconds": 1, "microseconds": 0}}`,
+				`{"return": {"running": true, "status": "running"}}`,
+			}
+		}
+		return []string{`{"error": {"class": "CommandNotFound", "desc": "The command was not found"}}`}
+	})
+
+	events := make(chan qmpEvent, 1)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	q, err := dialQMP(ctx, socket, func(ev qmpEvent) { events <- ev })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+
+	var status struct {
+		Running bool   `json:"running"`
+		Status  string `json:"status"`
+	}
+	if err := q.call(ctx, "query-status", nil, &status); err != nil {
+		t.Fatal(err)
+	}
+	if !status.Running || status.Status != "running" {
+		t.Errorf("query-status = %+v", status)
+	}
+	select {
+	case ev := <-events:
+		if ev.Event != "DEVICE_TRAY_MOVED" {
+			t.Errorf("event = %q", ev.Event)
+		}
+	case <-time.After(time.Second):
+		t.Error("DEVICE_TRAY_MOVED 이벤트를 받지 못했습니다")
+	}
+
+	if err := q.HumanMonitor(ctx, "savevm ok"); err != nil {
+		t.Errorf("savevm ok: %v", err)
+	}
+	if err := q.HumanMonitor(ctx, "savevm bad"); err == nil {
+		t.Error("HMP 출력이 있는데 오류가 아닙니다")
+	}
+	err = q.call(ctx, "no-such-command", nil, nil)
+	if qe, ok := err.(*qmpError); !ok || qe.Class != "CommandNotFound" {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestQMPTimeout(t *testing.T) {
+	socket := fakeQMP(t, func(cmd map[string]any) []string {
+		if cmd["execute"] == "qmp_capabilities" {
+			return []string{`{"return": {}}`}
+		}
+		return nil // 응답하지 않음
+	})
+	q, err := dialQMP(context.Background(), socket, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := q.call(ctx, "query-status", nil, nil); err != context.DeadlineExceeded {
+		t.Errorf("err = %v, want DeadlineExceeded", err)
+	}
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// splitArgs는 추가 인자를 셸과 비슷한 규칙으로 나눕니다.
+//   - 공백(줄바꿈 포함)으로 구분하고, '#'으로 시작하는 줄은 주석
+//   - '...' 안은 그대로, "..." 안에서는 \" 만 이스케이프
+//   - 따옴표 밖의 \는 따옴표나 공백 앞에서만 이스케이프 (Windows 경로의 \는 그대로 둠)
+func splitArgs(s string) ([]string, error) {
+	var args []string
+	var cur strings.Builder
+	inArg := false
+	var quote rune
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case quote == '"':
+			if r == '\\' && i+1 < len(runes) && runes[i+1] == '"' {
+				cur.WriteRune('"')
+				i++
+			} else if r == '"' {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '#' && !inArg && (i == 0 || runes[i-1] == '\n'):
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == '\\' && i+1 < len(runes) && strings.ContainsRune("\"' \t\n", runes[i+1]):
+			cur.WriteRune(runes[i+1])
+			inArg = true
+			i++
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		default:
+			cur.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, errors.New("추가 인자에 닫히지 않은 따옴표가 있습니다.")
+	}
+	if inArg {
+		args = append(args, cur.String())
+	}
+	return args, nil
+}
+
+// 설정에서 만들어지며 한 번만 쓸 수 있는 옵션
+var singletonOptions = map[string]bool{
+	"-name": true, "-machine": true, "-M": true, "-accel": true, "-enable-kvm": true,
+	"-cpu": true, "-smp": true, "-m": true, "-uuid": true, "-boot": true,
+	"-vga": true, "-display": true, "-nographic": true,
+	"-kernel": true, "-initrd": true, "-append": true, "-dtb": true,
+}
+
+// 같은 의미의 옵션 이름을 하나로 (--m → -m, -M → -machine, -enable-kvm → -accel)
+func normalizeOption(arg string) string {
+	if strings.HasPrefix(arg, "--") {
+		arg = arg[1:]
+	}
+	switch arg {
+	case "-M":
+		return "-machine"
+	case "-enable-kvm":
+		return "-accel"
+	case "-nographic":
+		return "-display"
+	}
+	return arg
+}
+
+// 옵션 값의 id=, node-name= 목록
+func optionIDs(value string) []string {
+	var ids []string
+	for _, part := range strings.Split(value, ",") {
+		if k, v, ok := strings.Cut(part, "="); ok && (k == "id" || k == "node-name") {
+			ids = append(ids, v)
+		}
+	}
+	return ids
+}
+
+// checkArgConflicts는 추가 인자가 설정에서 만든 옵션이나 장치 ID와 겹치는지 확인합니다.
+func checkArgConflicts(generated, extra []string) error {
+	used := make(map[string]bool)
+	ids := make(map[string]bool)
+	for i, arg := range generated {
+		if strings.HasPrefix(arg, "-") {
+			used[normalizeOption(arg)] = true
+			if i+1 < len(generated) {
+				for _, id := range optionIDs(generated[i+1]) {
+					ids[id] = true
+				}
+			}
+		}
+	}
+	for i, arg := range extra {
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		opt := normalizeOption(arg)
+		if singletonOptions[opt] && used[opt] {
+			return fmt.Errorf("추가 인자의 %s 옵션이 설정에서 만든 옵션과 중복됩니다.", arg)
+		}
+		if i+1 < len(extra) {
+			for _, id := range optionIDs(extra[i+1]) {
+				if ids[id] {
+					return fmt.Errorf("추가 인자의 id %s가 설정에서 만든 장치와 중복됩니다.", id)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Windows 명령줄 규칙으로 인자 하나를 따옴표 처리
+func quoteWindowsArg(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\"&|<>^") {
+		return arg
+	}
+	var b strings.Builder
+	b.WriteByte('"')
+	slashes := 0
+	for _, r := range arg {
+		switch r {
+		case '\\':
+			slashes++
+			continue
+		case '"':
+			b.WriteString(strings.Repeat(`\`, slashes*2+1))
+		default:
+			b.WriteString(strings.Repeat(`\`, slashes))
+		}
+		slashes = 0
+		b.WriteRune(r)
+	}
+	b.WriteString(strings.Repeat(`\`, slashes*2))
+	b.WriteByte('"')
+	return b.String()
+}
+
+// commandLineString은 미리보기용으로 실행 파일과 인자를 한 줄로 만듭니다.
+func commandLineString(binary string, args []string) string {
+	parts := []string{quoteWindowsArg(binary)}
+	for _, arg := range args {
+		parts = append(parts, quoteWindowsArg(arg))
+	}
+	return strings.Join(parts, " ")
+}
+
+// validateExtraArgs는 저장 전에 추가 인자의 문법과 중복 여부를 확인합니다.
+// 나머지 설정에서 명령줄을 만들 수 없으면 중복 검사는 건너뜁니다.
+func validateExtraArgs(config VMConfig) error {
+	extra, err := splitArgs(config.ExtraArgs)
+	if err != nil || len(extra) == 0 {
+		return err
+	}
+	config.ExtraArgs = ""
+	_, args, err := buildQEMUArgs(config)
+	if err != nil {
+		return nil
+	}
+	return checkArgConflicts(args, extra)
+}
+
+// joinArgs는 splitArgs로 다시 나눌 수 있게 인자를 한 줄로 합칩니다.
+func joinArgs(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		switch {
+		case arg != "" && !strings.ContainsAny(arg, " \t\r\n'\"#") && !strings.HasSuffix(arg, `\`):
+			quoted[i] = arg
+		case !strings.Contains(arg, "'"):
+			quoted[i] = "'" + arg + "'"
+		default:
+			// "..." 안에서 끝의 \는 닫는 따옴표를 이스케이프하므로, 끝의 \들은 '...'로 따로 붙임
+			body := strings.TrimRight(arg, `\`)
+			quoted[i] = `"` + strings.ReplaceAll(body, `"`, `\"`) + `"`
+			if tail := arg[len(body):]; tail != "" {
+				quoted[i] += "'" + tail + "'"
+			}
+		}
+	}
+	return strings.Join(quoted, " ")
+}
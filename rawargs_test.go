@@ -0,0 +1,87 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"", nil},
+		{"-device usb-tablet  -rtc base=localtime", []string{"-device", "usb-tablet", "-rtc", "base=localtime"}},
+		{`-drive file=C:\VM\a b.qcow2`, []string{"-drive", `file=C:\VM\a`, "b.qcow2"}},
+		{`-drive "file=C:\VM\a b.qcow2"`, []string{"-drive", `file=C:\VM\a b.qcow2`}},
+		{`-append 'console=ttyS0 root="/dev/vda1"'`, []string{"-append", `console=ttyS0 root="/dev/vda1"`}},
+		{`"say \"hi\""`, []string{`say "hi"`}},
+		{`a\ b \'c`, []string{"a b", "'c"}},
+		{"''", []string{""}},
+		{"-m 1G\n# 주석 -smp 4\n-usb", []string{"-m", "1G", "-usb"}},
+		{"x#y", []string{"x#y"}},
+		{`'it'"'"'s'`, []string{"it's"}},
+	}
+	for _, tt := range tests {
+		got, err := splitArgs(tt.in)
+		if err != nil {
+			t.Errorf("splitArgs(%q): %v", tt.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitArgs(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+	for _, in := range []string{`"abc`, `'abc`, `a "b c`} {
+		if _, err := splitArgs(in); err == nil {
+			t.Errorf("splitArgs(%q): 닫히지 않은 따옴표인데 오류가 없습니다", in)
+		}
+	}
+}
+
+func TestJoinArgsRoundTrip(t *testing.T) {
+	tests := [][]string{
+		{"-device", "usb-tablet"},
+		{"-drive", `file=C:\VM\disk.qcow2,if=virtio`},
+		{"-append", "console=ttyS0 quiet"},
+		{""},
+		{"#not-a-comment", "a#b"},
+		{`C:\dir\`},
+		{`it's`, `say "hi"`, `both ' and "`},
+		{`it's C:\dir\`},
+		{`it's "quoted" \\`},
+		{`\'\`},
+		{"multi\nline", "tab\there"},
+	}
+	for _, args := range tests {
+		joined := joinArgs(args)
+		got, err := splitArgs(joined)
+		if err != nil {
+			t.Errorf("joinArgs(%q) = %s: %v", args, joined, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, args) {
+			t.Errorf("joinArgs(%q) = %s, 다시 나누면 %q", args, joined, got)
+		}
+	}
+}
+
+func TestCheckArgConflicts(t *testing.T) {
+	generated := []string{"-name", "vm", "-m", "1G", "-smp", "cores=2", "-device", "e1000,netdev=net0,id=nic0"}
+	tests := []struct {
+		extra []string
+		ok    bool
+	}{
+		{[]string{"-device", "usb-tablet"}, true},
+		{[]string{"-m", "2G"}, false},
+		{[]string{"--smp", "4"}, false},
+		{[]string{"-device", "virtio-rng-pci,id=nic0"}, false},
+		{[]string{"-M", "pc"}, true},
+	}
+	for _, tt := range tests {
+		err := checkArgConflicts(generated, tt.extra)
+		if (err == nil) != tt.ok {
+			t.Errorf("checkArgConflicts(%q) = %v, ok = %v", tt.extra, err, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// VM 단위 스냅샷 (같은 이름의 디스크별 내부 스냅샷을 묶은 것)
+type vmSnapshot struct {
+	Name        string
+	Date        time.Time
+	VMStateSize int64
+	Disks       []string
+}
+
+// 내부 스냅샷은 QCOW2 디스크에만 만들 수 있음
+func qcow2DiskPaths(config VMConfig) []string {
+	var paths []string
+	for _, diskInfo := range strings.Split(config.Disk, ";") {
+		dType, dPath, _ := parseDiskInfo(diskInfo)
+		if dType == "QCOW2" && dPath != "" {
+			paths = append(paths, dPath)
+		}
+	}
+	return paths
+}
+
+// listVMSnapshots는 VM의 모든 QCOW2 디스크에서 스냅샷을 읽어 이름별로 묶어 반환합니다.
+func listVMSnapshots(config VMConfig) ([]vmSnapshot, error) {
+	var snapshots []vmSnapshot
+	index := make(map[string]int)
+	for _, path := range qcow2DiskPaths(config) {
+		info, err := getDiskImageInfo(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", filepath.Base(path), err)
+		}
+		for _, s := range info.Snapshots {
+			i, ok := index[s.Name]
+			if !ok {
+				i = len(snapshots)
+				index[s.Name] = i
+				snapshots = append(snapshots, vmSnapshot{
+					Name: s.Name,
+					Date: time.Unix(s.DateSec, 0),
+				})
+			}
+			snapshots[i].VMStateSize += s.VMStateSize
+			snapshots[i].Disks = append(snapshots[i].Disks, path)
+		}
+	}
+	return snapshots, nil
+}
+
+// savevm은 메모리 크기에 따라 오래 걸릴 수 있음
+const liveSnapshotTimeout = 10 * time.Minute
+
+// liveSnapshot은 실행 중인 가상머신에 HMP savevm/loadvm/delvm 명령을 보냅니다.
+// 이 명령들은 모든 쓰기 가능한 디스크와 VM 상태(메모리, 장치)를 함께 다룹니다.
+func liveSnapshot(config VMConfig, command, name string) error {
+	q, err := vmQMP(config.Name)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), liveSnapshotTimeout)
+	defer cancel()
+	return q.HumanMonitor(ctx, command+" "+name)
+}
+
+// createVMSnapshot은 모든 QCOW2 디스크에 같은 이름으로 스냅샷을 만들고,
+// 중간에 실패하면 이미 만든 스냅샷을 지웁니다. 실행 중이면 VM 상태를 포함해 savevm으로 만듭니다.
+func createVMSnapshot(config VMConfig, name string) error {
+	paths := qcow2DiskPaths(config)
+	if len(paths) == 0 {
+		return errors.New("스냅샷을 지원하는 QCOW2 디스크가 없습니다.")
+	}
+	if vmRunning(config.Name) {
+		return liveSnapshot(config, "savevm", name)
+	}
+	for i, path := range paths {
+		if err := createDiskSnapshot(path, name); err != nil {
+			for _, done := range paths[:i] {
+				deleteDiskSnapshot(done, name)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// applyVMSnapshot은 스냅샷으로 되돌립니다. 실행 중이면 loadvm으로 VM 상태까지 되돌립니다.
+func applyVMSnapshot(config VMConfig, snapshot vmSnapshot) error {
+	if vmRunning(config.Name) {
+		return liveSnapshot(config, "loadvm", snapshot.Name)
+	}
+	for _, path := range snapshot.Disks {
+		if err := applyDiskSnapshot(path, snapshot.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func deleteVMSnapshot(config VMConfig, snapshot vmSnapshot) error {
+	if vmRunning(config.Name) {
+		return liveSnapshot(config, "delvm", snapshot.Name)
+	}
+	for _, path := range snapshot.Disks {
+		if err := deleteDiskSnapshot(path, snapshot.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ShowSnapshotWindow는 VM의 스냅샷 관리 창을 엽니다.
+// 꺼져 있으면 qemu-img로, 이 앱이 시작해 실행 중이면 QMP로 스냅샷을 다룹니다.
+func ShowSnapshotWindow(config VMConfig) {
+	a := fyne.CurrentApp()
+	win := a.NewWindow(config.Name + " 스냅샷")
+	win.Resize(fyne.NewSize(500, 350))
+
+	var snapshots []vmSnapshot
+	selected := -1
+
+	snapshotList := widget.NewList(
+		func() int { return len(snapshots) },
+		func() fyne.CanvasObject { return widget.NewLabel("template") },
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			s := snapshots[i]
+			text := fmt.Sprintf("%s  (%s, 디스크 %d개)", s.Name, s.Date.Format("2006-01-02 15:04:05"), len(s.Disks))
+			if s.VMStateSize > 0 {
+				text += " [VM 상태 포함]"
+			}
+			o.(*widget.Label).SetText(text)
+		},
+	)
+	snapshotList.OnSelected = func(id widget.ListItemID) {
+		selected = id
+	}
+	var runAction func(action func() error, onOK func())
+
+	refreshSnapshots := func() {
+		list, err := listVMSnapshots(config)
+		if err != nil {
+			dialog.ShowError(err, win)
+		}
+		snapshots = list
+		selected = -1
+		snapshotList.UnselectAll()
+		snapshotList.Refresh()
+	}
+
+	createBtn := widget.NewButton("생성", func() {
+		nameEntry := widget.NewEntry()
+		nameEntry.SetPlaceHolder("스냅샷 이름")
+		nameEntry.SetText(time.Now().Format("snap-20060102-150405"))
+		dialog.ShowForm("스냅샷 생성", "생성", "취소",
+			[]*widget.FormItem{widget.NewFormItem("이름", nameEntry)},
+			func(ok bool) {
+				if !ok {
+					return
+				}
+				name := strings.TrimSpace(nameEntry.Text)
+				if name == "" {
+					dialog.ShowError(errors.New("스냅샷 이름을 입력하십시오."), win)
+					return
+				}
+				// HMP savevm은 공백으로 인자를 나누므로 이름에 공백이나 따옴표를 쓸 수 없음
+				if strings.ContainsAny(name, " \t\"'") {
+					dialog.ShowError(errors.New("스냅샷 이름에는 공백이나 따옴표를 쓸 수 없습니다."), win)
+					return
+				}
+				runAction(func() error { return createVMSnapshot(config, name) }, nil)
+			}, win)
+	})
+	revertBtn := widget.NewButton("되돌리기", func() {
+		if selected < 0 || selected >= len(snapshots) {
+			return
+		}
+		s := snapshots[selected]
+		dialog.ShowConfirm("스냅샷 되돌리기", s.Name+" 시점으로 되돌리시겠습니까?\n현재 디스크 상태는 사라집니다.", func(ok bool) {
+			if !ok {
+				return
+			}
+			runAction(func() error { return applyVMSnapshot(config, s) }, func() {
+				dialog.ShowInformation("되돌리기", s.Name+" 시점으로 되돌렸습니다.", win)
+			})
+		}, win)
+	})
+	deleteBtn := widget.NewButton("삭제", func() {
+		if selected < 0 || selected >= len(snapshots) {
+			return
+		}
+		s := snapshots[selected]
+		dialog.ShowConfirm("스냅샷 삭제", s.Name+" 스냅샷을 삭제하시겠습니까?", func(ok bool) {
+			if !ok {
+				return
+			}
+			runAction(func() error { return deleteVMSnapshot(config, s) }, nil)
+		}, win)
+	})
+	refreshBtn := widget.NewButton("새로고침", refreshSnapshots)
+	closeBtn := widget.NewButton("닫기", func() {
+		win.Close()
+	})
+	actionBtns := []*widget.Button{createBtn, revertBtn, deleteBtn, refreshBtn}
+
+	// runAction은 스냅샷 작업을 고루틴에서 실행합니다 (실행 중인 savevm은 오래 걸릴 수 있음).
+	// 작업 중에는 버튼을 끄고, 끝나면 목록을 새로 읽습니다.
+	runAction = func(action func() error, onOK func()) {
+		for _, b := range actionBtns {
+			b.Disable()
+		}
+		go func() {
+			err := action()
+			for _, b := range actionBtns {
+				b.Enable()
+			}
+			if err != nil {
+				dialog.ShowError(err, win)
+			} else if onOK != nil {
+				onOK()
+			}
+			refreshSnapshots()
+		}()
+	}
+
+	title := config.Name + " 내부 스냅샷 (QCOW2)"
+	if vmRunning(config.Name) {
+		title += " - 실행 중: VM 상태 포함"
+	}
+
+	win.SetContent(container.NewBorder(
+		widget.NewLabel(title),
+		container.NewHBox(createBtn, revertBtn, deleteBtn, refreshBtn, closeBtn),
+		nil, nil,
+		snapshotList,
+	))
+	refreshSnapshots()
+	win.CenterOnScreen()
+	win.Show()
+}
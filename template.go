@@ -0,0 +1,463 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	sqdialog "github.com/sqweek/dialog"
+)
+
+// vmTemplate은 새 가상머신의 기본 설정입니다.
+// Config의 디스크와 CD/DVD는 경로가 비어 있고, 식별자(UUID, MAC)도 비어 있습니다.
+// TPM과 게스트 에이전트는 비어 있지 않으면 켜짐 (소켓 경로는 만들 때 정함)
+type vmTemplate struct {
+	Name        string
+	Description string
+	Config      VMConfig
+	builtin     bool
+}
+
+// 사용자 템플릿은 설정 폴더의 templates\<이름>.conf에 가상머신 설정 형식으로 저장
+func templatesDir(configDir string) string {
+	return filepath.Join(configDir, "templates")
+}
+
+// 기본 제공 템플릿
+func builtinTemplates() []vmTemplate {
+	disk := func(diskType, capacity, bus string) string {
+		return formatDiskConfigs([]diskConfig{{Type: diskType, Capacity: capacity, Bus: bus, Discard: true}})
+	}
+	cd := func(bus string) string {
+		return formatCDROMConfigs([]cdromConfig{{Bus: bus}})
+	}
+	templates := []vmTemplate{
+		{
+			Name:        "Windows 11",
+			Description: "UEFI와 TPM 2.0을 켠 Windows 11 설치용 구성입니다 (q35, 4코어, 4GB, AHCI 64GB). TPM에는 swtpm이 필요합니다.",
+			Config: VMConfig{
+				CPUModel: "Intel: Skylake-Server/Client", CPUCores: "4", CPUAccel: "true", CPUAccelerator: "whpx",
+				RAM: "4GB", Machine: "q35", Disk: disk("QCOW2", "65536", "ahci"), CDROM: cd("ahci"),
+				GPU: "vga=std", Network: "user", BootOrder: "disk0,cd0", Firmware: "uefi", TPM: "true",
+			},
+		},
+		{
+			Name:        "Ubuntu Server",
+			Description: "Ubuntu Server 설치용 구성입니다 (q35, 2코어, 2GB, virtio 20GB).",
+			Config: VMConfig{
+				CPUModel: "Basic: qemu64", CPUCores: "2", CPUAccel: "true", CPUAccelerator: "whpx",
+				RAM: "2GB", Machine: "q35", Disk: disk("QCOW2", "20480", "virtio-blk"), CDROM: cd("ahci"),
+				GPU: "vga=std", Network: "user", BootOrder: "disk0,cd0",
+			},
+		},
+		{
+			Name:        "Alpine (최소)",
+			Description: "Alpine Linux 등 가벼운 배포판용 최소 구성입니다 (pc, 1코어, 512MB, 2GB).",
+			Config: VMConfig{
+				CPUModel: "Basic: qemu64", CPUCores: "1", CPUAccel: "true", CPUAccelerator: "whpx",
+				RAM: "512MB", Machine: "pc", Disk: disk("QCOW2", "2048", "virtio-blk"), CDROM: cd("ide"),
+				GPU: "vga=std", Network: "user", BootOrder: "disk0,cd0",
+			},
+		},
+		{
+			Name:        "ARM64 (virt)",
+			Description: "AArch64 UEFI 가상머신입니다 (virt, Cortex-A57, 2코어, 2GB, virtio 16GB). 에뮬레이션(TCG)으로 실행되어 느립니다.",
+			Config: VMConfig{
+				CPUModel: "ARM: Cortex-A57", CPUCores: "2", RAM: "2GB", Machine: "virt",
+				Disk: disk("QCOW2", "16384", "virtio-blk"), CDROM: cd("virtio-scsi"),
+				GPU: "device=virtio-gpu", Network: "user", BootOrder: "disk0,cd0", Firmware: "uefi",
+				ExtraArgs: "-device qemu-xhci -device usb-kbd -device usb-tablet",
+			},
+		},
+		{
+			Name:        "MIPS Malta",
+			Description: "MIPS Malta 보드입니다 (24Kc, 256MB, IDE 2GB). Malta는 ISO로 부팅하지 못하므로 만든 뒤 설정의 커널 탭에서 커널을 지정하세요. ISO는 비워 두어도 됩니다.",
+			Config: VMConfig{
+				CPUModel: "MIPS: 24Kc/24KEc/24Kf", CPUCores: "1", RAM: "256MB", Machine: "malta",
+				Disk: disk("QCOW2", "2048", "ide"), CDROM: cd("ide"), Network: "user",
+			},
+		},
+	}
+	for i := range templates {
+		templates[i].builtin = true
+	}
+	return templates
+}
+
+// loadTemplates는 기본 제공 템플릿 뒤에 사용자 템플릿을 이름 순으로 붙여 반환합니다.
+func loadTemplates(configDir string) []vmTemplate {
+	templates := builtinTemplates()
+	files, _ := filepath.Glob(filepath.Join(templatesDir(configDir), "*.conf"))
+	sort.Strings(files)
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		t := vmTemplate{Config: parseVMConfig(string(data))}
+		t.Name = strings.TrimSuffix(filepath.Base(file), ".conf")
+		for _, line := range strings.Split(string(data), "\n") {
+			if value, ok := strings.CutPrefix(strings.TrimSpace(line), "description="); ok {
+				t.Description = value
+			}
+		}
+		templates = append(templates, t)
+	}
+	return templates
+}
+
+// templateFromConfig는 가상머신 설정에서 경로와 식별자를 빼 템플릿을 만듭니다.
+// 커널, initrd 등 여러 가상머신이 함께 쓰는 파일과 추가 인자는 그대로 둡니다.
+func templateFromConfig(config VMConfig, name, description string) vmTemplate {
+	t := config
+	t.Name = name
+	t.UUID, t.MAC = "", ""
+	t.BootOnce = ""
+	t.Managed = false
+	disks := parseDiskConfigs(t.Disk)
+	for i := range disks {
+		disks[i].Path = ""
+		disks[i].Encrypted = false
+	}
+	t.Disk = formatDiskConfigs(disks)
+	cdroms := parseCDROMConfigs(t.CDROM)
+	for i := range cdroms {
+		cdroms[i].Path = ""
+	}
+	t.CDROM = formatCDROMConfigs(cdroms)
+	t.NVRAM = ""
+	t.CloudInit.Seed = ""
+	t.Unattend.Media = ""
+	if t.TPM != "" {
+		t.TPM = "true"
+	}
+	if t.GuestAgent != "" {
+		t.GuestAgent = "true"
+	}
+	return vmTemplate{Name: name, Description: description, Config: t}
+}
+
+// checkTemplateName은 템플릿 이름을 파일 이름으로 쓸 수 있는지, 기본 제공 템플릿과 겹치지 않는지 확인합니다.
+func checkTemplateName(name string) error {
+	if name == "" {
+		return errors.New("템플릿 이름을 입력하십시오.")
+	}
+	if strings.ContainsAny(name, `\/:*?"<>|`) {
+		return fmt.Errorf("템플릿 이름에 다음 문자는 쓸 수 없습니다: \\ / : * ? \" < > |")
+	}
+	for _, t := range builtinTemplates() {
+		if strings.EqualFold(t.Name, name) {
+			return fmt.Errorf("%s은(는) 기본 제공 템플릿 이름입니다.", name)
+		}
+	}
+	return nil
+}
+
+// templateDisks는 템플릿의 디스크를 읽습니다. 경로가 비어 있으므로 parseDiskConfigs 대신 씁니다.
+func templateDisks(config VMConfig) []diskConfig {
+	var disks []diskConfig
+	for _, diskInfo := range strings.Split(config.Disk, ";") {
+		if diskInfo != "" {
+			disks = append(disks, parseDiskConfig(diskInfo))
+		}
+	}
+	return disks
+}
+
+func templatePath(configDir, name string) string {
+	return filepath.Join(templatesDir(configDir), name+".conf")
+}
+
+func saveTemplate(configDir string, t vmTemplate) error {
+	if err := os.MkdirAll(templatesDir(configDir), os.ModePerm); err != nil {
+		return err
+	}
+	description := strings.NewReplacer("\r", " ", "\n", " ").Replace(t.Description)
+	data := formatVMConfig(t.Config) + "description=" + description + "\n"
+	return os.WriteFile(templatePath(configDir, t.Name), []byte(data), 0644)
+}
+
+// createVMFromTemplate은 템플릿으로 관리형 가상머신을 만듭니다.
+// 첫 디스크 크기는 diskMB(0이면 템플릿 값), ISO는 첫 CD/DVD 드라이브에 넣습니다.
+func createVMFromTemplate(configDir string, t vmTemplate, name string, diskMB int64, iso string) (VMConfig, error) {
+	if err := checkCloneName(configDir, name); err != nil {
+		return VMConfig{}, err
+	}
+	config := t.Config
+	config.Name = name
+	config.Managed = true
+	config.UUID = newVMUUID()
+	config.MAC = newVMMAC()
+
+	disks := templateDisks(config)
+	if len(disks) > 0 && diskMB > 0 {
+		disks[0].Capacity = strconv.FormatInt(diskMB, 10)
+	}
+	cdroms := parseCDROMConfigs(config.CDROM)
+	if iso != "" {
+		if len(cdroms) == 0 {
+			cdroms = append(cdroms, cdromConfig{})
+		}
+		cdroms[0].Path = iso
+	}
+	config.CDROM = formatCDROMConfigs(cdroms)
+	if config.TPM != "" {
+		config.TPM = defaultTPMSocket(configDir, config)
+	}
+	if config.GuestAgent != "" {
+		config.GuestAgent = defaultGuestAgentSocket(configDir, config)
+	}
+	if err := validateDisks(disks, cdroms, vmMachine(config)); err != nil {
+		return VMConfig{}, err
+	}
+	if err := validateFirmware(config); err != nil {
+		return VMConfig{}, err
+	}
+
+	if err := createVMDir(configDir, name); err != nil {
+		return VMConfig{}, err
+	}
+	dir := vmStorageDir(configDir, name)
+	var created []string
+	fail := func(err error) (VMConfig, error) {
+		for _, path := range created {
+			os.Remove(path)
+		}
+		removeEmptyVMDir(dir)
+		return VMConfig{}, err
+	}
+	for i := range disks {
+		disks[i].Path = newVMDiskPath(dir, diskFileExt(disks[i].Type), created)
+		capacity, err := strconv.ParseInt(disks[i].Capacity, 10, 64)
+		if err != nil || capacity < 1 {
+			capacity = 10240
+			disks[i].Capacity = "10240"
+		}
+		if err := runQemuImg("create", "-f", qemuImgFormat(disks[i].Type), disks[i].Path, fmt.Sprintf("%dM", capacity)); err != nil {
+			return fail(err)
+		}
+		created = append(created, disks[i].Path)
+	}
+	config.Disk = formatDiskConfigs(disks)
+	if err := ensureNVRAM(configDir, &config); err != nil {
+		return fail(fmt.Errorf("UEFI 변수 저장소를 만들지 못했습니다: %w", err))
+	}
+	if config.NVRAM != "" {
+		created = append(created, config.NVRAM)
+	}
+	if err := ensureCloudInitSeed(configDir, &config); err != nil {
+		return fail(err)
+	}
+	if config.CloudInit.Seed != "" {
+		created = append(created, config.CloudInit.Seed)
+	}
+	if err := ensureUnattendMedia(configDir, &config); err != nil {
+		return fail(err)
+	}
+	if config.Unattend.Media != "" {
+		created = append(created, config.Unattend.Media)
+	}
+	if err := saveVMConfig(configDir, config); err != nil {
+		return fail(err)
+	}
+	return config, nil
+}
+
+// ShowTemplateWizard는 템플릿을 골라 이름, 디스크 크기, ISO만 입력받아 가상머신을 만드는 창을 띄웁니다.
+func ShowTemplateWizard(configDir string, parent fyne.Window, onDone func()) {
+	a := fyne.CurrentApp()
+	win := a.NewWindow("템플릿으로 생성")
+	win.Resize(fyne.NewSize(550, 400))
+
+	templates := loadTemplates(configDir)
+	var selected *vmTemplate
+
+	descLabel := widget.NewLabel("")
+	descLabel.Wrapping = fyne.TextWrapWord
+
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder("새 가상머신 이름")
+	diskEntry := widget.NewEntry()
+	diskEntry.SetPlaceHolder("첫 디스크 크기 (GB)")
+	isoEntry := widget.NewEntry()
+	isoEntry.SetPlaceHolder("설치 ISO 경로 (비우면 빈 드라이브)")
+	isoBtn := widget.NewButton("ISO 선택", func() {
+		path, err := sqdialog.File().Title("ISO 이미지 선택").Filter("ISO 이미지", "iso", "img").Load()
+		if err != nil || path == "" {
+			return
+		}
+		isoEntry.SetText(path)
+	})
+	libraryBtn := widget.NewButton("라이브러리", func() {
+		pickMediaItem(configDir, win, isoEntry.SetText)
+	})
+
+	deleteBtn := widget.NewButton("템플릿 삭제", nil)
+	deleteBtn.Disable()
+
+	var templateList *widget.List
+	templateList = widget.NewList(
+		func() int { return len(templates) },
+		func() fyne.CanvasObject { return widget.NewLabel("template") },
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			text := templates[i].Name
+			if !templates[i].builtin {
+				text += " (사용자)"
+			}
+			o.(*widget.Label).SetText(text)
+		},
+	)
+	templateList.OnSelected = func(id widget.ListItemID) {
+		selected = &templates[id]
+		descLabel.SetText(selected.Description)
+		diskEntry.SetText("")
+		if disks := templateDisks(selected.Config); len(disks) > 0 {
+			if mb, err := strconv.ParseInt(disks[0].Capacity, 10, 64); err == nil && mb > 0 {
+				diskEntry.SetText(strconv.FormatFloat(float64(mb)/1024, 'f', -1, 64))
+			}
+		}
+		if selected.builtin {
+			deleteBtn.Disable()
+		} else {
+			deleteBtn.Enable()
+		}
+	}
+	deleteBtn.OnTapped = func() {
+		if selected == nil || selected.builtin {
+			return
+		}
+		name := selected.Name
+		dialog.ShowConfirm("템플릿 삭제", name+" 템플릿을 삭제하시겠습니까?", func(ok bool) {
+			if !ok {
+				return
+			}
+			if err := os.Remove(templatePath(configDir, name)); err != nil {
+				dialog.ShowError(err, win)
+				return
+			}
+			templates = loadTemplates(configDir)
+			selected = nil
+			descLabel.SetText("")
+			deleteBtn.Disable()
+			templateList.UnselectAll()
+			templateList.Refresh()
+		}, win)
+	}
+
+	createBtn := widget.NewButton("만들기", func() {
+		if selected == nil {
+			dialog.ShowError(errors.New("템플릿을 선택하십시오."), win)
+			return
+		}
+		name := strings.TrimSpace(nameEntry.Text)
+		var diskMB int64
+		if text := strings.TrimSpace(diskEntry.Text); text != "" {
+			gb, err := strconv.ParseFloat(text, 64)
+			if err != nil || gb <= 0 {
+				dialog.ShowError(fmt.Errorf("디스크 크기가 올바르지 않습니다: %s", text), win)
+				return
+			}
+			diskMB = int64(gb * 1024)
+		}
+		iso := strings.TrimSpace(isoEntry.Text)
+		if _, err := os.Stat(iso); iso != "" && err != nil {
+			dialog.ShowError(fmt.Errorf("ISO 파일을 찾을 수 없습니다: %s", iso), win)
+			return
+		}
+		config, err := createVMFromTemplate(configDir, *selected, name, diskMB, iso)
+		if err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		message := name + " 가상머신을 만들었습니다."
+		if config.TPM != "" {
+			message += "\n\nTPM에는 swtpm이 필요합니다. 가상머신을 시작할 때 함께 실행됩니다."
+		}
+		dialog.ShowInformation("템플릿으로 생성", message, parent)
+		win.Close()
+		if onDone != nil {
+			onDone()
+		}
+	})
+	cancelBtn := widget.NewButton("취소", func() {
+		win.Close()
+	})
+
+	form := container.NewVBox(
+		descLabel,
+		widget.NewForm(
+			widget.NewFormItem("이름", nameEntry),
+			widget.NewFormItem("디스크 (GB)", diskEntry),
+			widget.NewFormItem("ISO", container.NewBorder(nil, nil, nil, container.NewHBox(isoBtn, libraryBtn), isoEntry)),
+		),
+	)
+	split := container.NewHSplit(templateList, form)
+	split.SetOffset(0.3)
+	win.SetContent(container.NewBorder(nil, container.NewHBox(createBtn, cancelBtn, deleteBtn), nil, nil, split))
+	templateList.Select(0)
+	win.CenterOnScreen()
+	win.Show()
+}
+
+// ShowSaveTemplateWindow는 가상머신 설정을 사용자 템플릿으로 저장하는 창을 띄웁니다.
+func ShowSaveTemplateWindow(config VMConfig, configDir string, parent fyne.Window) {
+	a := fyne.CurrentApp()
+	win := a.NewWindow(config.Name + " 템플릿으로 저장")
+	win.Resize(fyne.NewSize(450, 250))
+
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder("템플릿 이름")
+	nameEntry.SetText(config.Name)
+	descEntry := widget.NewMultiLineEntry()
+	descEntry.SetPlaceHolder("설명")
+	infoLabel := widget.NewLabel("디스크와 ISO 경로, UUID, MAC은 저장하지 않습니다. 디스크 크기와 버스 등 나머지 설정은 그대로 저장됩니다.")
+	infoLabel.Wrapping = fyne.TextWrapWord
+
+	save := func(t vmTemplate) {
+		if err := saveTemplate(configDir, t); err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		dialog.ShowInformation("템플릿으로 저장", t.Name+" 템플릿을 저장했습니다.", parent)
+		win.Close()
+	}
+	saveBtn := widget.NewButton("저장", func() {
+		name := strings.TrimSpace(nameEntry.Text)
+		if err := checkTemplateName(name); err != nil {
+			dialog.ShowError(err, win)
+			return
+		}
+		t := templateFromConfig(config, name, strings.TrimSpace(descEntry.Text))
+		if _, err := os.Stat(templatePath(configDir, name)); err == nil {
+			dialog.ShowConfirm("템플릿 덮어쓰기", name+" 템플릿이 이미 있습니다. 덮어쓰시겠습니까?", func(ok bool) {
+				if ok {
+					save(t)
+				}
+			}, win)
+			return
+		}
+		save(t)
+	})
+	cancelBtn := widget.NewButton("취소", func() {
+		win.Close()
+	})
+
+	win.SetContent(container.NewVBox(
+		widget.NewForm(
+			widget.NewFormItem("이름", nameEntry),
+			widget.NewFormItem("설명", descEntry),
+		),
+		infoLabel,
+		container.NewHBox(saveBtn, cancelBtn),
+	))
+	win.CenterOnScreen()
+	win.Show()
+}
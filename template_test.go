@@ -0,0 +1,103 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestTemplateRoundTrip(t *testing.T) {
+	configDir := t.TempDir()
+	config := fullVMConfig(vmStorageDir(configDir, "full"))
+	config.Managed = true
+	tmpl := templateFromConfig(config, "내 템플릿", "첫 줄\r\n둘째 줄")
+
+	// 경로와 식별자는 빼고, TPM과 게스트 에이전트는 켜짐 표시만 남김
+	c := tmpl.Config
+	if c.Name != "내 템플릿" || c.UUID != "" || c.MAC != "" || c.BootOnce != "" || c.Managed ||
+		c.NVRAM != "" || c.CloudInit.Seed != "" || c.Unattend.Media != "" || c.TPM != "true" || c.GuestAgent != "true" {
+		t.Errorf("template config = %+v", c)
+	}
+	for _, d := range templateDisks(c) {
+		if d.Path != "" || d.Encrypted {
+			t.Errorf("disk = %+v", d)
+		}
+	}
+	if c.CDROM != formatCDROMConfigs([]cdromConfig{{Bus: "ahci"}, {Bus: "ahci"}}) {
+		t.Errorf("CDROM = %q", c.CDROM)
+	}
+	// 함께 쓰는 파일과 나머지 설정은 그대로
+	if c.Kernel != config.Kernel || c.CloudInit.RunCmd != config.CloudInit.RunCmd || c.ExtraArgs != config.ExtraArgs || c.RAM != config.RAM {
+		t.Errorf("template config = %+v", c)
+	}
+
+	if err := saveTemplate(configDir, tmpl); err != nil {
+		t.Fatal(err)
+	}
+	templates := loadTemplates(configDir)
+	builtin := len(builtinTemplates())
+	if len(templates) != builtin+1 {
+		t.Fatalf("%d개의 템플릿, want %d", len(templates), builtin+1)
+	}
+	got := templates[builtin]
+	if got.Name != tmpl.Name || got.Description != "첫 줄  둘째 줄" || got.builtin {
+		t.Errorf("template = %q, %q, builtin %v", got.Name, got.Description, got.builtin)
+	}
+	if len(templateDisks(got.Config)) != 2 || got.Config.Disk != c.Disk {
+		t.Errorf("Disk = %q, want %q", got.Config.Disk, c.Disk)
+	}
+	got.Config.Disk, c.Disk = "", ""
+	if got.Config != c {
+		t.Errorf("loaded config =\n%+v\nwant\n%+v", got.Config, c)
+	}
+}
+
+func TestBuiltinTemplates(t *testing.T) {
+	for _, tmpl := range builtinTemplates() {
+		if err := checkTemplateName(tmpl.Name); err == nil {
+			t.Errorf("%s: 기본 제공 템플릿 이름을 사용자 템플릿에 쓸 수 있습니다", tmpl.Name)
+		}
+		if err := validateDisks(templateDisks(tmpl.Config), parseCDROMConfigs(tmpl.Config.CDROM), vmMachine(tmpl.Config)); err != nil {
+			t.Errorf("%s: %v", tmpl.Name, err)
+		}
+	}
+	if err := checkTemplateName("a/b"); err == nil {
+		t.Error("'/'가 들어간 이름을 받았습니다")
+	}
+}
+
+func TestCreateVMFromTemplate(t *testing.T) {
+	if _, err := exec.LookPath("qemu-img"); err != nil {
+		t.Skip("qemu-img이 없습니다")
+	}
+	configDir := t.TempDir()
+	var tmpl vmTemplate
+	for _, b := range builtinTemplates() {
+		if b.Name == "Ubuntu Server" {
+			tmpl = b
+		}
+	}
+	iso := filepath.Join(configDir, "ubuntu.iso")
+	config, err := createVMFromTemplate(configDir, tmpl, "web", 64, iso)
+	if err != nil {
+		t.Fatal(err)
+	}
+	disks := parseDiskConfigs(config.Disk)
+	if len(disks) != 1 || disks[0].Capacity != "64" || disks[0].Bus != "virtio-blk" || !disks[0].Discard {
+		t.Fatalf("disks = %+v", disks)
+	}
+	if _, err := os.Stat(disks[0].Path); err != nil {
+		t.Error(err)
+	}
+	if config.UUID == "" || config.MAC == "" || config.CDROM != formatCDROMConfigs([]cdromConfig{{Bus: "ahci", Path: iso}}) {
+		t.Errorf("config = %+v", config)
+	}
+	loaded, err := loadVMConfig(configDir, "web")
+	if err != nil || loaded != config {
+		t.Errorf("loadVMConfig = %+v, %v\nwant %+v", loaded, err, config)
+	}
+	if _, err := createVMFromTemplate(configDir, tmpl, "web", 0, ""); err == nil {
+		t.Error("같은 이름으로 다시 만들었습니다")
+	}
+}
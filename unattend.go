@@ -0,0 +1,247 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+)
+
+// unattendConfig는 Windows 자동 설치 설정입니다.
+// 켜져 있으면 autounattend.xml을 담은 ISO를 만들어 설치 ISO와 함께 CD로 연결합니다.
+// Windows 설치 프로그램은 이동식 미디어와 CD의 루트에서 이 파일을 찾아 씁니다.
+type unattendConfig struct {
+	Enabled    bool
+	Media      string // 만든 응답 파일 ISO 경로
+	Edition    string // 설치할 이미지 이름 (예: Windows 11 Pro). 비우면 설치 중 선택
+	ProductKey string
+	Locale     string // 언어, 지역, 키보드 (비우면 ko-KR)
+	User       string // 만들 로컬 관리자 계정 (비우면 user)
+	Password   string // 응답 파일 형식으로 인코딩한 암호 (암호화가 아님)
+	Partition  string // "manual"이면 설치 중 직접 지정, 비우면 디스크 0 전체를 지우고 나눔
+	DriverISO  string // virtio-win 등 드라이버 ISO (함께 CD로 연결)
+	Drivers    string // 설치 중 읽을 드라이버 폴더 (한 줄에 하나)
+}
+
+const (
+	defaultUnattendLocale = "ko-KR"
+	defaultUnattendUser   = "user"
+)
+
+// 드라이브 문자가 없는 드라이버 경로는 CD가 붙을 수 있는 문자마다 넣음
+const unattendDriverLetters = "DEFGH"
+
+// CPU 아키텍처별 Windows 구성 요소 아키텍처
+func unattendArch(config VMConfig) (string, error) {
+	switch binary, _ := qemuCPU(config.CPUModel); binary {
+	case "qemu-system-x86_64":
+		return "amd64", nil
+	case "qemu-system-aarch64":
+		return "arm64", nil
+	}
+	return "", errors.New("Windows 자동 설치는 x86_64, AArch64 CPU에서만 사용할 수 있습니다.")
+}
+
+// virtioWinDrivers는 virtio-win ISO에서 디스크(viostor, vioscsi)와 네트워크(NetKVM) 드라이버 폴더입니다.
+func virtioWinDrivers(config VMConfig) string {
+	arch, err := unattendArch(config)
+	if err != nil {
+		arch = "amd64"
+	}
+	var dirs []string
+	for _, driver := range []string{"viostor", "vioscsi", "NetKVM"} {
+		dirs = append(dirs, driver+`\w11\`+arch)
+	}
+	return strings.Join(dirs, "\n")
+}
+
+// encodeUnattendPassword는 응답 파일의 PlainText=false 암호 형식(UTF-16LE(암호+"Password")의 base64)입니다.
+// 설정 파일에 평문이 보이지 않게 할 뿐 누구나 되돌릴 수 있습니다.
+func encodeUnattendPassword(password string) string {
+	units := utf16.Encode([]rune(password + "Password"))
+	b := make([]byte, len(units)*2)
+	for i, u := range units {
+		binary.LittleEndian.PutUint16(b[i*2:], u)
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+func xmlText(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// unattendDriverPaths는 드라이브 문자가 없는 경로를 D:부터 H:까지 펼칩니다.
+func unattendDriverPaths(drivers string) []string {
+	var paths []string
+	for _, line := range nonEmptyLines(drivers) {
+		if len(line) >= 2 && line[1] == ':' {
+			paths = append(paths, line)
+			continue
+		}
+		for _, letter := range unattendDriverLetters {
+			paths = append(paths, string(letter)+`:\`+strings.TrimLeft(line, `\`))
+		}
+	}
+	return paths
+}
+
+// unattendXML은 autounattend.xml을 만듭니다.
+// windowsPE 단계에서 언어, 드라이버, 디스크, 에디션을 정하고 oobeSystem 단계에서 로컬 계정을 만들어
+// 설치 중 입력 없이 바탕 화면까지 진행합니다.
+func unattendXML(config VMConfig) (string, error) {
+	arch, err := unattendArch(config)
+	if err != nil {
+		return "", err
+	}
+	u := config.Unattend
+	locale := u.Locale
+	if locale == "" {
+		locale = defaultUnattendLocale
+	}
+	user := u.User
+	if user == "" {
+		user = defaultUnattendUser
+	}
+	component := func(b *strings.Builder, name string) {
+		fmt.Fprintf(b, `    <component name="%s" processorArchitecture="%s" publicKeyToken="31bf3856ad364e35" language="neutral" versionScope="nonSxS" xmlns:wcm="http://schemas.microsoft.com/WMIConfig/2002/State">`+"\n", name, arch)
+	}
+	international := func(b *strings.Builder, indent string) {
+		for _, key := range []string{"InputLocale", "SystemLocale", "UILanguage", "UserLocale"} {
+			fmt.Fprintf(b, "%s<%s>%s</%s>\n", indent, key, xmlText(locale), key)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("<?xml version=\"1.0\" encoding=\"utf-8\"?>\n")
+	b.WriteString("<unattend xmlns=\"urn:schemas-microsoft-com:unattend\">\n")
+
+	b.WriteString("  <settings pass=\"windowsPE\">\n")
+	component(&b, "Microsoft-Windows-International-Core-WinPE")
+	fmt.Fprintf(&b, "      <SetupUILanguage>\n        <UILanguage>%s</UILanguage>\n      </SetupUILanguage>\n", xmlText(locale))
+	international(&b, "      ")
+	b.WriteString("    </component>\n")
+
+	if paths := unattendDriverPaths(u.Drivers); len(paths) > 0 {
+		component(&b, "Microsoft-Windows-PnpCustomizationsWinPE")
+		b.WriteString("      <DriverPaths>\n")
+		for i, path := range paths {
+			fmt.Fprintf(&b, "        <PathAndCredentials wcm:action=\"add\" wcm:keyValue=\"%d\">\n          <Path>%s</Path>\n        </PathAndCredentials>\n", i+1, xmlText(path))
+		}
+		b.WriteString("      </DriverPaths>\n")
+		b.WriteString("    </component>\n")
+	}
+
+	component(&b, "Microsoft-Windows-Setup")
+	if u.Partition != "manual" {
+		// UEFI: EFI 시스템(FAT32), MSR, Windows / BIOS: 시스템 예약(활성), Windows
+		b.WriteString("      <DiskConfiguration>\n        <Disk wcm:action=\"add\">\n          <DiskID>0</DiskID>\n          <WillWipeDisk>true</WillWipeDisk>\n")
+		type partition struct {
+			typ, size, format, label string
+		}
+		parts := []partition{{"Primary", "500", "NTFS", "System Reserved"}, {"Primary", "", "NTFS", "Windows"}}
+		if config.Firmware == "uefi" {
+			parts = []partition{{"EFI", "100", "FAT32", "System"}, {"MSR", "16", "", ""}, {"Primary", "", "NTFS", "Windows"}}
+		}
+		b.WriteString("          <CreatePartitions>\n")
+		for i, p := range parts {
+			fmt.Fprintf(&b, "            <CreatePartition wcm:action=\"add\">\n              <Order>%d</Order>\n              <Type>%s</Type>\n", i+1, p.typ)
+			if p.size != "" {
+				fmt.Fprintf(&b, "              <Size>%s</Size>\n", p.size)
+			} else {
+				b.WriteString("              <Extend>true</Extend>\n")
+			}
+			b.WriteString("            </CreatePartition>\n")
+		}
+		b.WriteString("          </CreatePartitions>\n          <ModifyPartitions>\n")
+		order := 0
+		for i, p := range parts {
+			if p.format == "" {
+				continue
+			}
+			order++
+			fmt.Fprintf(&b, "            <ModifyPartition wcm:action=\"add\">\n              <Order>%d</Order>\n              <PartitionID>%d</PartitionID>\n              <Format>%s</Format>\n              <Label>%s</Label>\n", order, i+1, p.format, p.label)
+			if config.Firmware != "uefi" && i == 0 {
+				b.WriteString("              <Active>true</Active>\n")
+			}
+			if p.label == "Windows" {
+				b.WriteString("              <Letter>C</Letter>\n")
+			}
+			b.WriteString("            </ModifyPartition>\n")
+		}
+		b.WriteString("          </ModifyPartitions>\n        </Disk>\n      </DiskConfiguration>\n")
+	}
+	b.WriteString("      <ImageInstall>\n        <OSImage>\n")
+	if u.Edition != "" {
+		fmt.Fprintf(&b, "          <InstallFrom>\n            <MetaData wcm:action=\"add\">\n              <Key>/IMAGE/NAME</Key>\n              <Value>%s</Value>\n            </MetaData>\n          </InstallFrom>\n", xmlText(u.Edition))
+	}
+	if u.Partition != "manual" {
+		windowsPartition := 2
+		if config.Firmware == "uefi" {
+			windowsPartition = 3
+		}
+		fmt.Fprintf(&b, "          <InstallTo>\n            <DiskID>0</DiskID>\n            <PartitionID>%d</PartitionID>\n          </InstallTo>\n", windowsPartition)
+	}
+	b.WriteString("        </OSImage>\n      </ImageInstall>\n")
+	b.WriteString("      <UserData>\n        <AcceptEula>true</AcceptEula>\n")
+	if u.ProductKey != "" {
+		fmt.Fprintf(&b, "        <ProductKey>\n          <Key>%s</Key>\n          <WillShowUI>OnError</WillShowUI>\n        </ProductKey>\n", xmlText(u.ProductKey))
+	}
+	b.WriteString("      </UserData>\n")
+	b.WriteString("    </component>\n")
+	b.WriteString("  </settings>\n")
+
+	b.WriteString("  <settings pass=\"oobeSystem\">\n")
+	component(&b, "Microsoft-Windows-International-Core")
+	international(&b, "      ")
+	b.WriteString("    </component>\n")
+	component(&b, "Microsoft-Windows-Shell-Setup")
+	b.WriteString("      <OOBE>\n")
+	for _, key := range []string{"HideEULAPage", "HideOEMRegistrationScreen", "HideOnlineAccountScreens", "HideWirelessSetupInOOBE"} {
+		fmt.Fprintf(&b, "        <%s>true</%s>\n", key, key)
+	}
+	b.WriteString("        <ProtectYourPC>3</ProtectYourPC>\n      </OOBE>\n")
+	password := "<Value></Value>\n              <PlainText>true</PlainText>"
+	if u.Password != "" {
+		password = "<Value>" + xmlText(u.Password) + "</Value>\n              <PlainText>false</PlainText>"
+	}
+	fmt.Fprintf(&b, "      <UserAccounts>\n        <LocalAccounts>\n          <LocalAccount wcm:action=\"add\">\n            <Name>%s</Name>\n            <DisplayName>%s</DisplayName>\n            <Group>Administrators</Group>\n            <Password>\n              %s\n            </Password>\n          </LocalAccount>\n        </LocalAccounts>\n      </UserAccounts>\n",
+		xmlText(user), xmlText(user), password)
+	b.WriteString("    </component>\n")
+	b.WriteString("  </settings>\n")
+	b.WriteString("</unattend>\n")
+	return b.String(), nil
+}
+
+// 기본 응답 파일 ISO 경로 (관리형이면 가상머신 폴더)
+func defaultUnattendMedia(configDir string, config VMConfig) string {
+	if config.Managed {
+		return filepath.Join(vmStorageDir(configDir, config.Name), "unattend.iso")
+	}
+	return filepath.Join(configDir, "unattend", config.Name+"-unattend.iso")
+}
+
+// ensureUnattendMedia는 자동 설치가 켜져 있으면 응답 파일 ISO를 만들고, 설정이 바뀌었으면 다시 만듭니다.
+func ensureUnattendMedia(configDir string, config *VMConfig) error {
+	if !config.Unattend.Enabled {
+		return nil
+	}
+	xmlData, err := unattendXML(*config)
+	if err != nil {
+		return err
+	}
+	if config.Unattend.Media == "" {
+		config.Unattend.Media = defaultUnattendMedia(configDir, *config)
+	}
+	if err := os.MkdirAll(filepath.Dir(config.Unattend.Media), os.ModePerm); err != nil {
+		return err
+	}
+	_, err = writeISO9660(config.Unattend.Media, "UNATTEND", []isoFile{{name: "autounattend.xml", data: []byte(xmlData)}})
+	return err
+}
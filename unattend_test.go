@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/xml"
+	"io"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEncodeUnattendPassword(t *testing.T) {
+	// Windows SIM이 PlainText=false로 저장하는 값과 같아야 함
+	for password, want := range map[string]string{
+		"":         "UABhAHMAcwB3AG8AcgBkAA==",
+		"P@ssw0rd": "UABAAHMAcwB3ADAAcgBkAFAAYQBzAHMAdwBvAHIAZAA=",
+		"비밀번호":     "RL4AvIi8ONZQAGEAcwBzAHcAbwByAGQA",
+	} {
+		if got := encodeUnattendPassword(password); got != want {
+			t.Errorf("encodeUnattendPassword(%q) = %q, want %q", password, got, want)
+		}
+	}
+}
+
+func TestUnattendDriverPaths(t *testing.T) {
+	got := unattendDriverPaths("viostor\\w11\\amd64\n\n  E:\\NetKVM\\w11\\amd64  \n\\vioscsi")
+	want := []string{
+		`D:\viostor\w11\amd64`, `E:\viostor\w11\amd64`, `F:\viostor\w11\amd64`, `G:\viostor\w11\amd64`, `H:\viostor\w11\amd64`,
+		`E:\NetKVM\w11\amd64`,
+		`D:\vioscsi`, `E:\vioscsi`, `F:\vioscsi`, `G:\vioscsi`, `H:\vioscsi`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unattendDriverPaths =\n%q\nwant\n%q", got, want)
+	}
+}
+
+// unattendValues는 응답 파일의 요소 경로("settings/component/UserData/ProductKey/Key") → 텍스트 목록입니다.
+func unattendValues(t *testing.T, text string) map[string][]string {
+	t.Helper()
+	values := make(map[string][]string)
+	dec := xml.NewDecoder(strings.NewReader(text))
+	var path []string
+	var chars string
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return values
+		}
+		if err != nil {
+			t.Fatalf("XML 오류: %v\n%s", err, text)
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			path = append(path, tok.Name.Local)
+			chars = ""
+		case xml.CharData:
+			chars += string(tok)
+		case xml.EndElement:
+			key := strings.Join(path[1:], "/")
+			values[key] = append(values[key], strings.TrimSpace(chars))
+			path = path[:len(path)-1]
+			chars = ""
+		}
+	}
+}
+
+func TestUnattendXML(t *testing.T) {
+	config := VMConfig{
+		CPUModel: "Intel: Skylake-Server/Client", Firmware: "uefi",
+		Unattend: unattendConfig{
+			Enabled: true, Edition: "Windows 11 Pro <N> & more", ProductKey: "VK7JG-NPHTM-C97JM-9MPGT-3V66T",
+			User: "홍길동", Password: encodeUnattendPassword("pw"), Drivers: "E:\\viostor\\w11\\amd64",
+		},
+	}
+	text, err := unattendXML(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := unattendValues(t, text)
+	checks := map[string][]string{
+		"settings/component/SetupUILanguage/UILanguage":                                   {"ko-KR"},
+		"settings/component/DriverPaths/PathAndCredentials/Path":                          {`E:\viostor\w11\amd64`},
+		"settings/component/DiskConfiguration/Disk/CreatePartitions/CreatePartition/Type": {"EFI", "MSR", "Primary"},
+		"settings/component/ImageInstall/OSImage/InstallFrom/MetaData/Value":              {"Windows 11 Pro <N> & more"},
+		"settings/component/ImageInstall/OSImage/InstallTo/PartitionID":                   {"3"},
+		"settings/component/UserData/ProductKey/Key":                                      {"VK7JG-NPHTM-C97JM-9MPGT-3V66T"},
+		"settings/component/UserAccounts/LocalAccounts/LocalAccount/Name":                 {"홍길동"},
+		"settings/component/UserAccounts/LocalAccounts/LocalAccount/Password/Value":       {encodeUnattendPassword("pw")},
+		"settings/component/UserAccounts/LocalAccounts/LocalAccount/Password/PlainText":   {"false"},
+	}
+	for key, want := range checks {
+		if !reflect.DeepEqual(v[key], want) {
+			t.Errorf("%s = %q, want %q", key, v[key], want)
+		}
+	}
+	if !strings.Contains(text, `processorArchitecture="amd64"`) {
+		t.Error("amd64 구성 요소가 아닙니다")
+	}
+
+	// BIOS는 활성 시스템 예약 파티션 뒤 2번에 설치, 직접 나누면 디스크 설정 없음
+	config.Firmware = ""
+	config.Unattend.Password = ""
+	text, _ = unattendXML(config)
+	v = unattendValues(t, text)
+	if got := v["settings/component/ImageInstall/OSImage/InstallTo/PartitionID"]; !reflect.DeepEqual(got, []string{"2"}) {
+		t.Errorf("BIOS PartitionID = %q", got)
+	}
+	if got := v["settings/component/DiskConfiguration/Disk/ModifyPartitions/ModifyPartition/Active"]; !reflect.DeepEqual(got, []string{"true"}) {
+		t.Errorf("BIOS Active = %q", got)
+	}
+	if got := v["settings/component/UserAccounts/LocalAccounts/LocalAccount/Password/PlainText"]; !reflect.DeepEqual(got, []string{"true"}) {
+		t.Errorf("빈 암호 PlainText = %q", got)
+	}
+	config.Unattend.Partition = "manual"
+	text, _ = unattendXML(config)
+	if v = unattendValues(t, text); v["settings/component/DiskConfiguration"] != nil || v["settings/component/ImageInstall/OSImage/InstallTo"] != nil {
+		t.Errorf("직접 나누기인데 디스크 설정이 있습니다:\n%s", text)
+	}
+
+	config.CPUModel = "ARM: Cortex-A57"
+	if text, _ = unattendXML(config); !strings.Contains(text, `processorArchitecture="arm64"`) {
+		t.Error("arm64 구성 요소가 아닙니다")
+	}
+	config.CPUModel = "MIPS: 24Kc/24KEc/24Kf"
+	if _, err := unattendXML(config); err == nil {
+		t.Error("MIPS에서 응답 파일을 만들었습니다")
+	}
+}
+
+func TestEnsureUnattendMedia(t *testing.T) {
+	configDir := t.TempDir()
+	config := VMConfig{Name: "win", Unattend: unattendConfig{Enabled: true}}
+	if err := ensureUnattendMedia(configDir, &config); err != nil {
+		t.Fatal(err)
+	}
+	if config.Unattend.Media != filepath.Join(configDir, "unattend", "win-unattend.iso") {
+		t.Errorf("Media = %q", config.Unattend.Media)
+	}
+	files := readISORoot(t, []byte(readTestFile(t, config.Unattend.Media)), isoJolietSector)
+	want, _ := unattendXML(config)
+	if len(files) != 1 || files["autounattend.xml"] != want {
+		t.Errorf("ISO 내용 = %q", files)
+	}
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// VirtualBox .vbox 설정 중 가져오기에 쓰는 부분
+type vboxFile struct {
+	XMLName xml.Name    `xml:"VirtualBox"`
+	Machine vboxMachine `xml:"Machine"`
+}
+
+type vboxMachine struct {
+	Name      string         `xml:"name,attr"`
+	UUID      string         `xml:"uuid,attr"`
+	HardDisks []vboxHardDisk `xml:"MediaRegistry>HardDisks>HardDisk"`
+	DVDImages []vboxImage    `xml:"MediaRegistry>DVDImages>Image"`
+	Hardware  vboxHardware   `xml:"Hardware"`
+	Storage   []vboxStorCtrl `xml:"StorageControllers>StorageController"` // 6.0 이전 형식
+}
+
+// 차등 이미지(스냅샷)는 부모 HardDisk 안에 중첩됨
+type vboxHardDisk struct {
+	UUID     string         `xml:"uuid,attr"`
+	Location string         `xml:"location,attr"`
+	Format   string         `xml:"format,attr"`
+	Children []vboxHardDisk `xml:"HardDisk"`
+}
+
+type vboxImage struct {
+	UUID     string `xml:"uuid,attr"`
+	Location string `xml:"location,attr"`
+}
+
+type vboxFirmware struct {
+	Type string `xml:"type,attr"`
+}
+
+type vboxHardware struct {
+	CPU struct {
+		Count int `xml:"count,attr"`
+	} `xml:"CPU"`
+	Memory struct {
+		RAMSize int64 `xml:"RAMSize,attr"`
+	} `xml:"Memory"`
+	Firmware         *vboxFirmware `xml:"Firmware"`
+	PlatformFirmware *vboxFirmware `xml:"Platform>Firmware"` // 7.1 이후 형식
+	TPM              *struct {
+		Type string `xml:"type,attr"`
+	} `xml:"TrustedPlatformModule"`
+	Adapters []vboxAdapter  `xml:"Network>Adapter"`
+	Storage  []vboxStorCtrl `xml:"StorageControllers>StorageController"`
+}
+
+type vboxAdapter struct {
+	Slot    int    `xml:"slot,attr"`
+	Enabled bool   `xml:"enabled,attr"`
+	MAC     string `xml:"MACAddress,attr"`
+	// 연결 방식 (NAT, BridgedInterface ...)과 DisabledModes
+	Attachments []struct {
+		XMLName xml.Name
+	} `xml:",any"`
+}
+
+type vboxStorCtrl struct {
+	Name    string `xml:"name,attr"`
+	Type    string `xml:"type,attr"`
+	Devices []struct {
+		Type   string `xml:"type,attr"` // HardDisk, DVD
+		Port   int    `xml:"port,attr"`
+		Device int    `xml:"device,attr"`
+		Image  *struct {
+			UUID string `xml:"uuid,attr"`
+		} `xml:"Image"`
+	} `xml:"AttachedDevice"`
+}
+
+// VirtualBox 컨트롤러 종류 → 디스크 버스 (SCSI와 SAS는 virtio-scsi로)
+var vboxBus = map[string]string{
+	"PIIX3": "ide", "PIIX4": "ide", "ICH6": "ide",
+	"AHCI":     "ahci",
+	"LsiLogic": "virtio-scsi", "BusLogic": "virtio-scsi", "LsiLogicSas": "virtio-scsi", "VirtioSCSI": "virtio-scsi",
+	"NVMe": "nvme",
+}
+
+// VirtualBox 이미지 형식 → qemu-img 포맷 이름
+var vboxFormat = map[string]string{
+	"VDI": "vdi", "VMDK": "vmdk", "VHD": "vpc", "PARALLELS": "parallels", "QED": "qed", "QCOW": "qcow",
+}
+
+// parseVBoxFile은 VirtualBox .vbox 파일을 읽어 가져올 가상머신을 만듭니다.
+func parseVBoxFile(path string) (foreignVM, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return foreignVM{}, err
+	}
+	var file vboxFile
+	if err := xml.Unmarshal(data, &file); err != nil {
+		return foreignVM{}, fmt.Errorf("VirtualBox 설정 파일을 읽을 수 없습니다: %w", err)
+	}
+	m := file.Machine
+	dir := filepath.Dir(path)
+	resolve := func(p string) string {
+		if p == "" || filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(dir, p)
+	}
+
+	var vm foreignVM
+	config := &vm.config
+	config.Name = m.Name
+	config.UUID = strings.Trim(m.UUID, "{}")
+	config.CPUCores = "1"
+	if n := m.Hardware.CPU.Count; n > 0 {
+		config.CPUCores = fmt.Sprint(n)
+	}
+	if m.Hardware.Memory.RAMSize > 0 {
+		config.RAM, _ = importMemorySize(fmt.Sprint(m.Hardware.Memory.RAMSize))
+	}
+	firmware := m.Hardware.Firmware
+	if firmware == nil {
+		firmware = m.Hardware.PlatformFirmware
+	}
+	if firmware != nil && strings.HasPrefix(strings.ToUpper(firmware.Type), "EFI") {
+		// 변수 저장소(부팅 항목)는 새로 만들어짐
+		config.Firmware = "uefi"
+	}
+	if tpm := m.Hardware.TPM; tpm != nil && tpm.Type != "" && tpm.Type != "None" {
+		vm.notes = append(vm.notes, "TPM은 가져오지 않았습니다.")
+	}
+
+	// 이미지 UUID → 기반 이미지부터의 체인
+	chains := make(map[string][]vboxHardDisk)
+	var walk func(disks []vboxHardDisk, parents []vboxHardDisk)
+	walk = func(disks []vboxHardDisk, parents []vboxHardDisk) {
+		for _, d := range disks {
+			chain := append(append([]vboxHardDisk{}, parents...), d)
+			chains[d.UUID] = chain
+			walk(d.Children, chain)
+		}
+	}
+	walk(m.HardDisks, nil)
+
+	controllers := m.Hardware.Storage
+	if len(controllers) == 0 {
+		controllers = m.Storage
+	}
+	var cdroms []cdromConfig
+	scsi := false
+	for _, ctrl := range controllers {
+		bus, ok := vboxBus[ctrl.Type]
+		if !ok {
+			if len(ctrl.Devices) > 0 {
+				vm.notes = append(vm.notes, ctrl.Name+" 컨트롤러("+ctrl.Type+")의 장치는 가져오지 않았습니다.")
+			}
+			continue
+		}
+		for _, dev := range ctrl.Devices {
+			switch dev.Type {
+			case "DVD":
+				cd := cdromConfig{Bus: bus}
+				if dev.Image != nil {
+					for _, img := range m.DVDImages {
+						if img.UUID == dev.Image.UUID {
+							cd.Path = resolve(img.Location)
+						}
+					}
+				}
+				cdroms = append(cdroms, cd)
+			case "HardDisk":
+				if dev.Image == nil {
+					continue
+				}
+				chain, ok := chains[dev.Image.UUID]
+				if !ok {
+					return foreignVM{}, fmt.Errorf("%s 이미지가 설정 파일의 미디어 목록에 없습니다.", dev.Image.UUID)
+				}
+				var layers []foreignLayer
+				for i, d := range chain {
+					format := vboxFormat[strings.ToUpper(d.Format)]
+					if format == "" {
+						return foreignVM{}, fmt.Errorf("%s: %s 형식은 지원하지 않습니다.", d.Location, d.Format)
+					}
+					// QEMU는 VirtualBox 차등 VDI를 읽지 못함
+					if format == "vdi" && i > 0 {
+						return foreignVM{}, errors.New(resolve(chain[len(chain)-1].Location) +
+							": VDI 스냅샷은 가져올 수 없습니다. VirtualBox에서 스냅샷을 삭제하거나 VBoxManage clonemedium으로 병합한 뒤 다시 시도하세요.")
+					}
+					layers = append(layers, foreignLayer{path: resolve(d.Location), format: format})
+				}
+				vm.disks = append(vm.disks, foreignDisk{chain: layers, bus: bus})
+				scsi = scsi || bus == "virtio-scsi"
+			}
+		}
+	}
+	config.CDROM = formatCDROMConfigs(cdroms)
+	if scsi {
+		vm.notes = append(vm.notes, "SCSI 디스크는 virtio-scsi로 연결됩니다. 게스트에 virtio 드라이버가 필요합니다.")
+	}
+
+	// 네트워크 어댑터는 첫 번째만 사용자 모드 NAT로
+	nics := 0
+	for _, a := range m.Hardware.Adapters {
+		if !a.Enabled {
+			continue
+		}
+		nics++
+		if nics > 1 {
+			continue
+		}
+		config.Network = "user"
+		config.MAC = ovfMACAddress(a.MAC)
+		for _, att := range a.Attachments {
+			if name := att.XMLName.Local; name != "DisabledModes" && name != "NAT" {
+				vm.notes = append(vm.notes, name+" 네트워크 연결은 사용자 모드 NAT로 바꾸었습니다.")
+			}
+		}
+	}
+	if nics == 0 {
+		config.Network = "none"
+	} else if nics > 1 {
+		vm.notes = append(vm.notes, fmt.Sprintf("나머지 네트워크 어댑터 %d개는 가져오지 않았습니다.", nics-1))
+	}
+	return vm, nil
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseVBoxFile(t *testing.T) {
+	dir := t.TempDir()
+	// 절대 경로는 그대로, 상대 경로는 .vbox 폴더 기준
+	iso, data := filepath.Join(dir, "iso", "ubuntu.iso"), filepath.Join(dir, "vms", "data.vhd")
+	vbox := filepath.Join(dir, "Ubuntu.vbox")
+	writeTestFile(t, vbox, `<?xml version="1.0"?>
+<VirtualBox xmlns="http://www.virtualbox.org/" version="1.19-windows">
+  <Machine uuid="{0d7b2b8e-4f4c-4a7e-9c43-2f8a1b6e5d10}" name="Ubuntu" OSType="Ubuntu_64">
+    <MediaRegistry>
+      <HardDisks>
+        <HardDisk uuid="{11111111-1111-1111-1111-111111111111}" location="Ubuntu.vmdk" format="VMDK" type="Normal">
+          <HardDisk uuid="{22222222-2222-2222-2222-222222222222}" location="Snapshots/{2222}.vmdk" format="VMDK"/>
+        </HardDisk>
+        <HardDisk uuid="{33333333-3333-3333-3333-333333333333}" location="`+data+`" format="VHD" type="Normal"/>
+      </HardDisks>
+      <DVDImages>
+        <Image uuid="{44444444-4444-4444-4444-444444444444}" location="`+iso+`"/>
+      </DVDImages>
+    </MediaRegistry>
+    <Hardware>
+      <CPU count="2"/>
+      <Memory RAMSize="2048"/>
+      <Firmware type="EFI"/>
+      <TrustedPlatformModule type="v2_0"/>
+      <Network>
+        <Adapter slot="0" enabled="true" MACAddress="080027D1E0F4" type="82540EM">
+          <DisabledModes/>
+          <BridgedInterface name="eth0"/>
+        </Adapter>
+        <Adapter slot="1" enabled="true" MACAddress="080027000001">
+          <NAT/>
+        </Adapter>
+        <Adapter slot="2" enabled="false"/>
+      </Network>
+      <StorageControllers>
+        <StorageController name="SATA" type="AHCI" PortCount="2">
+          <AttachedDevice type="HardDisk" port="0" device="0">
+            <Image uuid="{22222222-2222-2222-2222-222222222222}"/>
+          </AttachedDevice>
+          <AttachedDevice passthrough="false" type="DVD" port="1" device="0">
+            <Image uuid="{44444444-4444-4444-4444-444444444444}"/>
+          </AttachedDevice>
+        </StorageController>
+        <StorageController name="SCSI" type="LsiLogic">
+          <AttachedDevice type="HardDisk" port="0" device="0">
+            <Image uuid="{33333333-3333-3333-3333-333333333333}"/>
+          </AttachedDevice>
+        </StorageController>
+        <StorageController name="Floppy" type="I82078">
+          <AttachedDevice type="Floppy" port="0" device="0"/>
+        </StorageController>
+      </StorageControllers>
+    </Hardware>
+  </Machine>
+</VirtualBox>
+`)
+	vm, err := parseVBoxFile(vbox)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := VMConfig{
+		Name: "Ubuntu", UUID: "0d7b2b8e-4f4c-4a7e-9c43-2f8a1b6e5d10", CPUCores: "2", RAM: "2048MB", Firmware: "uefi",
+		CDROM:   formatCDROMConfigs([]cdromConfig{{Bus: "ahci", Path: iso}}),
+		Network: "user", MAC: "08:00:27:d1:e0:f4",
+	}
+	if vm.config != want {
+		t.Errorf("config =\n%+v\nwant\n%+v", vm.config, want)
+	}
+	wantDisks := []foreignDisk{
+		{bus: "ahci", chain: []foreignLayer{
+			{path: filepath.Join(dir, "Ubuntu.vmdk"), format: "vmdk"},
+			{path: filepath.Join(dir, "Snapshots/{2222}.vmdk"), format: "vmdk"},
+		}},
+		{bus: "virtio-scsi", chain: []foreignLayer{{path: data, format: "vpc"}}},
+	}
+	if !reflect.DeepEqual(vm.disks, wantDisks) {
+		t.Errorf("disks =\n%+v\nwant\n%+v", vm.disks, wantDisks)
+	}
+	// TPM, 플로피 컨트롤러, SCSI, 브리지 연결, 나머지 NIC
+	if len(vm.notes) != 5 || !strings.Contains(strings.Join(vm.notes, "\n"), "BridgedInterface") {
+		t.Errorf("notes = %q", vm.notes)
+	}
+}
+
+func TestParseVBoxFileVDISnapshot(t *testing.T) {
+	vbox := filepath.Join(t.TempDir(), "old.vbox")
+	writeTestFile(t, vbox, `<VirtualBox><Machine name="old">
+  <MediaRegistry><HardDisks>
+    <HardDisk uuid="{a}" location="old.vdi" format="VDI"><HardDisk uuid="{b}" location="Snapshots/{b}.vdi" format="VDI"/></HardDisk>
+  </HardDisks></MediaRegistry>
+  <StorageControllers><StorageController name="IDE" type="PIIX4">
+    <AttachedDevice type="HardDisk" port="0" device="0"><Image uuid="{b}"/></AttachedDevice>
+  </StorageController></StorageControllers>
+  <Hardware/>
+</Machine></VirtualBox>`)
+	_, err := parseVBoxFile(vbox)
+	if err == nil || !strings.Contains(err.Error(), "clonemedium") {
+		t.Errorf("err = %v", err)
+	}
+}
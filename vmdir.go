@@ -0,0 +1,308 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 관리형 레이아웃: 가상머신마다 설정 폴더 아래 vms\<이름> 폴더를 두고 설정(vm.conf)과
+// 디스크(diskN.*)는 폴더에 바로, UEFI 변수는 nvram, TPM 상태는 tpm, QEMU 출력은 logs에 보관합니다.
+// 스냅샷은 qcow2 디스크 안에 저장되므로 따로 폴더를 두지 않습니다.
+// 폴더 안 파일의 경로는 설정 파일에 폴더 기준 상대 경로로 저장하므로 폴더째 옮길 수 있습니다.
+const vmDirConfigName = "vm.conf"
+
+var vmSubdirs = []string{"nvram", "tpm", "logs"}
+
+// 가상머신 폴더 (가져온 가상머신의 기본 디스크 폴더이기도 함)
+func vmStorageDir(configDir, name string) string {
+	return filepath.Join(configDir, "vms", name)
+}
+
+// vmConfigPath는 가상머신 설정 파일의 경로입니다.
+func vmConfigPath(configDir string, config VMConfig) string {
+	if config.Managed {
+		return filepath.Join(vmStorageDir(configDir, config.Name), vmDirConfigName)
+	}
+	return filepath.Join(configDir, config.Name+".conf")
+}
+
+// vmConfigExists는 두 레이아웃 중 하나로 같은 이름의 가상머신이 있는지 확인합니다.
+func vmConfigExists(configDir, name string) bool {
+	for _, managed := range []bool{false, true} {
+		if _, err := os.Stat(vmConfigPath(configDir, VMConfig{Name: name, Managed: managed})); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// createVMDir는 새 가상머신의 폴더와 하위 폴더를 만듭니다.
+// 지운 가상머신의 디스크 등 파일이 남아 있는 폴더는 쓰지 않습니다.
+func createVMDir(configDir, name string) error {
+	dir := vmStorageDir(configDir, name)
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if sub, _ := os.ReadDir(filepath.Join(dir, e.Name())); !e.IsDir() || len(sub) > 0 {
+			return fmt.Errorf("%s 폴더에 다른 파일이 남아 있습니다.", dir)
+		}
+	}
+	return makeVMSubdirs(dir)
+}
+
+func makeVMSubdirs(dir string) error {
+	for _, sub := range vmSubdirs {
+		if err := os.MkdirAll(filepath.Join(dir, sub), os.ModePerm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 비어 있는 하위 폴더와 폴더를 지움 (파일이 남아 있으면 그대로 둠)
+// 예전 버전이 만든 빈 snapshots 폴더 등도 함께 지웁니다.
+func removeEmptyVMDir(dir string) {
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if e.IsDir() {
+			os.Remove(filepath.Join(dir, e.Name()))
+		}
+	}
+	os.Remove(dir)
+}
+
+// vmLogPath는 QEMU의 오류 출력을 남길 파일입니다. 관리형이 아니면 "" (로그를 남기지 않음)
+func vmLogPath(configDir string, config VMConfig) string {
+	if !config.Managed {
+		return ""
+	}
+	return filepath.Join(vmStorageDir(configDir, config.Name), "logs", "qemu.log")
+}
+
+// 폴더 안의 경로인지 (폴더 자체는 제외)
+func pathInDir(path, dir string) (string, bool) {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) || filepath.IsAbs(rel) {
+		return "", false
+	}
+	return rel, true
+}
+
+// relativeVMPaths는 폴더 안 파일의 절대 경로를 폴더 기준 상대 경로로 바꿉니다 (저장할 때).
+func relativeVMPaths(config VMConfig, dir string) VMConfig {
+	config, _ = rewriteConfigPaths(config, func(path, role string) (string, error) {
+		if !filepath.IsAbs(path) {
+			return path, nil
+		}
+		if rel, ok := pathInDir(path, dir); ok {
+			return rel, nil
+		}
+		return path, nil
+	})
+	return config
+}
+
+// absoluteVMPaths는 상대 경로를 폴더 기준 절대 경로로 바꿉니다 (읽을 때).
+func absoluteVMPaths(config VMConfig, dir string) VMConfig {
+	config, _ = rewriteConfigPaths(config, func(path, role string) (string, error) {
+		if path == "" || filepath.IsAbs(path) {
+			return path, nil
+		}
+		return filepath.Join(dir, path), nil
+	})
+	return config
+}
+
+// moveVMDir는 이름을 바꿀 때 가상머신 폴더를 통째로 옮기고(같은 볼륨 안에서 os.Rename은 원자적),
+// 설정 안의 절대 경로와 자격 증명 관리자에 저장된 디스크 암호도 새 폴더 기준으로 바꿉니다.
+func moveVMDir(configDir string, config *VMConfig, oldName string) error {
+	oldDir := vmStorageDir(configDir, oldName)
+	newDir := vmStorageDir(configDir, config.Name)
+	if _, err := os.Stat(newDir); err == nil {
+		return fmt.Errorf("%s 폴더가 이미 있습니다.", newDir)
+	}
+	if err := os.Rename(oldDir, newDir); err != nil {
+		return err
+	}
+	moved := make(map[string]string)
+	*config, _ = rewriteConfigPaths(*config, func(path, role string) (string, error) {
+		if rel, ok := pathInDir(path, oldDir); ok {
+			moved[path] = filepath.Join(newDir, rel)
+			return moved[path], nil
+		}
+		return path, nil
+	})
+	moveDiskPassphrases(*config, moved)
+	return nil
+}
+
+// moveDiskPassphrases는 옮긴 암호화 디스크(이전 경로 → 새 경로)의 저장된 암호를 새 경로로 옮깁니다.
+func moveDiskPassphrases(config VMConfig, moved map[string]string) {
+	for _, d := range parseDiskConfigs(config.Disk) {
+		for oldPath, newPath := range moved {
+			if newPath != d.Path || !d.Encrypted {
+				continue
+			}
+			if passphrase, ok := loadDiskPassphrase(oldPath); ok {
+				if storeDiskPassphrase(newPath, passphrase) == nil {
+					deleteDiskPassphrase(oldPath)
+				}
+			}
+		}
+	}
+}
+
+// convertToManaged는 기존 가상머신을 관리형 레이아웃으로 바꿉니다. 디스크, UEFI 변수 저장소,
+// TPM 상태를 가상머신 폴더로 옮기고(os.Rename) 설정을 vm.conf로 저장한 뒤 이전 .conf를 지웁니다.
+// 다른 볼륨에 있는 디스크와 다른 가상머신의 기반 이미지인 디스크는 그대로 두고 notes에 적습니다.
+// 중간에 실패하면 옮긴 파일을 되돌립니다.
+func convertToManaged(configDir string, config VMConfig) (VMConfig, []string, error) {
+	if config.Managed {
+		return config, nil, fmt.Errorf("%s 가상머신은 이미 관리형입니다.", config.Name)
+	}
+	if vmRunning(config.Name) {
+		return config, nil, fmt.Errorf("%s 가상머신이 실행 중입니다. 끈 뒤에 바꾸십시오.", config.Name)
+	}
+	if err := createVMDir(configDir, config.Name); err != nil {
+		return config, nil, err
+	}
+	dir := vmStorageDir(configDir, config.Name)
+	var notes []string
+	backing := backingImageUsers(configDir, config.Name)
+
+	// 옮길 파일 (이전 경로 → 새 경로)
+	type move struct{ from, to string }
+	var moves []move
+	var usedPaths []string
+	managed := config
+	managed.Managed = true
+	converted, err := rewriteConfigPaths(config, func(path, role string) (string, error) {
+		var to string
+		switch role {
+		case "disk":
+			if !strings.EqualFold(filepath.VolumeName(path), filepath.VolumeName(dir)) {
+				notes = append(notes, path+": 다른 드라이브에 있어 옮기지 않았습니다.")
+				return path, nil
+			}
+			if user, ok := backing[strings.ToLower(filepath.Clean(path))]; ok {
+				notes = append(notes, path+": "+user+" 가상머신의 기반 이미지라서 옮기지 않았습니다.")
+				return path, nil
+			}
+			to = newVMDiskPath(dir, filepath.Ext(path), usedPaths)
+			usedPaths = append(usedPaths, to)
+		case "nvram":
+			if path != config.NVRAM {
+				return path, nil // 추가 인자의 pflash는 그대로
+			}
+			to = defaultNVRAMPath(configDir, managed)
+		case "tpm":
+			to = defaultTPMSocket(configDir, managed)
+			states, err := tpmStateFiles(path)
+			if err != nil {
+				return "", err
+			}
+			for _, state := range states {
+				moves = append(moves, move{state, filepath.Join(filepath.Dir(to), filepath.Base(state))})
+			}
+			return to, nil
+		case "agent":
+			return defaultGuestAgentSocket(configDir, managed), nil
+		case "seed", "unattend":
+			return "", nil // 관리형 폴더에 다시 만듦
+		default:
+			return path, nil // 커널, 펌웨어 등 공유 파일
+		}
+		if _, err := os.Stat(path); err != nil {
+			return path, nil // 아직 만들지 않은 파일
+		}
+		moves = append(moves, move{path, to})
+		return to, nil
+	})
+	if err != nil {
+		removeEmptyVMDir(dir)
+		return config, nil, err
+	}
+
+	var done []move
+	converted.Managed = true
+	undo := func() {
+		if converted.CloudInit.Seed != "" {
+			os.Remove(converted.CloudInit.Seed)
+		}
+		if converted.Unattend.Media != "" {
+			os.Remove(converted.Unattend.Media)
+		}
+		for i := len(done) - 1; i >= 0; i-- {
+			os.Rename(done[i].to, done[i].from)
+		}
+		removeEmptyVMDir(dir)
+	}
+	for _, m := range moves {
+		if err := os.Rename(m.from, m.to); err != nil {
+			undo()
+			return config, nil, err
+		}
+		done = append(done, m)
+	}
+
+	if err := ensureCloudInitSeed(configDir, &converted); err != nil {
+		undo()
+		return config, nil, err
+	}
+	if err := ensureUnattendMedia(configDir, &converted); err != nil {
+		undo()
+		return config, nil, err
+	}
+	if err := saveVMConfig(configDir, converted); err != nil {
+		os.Remove(vmConfigPath(configDir, converted))
+		undo()
+		return config, nil, err
+	}
+	os.Remove(vmConfigPath(configDir, config))
+	if config.CloudInit.Seed != "" {
+		os.Remove(config.CloudInit.Seed)
+	}
+	if config.Unattend.Media != "" {
+		os.Remove(config.Unattend.Media)
+	}
+	if config.TPM != "" {
+		os.Remove(filepath.Dir(config.TPM)) // 비어 있을 때만 지워짐
+	}
+
+	moved := make(map[string]string)
+	for _, m := range done {
+		moved[m.from] = m.to
+	}
+	moveDiskPassphrases(converted, moved)
+	return converted, notes, nil
+}
+
+// removeVMConfig는 설정 파일을 지웁니다. 관리형이면 QEMU 로그와 빈 하위 폴더, 폴더도 지우고,
+// 디스크 등 남은 파일은 그대로 둡니다.
+func removeVMConfig(configDir string, config VMConfig) error {
+	if err := os.Remove(vmConfigPath(configDir, config)); err != nil {
+		return err
+	}
+	if config.Managed {
+		os.Remove(vmLogPath(configDir, config))
+		removeEmptyVMDir(vmStorageDir(configDir, config.Name))
+	}
+	return nil
+}
+
+// newVMDiskPath는 관리형 가상머신 폴더에서 아직 쓰지 않은 diskN 파일 경로를 고릅니다.
+func newVMDiskPath(dir, ext string, used []string) string {
+	for n := 1; ; n++ {
+		path := filepath.Join(dir, fmt.Sprintf("disk%d%s", n, ext))
+		taken := false
+		for _, u := range used {
+			if strings.EqualFold(strings.TrimSuffix(u, filepath.Ext(u)), strings.TrimSuffix(path, ext)) {
+				taken = true
+			}
+		}
+		if _, err := os.Stat(path); !taken && os.IsNotExist(err) {
+			return path
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConvertToManaged(t *testing.T) {
+	configDir := t.TempDir()
+	old := VMConfig{Name: "legacy", RAM: "1GB", Firmware: "uefi"}
+	disk := filepath.Join(configDir, "disks", "legacy.qcow2")
+	old.NVRAM = defaultNVRAMPath(configDir, old)
+	old.TPM = defaultTPMSocket(configDir, old)
+	old.GuestAgent = defaultGuestAgentSocket(configDir, old)
+	old.Disk = formatDiskConfigs([]diskConfig{{Type: "QCOW2", Path: disk, Bus: "virtio-blk"}})
+	writeTestFile(t, disk, "disk")
+	writeTestFile(t, old.NVRAM, "vars")
+	writeTestFile(t, filepath.Join(filepath.Dir(old.TPM), "tpm2-00.permall"), "state")
+	if err := saveVMConfig(configDir, old); err != nil {
+		t.Fatal(err)
+	}
+
+	converted, _, err := convertToManaged(configDir, old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := vmStorageDir(configDir, old.Name)
+	if _, err := os.Stat(filepath.Join(configDir, "legacy.conf")); !os.IsNotExist(err) {
+		t.Errorf("이전 설정 파일이 남아 있습니다: %v", err)
+	}
+	loaded, err := loadVMConfig(configDir, old.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.Managed || loaded != converted {
+		t.Errorf("loaded = %+v\nconverted = %+v", loaded, converted)
+	}
+	disks := parseDiskConfigs(loaded.Disk)
+	if len(disks) != 1 || disks[0].Path != filepath.Join(dir, "disk1.qcow2") || disks[0].Bus != "virtio-blk" {
+		t.Errorf("disks = %+v", disks)
+	}
+	if readTestFile(t, disks[0].Path) != "disk" {
+		t.Error("디스크 내용이 다릅니다")
+	}
+	if loaded.NVRAM != filepath.Join(dir, "nvram", "VARS.fd") || readTestFile(t, loaded.NVRAM) != "vars" {
+		t.Errorf("NVRAM = %q", loaded.NVRAM)
+	}
+	if loaded.TPM != filepath.Join(dir, "tpm", "swtpm-sock") || readTestFile(t, filepath.Join(dir, "tpm", "tpm2-00.permall")) != "state" {
+		t.Errorf("TPM = %q", loaded.TPM)
+	}
+	if _, err := os.Stat(filepath.Dir(old.TPM)); !os.IsNotExist(err) {
+		t.Errorf("이전 TPM 폴더가 남아 있습니다: %v", err)
+	}
+	if loaded.GuestAgent != defaultGuestAgentSocket(configDir, loaded) {
+		t.Errorf("GuestAgent = %q", loaded.GuestAgent)
+	}
+	for _, p := range []string{disk, old.NVRAM} {
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			t.Errorf("%s가 남아 있습니다", p)
+		}
+	}
+
+	if _, _, err := convertToManaged(configDir, loaded); err == nil {
+		t.Error("이미 관리형인데 오류가 없습니다")
+	}
+}
+
+func TestConvertToManagedLeftoverDir(t *testing.T) {
+	configDir := t.TempDir()
+	old := VMConfig{Name: "legacy", Disk: formatDiskConfigs([]diskConfig{{Type: "RAW", Path: filepath.Join(configDir, "a.img")}})}
+	writeTestFile(t, filepath.Join(configDir, "a.img"), "disk")
+	writeTestFile(t, filepath.Join(vmStorageDir(configDir, "legacy"), "disk1.img"), "old")
+	if err := saveVMConfig(configDir, old); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := convertToManaged(configDir, old); err == nil {
+		t.Fatal("남은 파일이 있는 폴더인데 오류가 없습니다")
+	}
+	if readTestFile(t, filepath.Join(configDir, "a.img")) != "disk" {
+		t.Error("디스크를 옮겼습니다")
+	}
+	if _, err := os.Stat(filepath.Join(configDir, "legacy.conf")); err != nil {
+		t.Errorf("설정 파일이 없어졌습니다: %v", err)
+	}
+}
+
+func TestLoadVMConfigsBothLayouts(t *testing.T) {
+	configDir := t.TempDir()
+	flat := VMConfig{Name: "dup", RAM: "1GB"}
+	managed := VMConfig{Name: "dup", RAM: "2GB", Managed: true}
+	for _, c := range []VMConfig{flat, managed, {Name: "other", RAM: "512MB"}} {
+		if err := saveVMConfig(configDir, c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 같은 이름이면 관리형 설정만 한 번 나옴
+	configs := loadVMConfigs(configDir)
+	if len(configs) != 2 || configs[0].Name != "dup" || configs[0].RAM != "2GB" || !configs[0].Managed || configs[1].Name != "other" {
+		t.Errorf("loadVMConfigs = %+v", configs)
+	}
+	if loaded, err := loadVMConfig(configDir, "dup"); err != nil || loaded.RAM != "2GB" {
+		t.Errorf("loadVMConfig = %+v, %v", loaded, err)
+	}
+}
+
+func TestRemoveManagedVMConfig(t *testing.T) {
+	configDir := t.TempDir()
+	config := VMConfig{Name: "gone", Managed: true}
+	if err := createVMDir(configDir, config.Name); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveVMConfig(configDir, config); err != nil {
+		t.Fatal(err)
+	}
+	dir := vmStorageDir(configDir, config.Name)
+	writeTestFile(t, vmLogPath(configDir, config), "qemu: warning")
+	if err := os.Mkdir(filepath.Join(dir, "snapshots"), os.ModePerm); err != nil { // 예전 버전이 만든 빈 폴더
+		t.Fatal(err)
+	}
+
+	// 디스크가 없으면 폴더까지 지워짐
+	if err := removeVMConfig(configDir, config); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("가상머신 폴더가 남아 있습니다: %v", err)
+	}
+
+	// 디스크는 남기고 빈 하위 폴더만 지움
+	if err := createVMDir(configDir, config.Name); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveVMConfig(configDir, config); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(dir, "disk1.qcow2"), "disk")
+	if err := removeVMConfig(configDir, config); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 || entries[0].Name() != "disk1.qcow2" {
+		t.Errorf("남은 파일: %v", entries)
+	}
+}
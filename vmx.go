@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// VMware 장치 키 (ide0:1, sata0:0, scsi0:0, nvme0:0)
+var vmxDeviceKey = regexp.MustCompile(`^(ide|sata|scsi|nvme)(\d+):(\d+)$`)
+
+// VMware 컨트롤러 → 디스크 버스 (SCSI는 LSI Logic/PVSCSI 대신 virtio-scsi)
+var vmxBus = map[string]string{"ide": "ide", "sata": "ahci", "scsi": "virtio-scsi", "nvme": "nvme"}
+
+// parseVMX는 .vmx의 key = "value" 줄을 읽습니다. 키는 대소문자를 구분하지 않으므로 소문자로 맞춥니다.
+func parseVMX(r io.Reader) (map[string]string, error) {
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ".encoding") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		values[strings.ToLower(strings.TrimSpace(key))] = decodeVMXValue(strings.Trim(strings.TrimSpace(value), `"`))
+	}
+	return values, scanner.Err()
+}
+
+// VMware는 값 안의 특수문자를 |22 처럼 |와 16진수 두 자리로 씀
+func decodeVMXValue(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '|' && i+2 < len(s) {
+			if n, err := strconv.ParseUint(s[i+1:i+3], 16, 8); err == nil {
+				b.WriteByte(byte(n))
+				i += 2
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+func vmxTrue(s string) bool {
+	return strings.EqualFold(s, "TRUE")
+}
+
+// vmdkDescriptor는 VMDK의 텍스트 설명자를 읽습니다.
+// 설명자만 있는 파일과 설명자가 내장된 monolithicSparse(KDMV) 파일을 모두 지원합니다.
+func vmdkDescriptor(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	header := make([]byte, 512)
+	n, err := io.ReadFull(f, header)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	if n >= 44 && string(header[:4]) == "KDMV" {
+		// SparseExtentHeader: descriptorOffset(28), descriptorSize(36) 단위는 섹터
+		offset := binary.LittleEndian.Uint64(header[28:])
+		size := binary.LittleEndian.Uint64(header[36:])
+		if offset == 0 || size == 0 || size > 2048 {
+			return "", nil
+		}
+		desc := make([]byte, size*512)
+		if _, err := f.ReadAt(desc, int64(offset*512)); err != nil && err != io.EOF {
+			return "", err
+		}
+		return strings.TrimRight(string(desc), "\x00"), nil
+	}
+	if !strings.HasPrefix(string(header[:n]), "#") {
+		return "", nil
+	}
+	// 설명자 파일은 작으므로 64KB까지만 읽음
+	rest, err := io.ReadAll(io.LimitReader(f, 64*1024))
+	if err != nil {
+		return "", err
+	}
+	return string(header[:n]) + string(rest), nil
+}
+
+// vmdkChain은 parentFileNameHint를 따라가며 기반 이미지부터 path까지의 스냅샷 체인을 만듭니다.
+func vmdkChain(path string) ([]foreignLayer, error) {
+	var chain []foreignLayer
+	for {
+		chain = append([]foreignLayer{{path: path, format: "vmdk"}}, chain...)
+		if len(chain) > 64 {
+			return nil, fmt.Errorf("%s: 스냅샷 체인이 너무 깁니다.", path)
+		}
+		desc, err := vmdkDescriptor(path)
+		if err != nil {
+			return nil, err
+		}
+		parent := ""
+		for _, line := range strings.Split(desc, "\n") {
+			if key, value, ok := strings.Cut(strings.TrimSpace(line), "="); ok && strings.TrimSpace(key) == "parentFileNameHint" {
+				parent = strings.Trim(strings.TrimSpace(value), `"`)
+			}
+		}
+		if parent == "" {
+			return chain, nil
+		}
+		if !filepath.IsAbs(parent) {
+			parent = filepath.Join(filepath.Dir(path), parent)
+		}
+		path = parent
+	}
+}
+
+// parseVMXFile은 VMware .vmx 파일을 읽어 가져올 가상머신을 만듭니다.
+func parseVMXFile(path string) (foreignVM, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return foreignVM{}, err
+	}
+	values, err := parseVMX(f)
+	f.Close()
+	if err != nil {
+		return foreignVM{}, err
+	}
+	if values["config.version"] == "" && values["virtualhw.version"] == "" {
+		return foreignVM{}, errors.New("VMware 가상머신 설정(.vmx) 파일이 아닙니다.")
+	}
+	dir := filepath.Dir(path)
+	resolve := func(p string) string {
+		if p == "" || filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(dir, p)
+	}
+
+	var vm foreignVM
+	config := &vm.config
+	config.Name = values["displayname"]
+	config.UUID = values["uuid.bios"]
+	if config.UUID != "" {
+		// "56 4d 12 34 ... 56-78 9a ..." → 표준 UUID 형식
+		hex := strings.NewReplacer(" ", "", "-", "").Replace(config.UUID)
+		if len(hex) == 32 {
+			config.UUID = hex[:8] + "-" + hex[8:12] + "-" + hex[12:16] + "-" + hex[16:20] + "-" + hex[20:]
+		} else {
+			config.UUID = ""
+		}
+	}
+
+	vcpus, _ := strconv.Atoi(values["numvcpus"])
+	if vcpus <= 0 {
+		vcpus = 1
+	}
+	if cps, _ := strconv.Atoi(values["cpuid.corespersocket"]); cps > 0 && vcpus%cps == 0 && cps < vcpus {
+		config.CPUSockets = strconv.Itoa(vcpus / cps)
+		config.CPUCores = strconv.Itoa(cps)
+	} else {
+		config.CPUCores = strconv.Itoa(vcpus)
+	}
+	if mem, ok := importMemorySize(values["memsize"]); ok {
+		config.RAM = mem
+	}
+	if strings.EqualFold(values["firmware"], "efi") {
+		// 변수 저장소(부팅 항목)는 새로 만들어짐
+		config.Firmware = "uefi"
+	}
+
+	// 장치는 컨트롤러 종류, 번호, 슬롯 순서로 (부팅 디스크가 주로 있는 NVMe, SATA 먼저)
+	type vmxDevice struct {
+		kind       string
+		ctrl, unit int
+		key        string
+	}
+	var devices []vmxDevice
+	for key, value := range values {
+		dev, ok := strings.CutSuffix(key, ".present")
+		if !ok || !vmxTrue(value) {
+			continue
+		}
+		m := vmxDeviceKey.FindStringSubmatch(dev)
+		if m == nil || !vmxTrue(values[m[1]+m[2]+".present"]) {
+			continue
+		}
+		ctrl, _ := strconv.Atoi(m[2])
+		unit, _ := strconv.Atoi(m[3])
+		devices = append(devices, vmxDevice{kind: m[1], ctrl: ctrl, unit: unit, key: dev})
+	}
+	kinds := map[string]int{"nvme": 0, "sata": 1, "scsi": 2, "ide": 3}
+	sort.Slice(devices, func(i, j int) bool {
+		a, b := devices[i], devices[j]
+		if a.kind != b.kind {
+			return kinds[a.kind] < kinds[b.kind]
+		}
+		if a.ctrl != b.ctrl {
+			return a.ctrl < b.ctrl
+		}
+		return a.unit < b.unit
+	})
+
+	var cdroms []cdromConfig
+	scsi := false
+	for _, dev := range devices {
+		bus := vmxBus[dev.kind]
+		file := values[dev.key+".filename"]
+		switch deviceType := strings.ToLower(values[dev.key+".devicetype"]); {
+		case deviceType == "cdrom-image":
+			cdroms = append(cdroms, cdromConfig{Bus: bus, Path: resolve(file)})
+		case strings.Contains(deviceType, "cdrom"):
+			// 호스트 드라이브 연결은 빈 드라이브로
+			cdroms = append(cdroms, cdromConfig{Bus: bus})
+		case strings.EqualFold(filepath.Ext(file), ".vmdk"):
+			chain, err := vmdkChain(resolve(file))
+			if err != nil {
+				return foreignVM{}, err
+			}
+			vm.disks = append(vm.disks, foreignDisk{chain: chain, bus: bus})
+			scsi = scsi || dev.kind == "scsi"
+		}
+	}
+	config.CDROM = formatCDROMConfigs(cdroms)
+	if scsi {
+		vm.notes = append(vm.notes, "SCSI 디스크는 virtio-scsi로 연결됩니다. 게스트에 virtio 드라이버가 필요합니다.")
+	}
+
+	// 네트워크 어댑터는 첫 번째만 사용자 모드 NAT로
+	nics := 0
+	for i := 0; i < 10; i++ {
+		prefix := fmt.Sprintf("ethernet%d", i)
+		if !vmxTrue(values[prefix+".present"]) {
+			continue
+		}
+		nics++
+		if nics > 1 {
+			continue
+		}
+		config.Network = "user"
+		if strings.EqualFold(values[prefix+".addresstype"], "static") {
+			config.MAC = strings.ToLower(values[prefix+".address"])
+		} else {
+			config.MAC = strings.ToLower(values[prefix+".generatedaddress"])
+		}
+		if t := strings.ToLower(values[prefix+".connectiontype"]); t != "" && t != "nat" {
+			vm.notes = append(vm.notes, t+" 네트워크 연결은 사용자 모드 NAT로 바꾸었습니다.")
+		}
+	}
+	if nics == 0 {
+		config.Network = "none"
+	} else if nics > 1 {
+		vm.notes = append(vm.notes, fmt.Sprintf("나머지 네트워크 어댑터 %d개는 가져오지 않았습니다.", nics-1))
+	}
+	return vm, nil
+}
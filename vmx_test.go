@@ -0,0 +1,92 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseVMXFile(t *testing.T) {
+	dir := t.TempDir()
+	// 스냅샷 체인: base.vmdk ← base-000001.vmdk (설명자만 있는 파일)
+	writeTestFile(t, filepath.Join(dir, "base.vmdk"), "# Disk DescriptorFile\nversion=1\nparentCID=ffffffff\n")
+	writeTestFile(t, filepath.Join(dir, "base-000001.vmdk"),
+		"# Disk DescriptorFile\nversion=1\nparentCID=fffffffe\nparentFileNameHint=\"base.vmdk\"\n")
+	writeTestFile(t, filepath.Join(dir, "data.vmdk"), "# Disk DescriptorFile\nversion=1\n")
+	iso := filepath.Join(dir, "iso", "win10.iso")
+	vmx := filepath.Join(dir, "Win 10.vmx")
+	writeTestFile(t, vmx, `.encoding = "UTF-8"
+config.version = "8"
+virtualHW.version = "19"
+displayName = "Win 10 |22Test|22"
+uuid.bios = "56 4d 12 34 56 78 9a bc-de f0 12 34 56 78 9a bc"
+numvcpus = "4"
+cpuid.coresPerSocket = "2"
+memsize = "4096"
+firmware = "efi"
+sata0.present = "TRUE"
+sata0:0.present = "TRUE"
+sata0:0.fileName = "base-000001.vmdk"
+sata0:1.present = "TRUE"
+sata0:1.deviceType = "cdrom-image"
+sata0:1.fileName = "`+iso+`"
+scsi0.present = "TRUE"
+scsi0.virtualDev = "pvscsi"
+scsi0:0.present = "TRUE"
+scsi0:0.fileName = "data.vmdk"
+ide1:0.present = "TRUE"
+ide1:0.deviceType = "cdrom-raw"
+ide1.present = "FALSE"
+ide0.present = "TRUE"
+ide0:0.present = "TRUE"
+ide0:0.deviceType = "atapi-cdrom"
+ethernet0.present = "TRUE"
+ethernet0.connectionType = "bridged"
+ethernet0.addressType = "generated"
+ethernet0.generatedAddress = "00:0C:29:AB:CD:EF"
+ethernet1.present = "TRUE"
+`)
+	vm, err := parseVMXFile(vmx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := VMConfig{
+		Name: `Win 10 "Test"`, UUID: "564d1234-5678-9abc-def0-123456789abc",
+		CPUSockets: "2", CPUCores: "2", RAM: "4096MB", Firmware: "uefi",
+		// ide1 컨트롤러가 없으므로 ide1:0은 뺌
+		CDROM:   formatCDROMConfigs([]cdromConfig{{Bus: "ahci", Path: iso}, {Bus: "ide"}}),
+		Network: "user", MAC: "00:0c:29:ab:cd:ef",
+	}
+	if vm.config != want {
+		t.Errorf("config =\n%+v\nwant\n%+v", vm.config, want)
+	}
+	wantDisks := []foreignDisk{
+		{bus: "ahci", chain: []foreignLayer{
+			{path: filepath.Join(dir, "base.vmdk"), format: "vmdk"},
+			{path: filepath.Join(dir, "base-000001.vmdk"), format: "vmdk"},
+		}},
+		{bus: "virtio-scsi", chain: []foreignLayer{{path: filepath.Join(dir, "data.vmdk"), format: "vmdk"}}},
+	}
+	if !reflect.DeepEqual(vm.disks, wantDisks) {
+		t.Errorf("disks =\n%+v\nwant\n%+v", vm.disks, wantDisks)
+	}
+	// SCSI, bridged 연결, 나머지 NIC 안내
+	if notes := strings.Join(vm.notes, "\n"); len(vm.notes) != 3 || !strings.Contains(notes, "bridged") {
+		t.Errorf("notes = %q", vm.notes)
+	}
+
+	notVMX := filepath.Join(dir, "other.vmx")
+	writeTestFile(t, notVMX, "displayName = \"x\"\n")
+	if _, err := parseVMXFile(notVMX); err == nil {
+		t.Error("config.version이 없는 파일을 읽었습니다")
+	}
+}
+
+func TestDecodeVMXValue(t *testing.T) {
+	for in, want := range map[string]string{`a|22b|22`: `a"b"`, `C:|5cvm`: `C:\vm`, `100|`: `100|`, `|zz`: `|zz`} {
+		if got := decodeVMXValue(in); got != want {
+			t.Errorf("decodeVMXValue(%q) = %q, want %q", in, got, want)
+		}
+	}
+}